@@ -2,6 +2,7 @@ package config
 
 import (
 	"github.com/vrischmann/envconfig"
+	"time"
 )
 
 // Config provide helm broker configuration
@@ -18,14 +19,103 @@ type Config struct {
 	FbAppKey    string
 	FbAppSecret string
 
-	DbHost string
-	DbUser string
-	DbPass string
-	DbName string
-	DbPort string
+	// DbDialect is mysql, postgres or sqlite3, DbName is the database file for sqlite3
+	// which needs a binary built with cgo
+	DbDialect string `envconfig:"default=mysql"`
+	DbHost    string `envconfig:"optional"`
+	DbUser    string `envconfig:"optional"`
+	DbPass    string `envconfig:"optional"`
+	DbName    string
+	DbPort    string `envconfig:"optional"`
+	DbSSLMode string `envconfig:"default=disable"`
+
+	// MigrateOnStart applies pending schema migrations before serving, they can also be applied with the migrate command
+	MigrateOnStart       bool          `envconfig:"default=true"`
+	MigrationLockTimeout time.Duration `envconfig:"default=1m"`
+	// SeedFixtures are the files or directories loaded by the seed command when none are given
+	SeedFixtures string `envconfig:"default=fixtures"`
+	// SeedPassword is given to seeded users without a password, a random one is generated and printed when empty
+	SeedPassword string `envconfig:"optional"`
 
 	PassPercent float32 `envconfig:"optional"`
-	InitDB      bool    `envconfig:"optional" default:"false"`
+
+	MistakeStreak int `envconfig:"default=3"`
+
+	DailyGoal         int `envconfig:"default=20"`
+	StreakFreezeEvery int `envconfig:"default=7"`
+	StreakFreezeMax   int `envconfig:"default=2"`
+
+	AchievementsPath string `envconfig:"default=achievements.yaml"`
+
+	XpPerTask              int     `envconfig:"default=10"`
+	XpPerPuzzleTask        int     `envconfig:"default=10"`
+	XpPerfectBonus         int     `envconfig:"default=0"`
+	XpFirstCompletionBonus int     `envconfig:"default=0"`
+	XpRepeatPercent        float32 `envconfig:"default=1"`
+
+	// LevelCurve is one of linear, polynomial or table
+	LevelCurve         string  `envconfig:"default=linear"`
+	LevelCurveBase     float64 `envconfig:"default=100"`
+	LevelCurveStep     float64 `envconfig:"default=10"`
+	LevelCurveFactor   float64 `envconfig:"default=1.2"`
+	LevelCurveExponent float64 `envconfig:"default=1.5"`
+	LevelTable         []int   `envconfig:"optional"`
+
+	LeagueCohortSize int `envconfig:"default=30"`
+	LeagueTiers      int `envconfig:"default=5"`
+	LeaguePromote    int `envconfig:"default=5"`
+	LeagueRelegate   int `envconfig:"default=5"`
+	// LeagueCutoffDay and LeagueCutoffHour (UTC) define when the league week ends
+	LeagueCutoffDay  string        `envconfig:"default=sunday"`
+	LeagueCutoffHour int           `envconfig:"default=20"`
+	LeagueJobPeriod  time.Duration `envconfig:"default=1m"`
+
+	ChallengeTaskCount     int           `envconfig:"default=10"`
+	ChallengeDefaultExpiry time.Duration `envconfig:"default=24h"`
+	ChallengeMaxExpiry     time.Duration `envconfig:"default=168h"`
+	ChallengeJobPeriod     time.Duration `envconfig:"default=1m"`
+
+	LiveQuestionTime time.Duration `envconfig:"default=20s"`
+	LiveRevealTime   time.Duration `envconfig:"default=5s"`
+	LiveLobbyTimeout time.Duration `envconfig:"default=30m"`
+	LiveMaxPoints    int           `envconfig:"default=1000"`
+	LiveMaxPlayers   int           `envconfig:"default=100"`
+
+	// BlobBackend is either local or s3, BlobPublicURL prefixes keys in the image urls
+	BlobBackend   string `envconfig:"default=local"`
+	BlobLocalDir  string `envconfig:"default=images"`
+	BlobPublicURL string `envconfig:"default=/images"`
+
+	ImageMaxBytes  int64 `envconfig:"default=5242880"`
+	ImageMaxWidth  int   `envconfig:"default=4096"`
+	ImageMaxHeight int   `envconfig:"default=4096"`
+	// ImageGCGrace is how long an unreferenced image is kept, uploads are saved before the entity using them
+	ImageGCGrace  time.Duration `envconfig:"default=72h"`
+	ImageGCPeriod time.Duration `envconfig:"default=6h"`
+	// ImageImportTimeout limits fetching a remote image linked from a task
+	ImageImportTimeout time.Duration `envconfig:"default=15s"`
+	ImageImportPeriod  time.Duration `envconfig:"default=1h"`
+
+	AudioMaxBytes int64 `envconfig:"default=2097152"`
+	// SpeechProvider fills in missing task audio: none, http or fake
+	SpeechProvider string        `envconfig:"default=none"`
+	SpeechURL      string        `envconfig:"optional"`
+	SpeechTimeout  time.Duration `envconfig:"default=15s"`
+	SpeechPeriod   time.Duration `envconfig:"default=1h"`
+	SpeechBatch    int           `envconfig:"default=50"`
+
+	// MediaSigningKey signs media urls of private courses, it is required unless DbDialect is sqlite3
+	// which uses a random one when empty so signed urls stop working after a restart
+	MediaSigningKey string        `envconfig:"optional"`
+	MediaURLTTL     time.Duration `envconfig:"default=1h"`
+
+	// S3Endpoint is the base url of an S3 compatible service, e.g. http://minio:9000
+	S3Endpoint  string `envconfig:"optional"`
+	S3Region    string `envconfig:"default=us-east-1"`
+	S3Bucket    string `envconfig:"optional"`
+	S3AccessKey string `envconfig:"optional"`
+	S3SecretKey string `envconfig:"optional"`
+	S3PathStyle bool   `envconfig:"default=true"`
 }
 
 func NewConfig() (*Config, error) {
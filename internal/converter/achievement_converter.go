@@ -0,0 +1,51 @@
+package converter
+
+import (
+	"github.com/best-project/api/internal"
+)
+
+type AchievementConverter struct{}
+
+func NewAchievementConverter() *AchievementConverter {
+	return &AchievementConverter{}
+}
+
+func (a *AchievementConverter) RuleToDTO(rule internal.AchievementRule) internal.AchievementDTO {
+	return internal.AchievementDTO{
+		ID:          rule.ID,
+		Name:        rule.Name,
+		Description: rule.Description,
+		XP:          rule.XP,
+	}
+}
+
+func (a *AchievementConverter) ManyRulesToDTO(rules []internal.AchievementRule) []internal.AchievementDTO {
+	result := make([]internal.AchievementDTO, 0)
+	for _, rule := range rules {
+		result = append(result, a.RuleToDTO(rule))
+	}
+
+	return result
+}
+
+// ManyToDTO describes unlocked achievements, achievements removed from the rules are skipped
+func (a *AchievementConverter) ManyToDTO(unlocked []internal.UserAchievement, rules []internal.AchievementRule) []internal.AchievementDTO {
+	ruleByID := make(map[string]internal.AchievementRule, len(rules))
+	for _, rule := range rules {
+		ruleByID[rule.ID] = rule
+	}
+
+	result := make([]internal.AchievementDTO, 0)
+	for _, achievement := range unlocked {
+		rule, ok := ruleByID[achievement.AchievementID]
+		if !ok {
+			continue
+		}
+		dto := a.RuleToDTO(rule)
+		unlockedAt := achievement.UnlockedAt
+		dto.UnlockedAt = &unlockedAt
+		result = append(result, dto)
+	}
+
+	return result
+}
@@ -0,0 +1,93 @@
+package converter
+
+import (
+	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/service"
+	"strconv"
+)
+
+type ClassroomConverter struct{}
+
+func NewClassroomConverter() *ClassroomConverter {
+	return &ClassroomConverter{}
+}
+
+// ToDTO describes the classroom to the member, the join code is shown to teachers only
+func (c *ClassroomConverter) ToDTO(classroom internal.Classroom, role string) internal.ClassroomDTO {
+	dto := internal.ClassroomDTO{
+		ClassroomID: strconv.Itoa(int(classroom.ID)),
+		Name:        classroom.Name,
+		TeacherID:   classroom.TeacherID,
+		Role:        role,
+		Assignments: make([]internal.AssignmentDTO, 0),
+	}
+	if role == internal.TeacherRole {
+		dto.JoinCode = classroom.JoinCode
+	}
+
+	return dto
+}
+
+func (c *ClassroomConverter) AssignmentToDTO(assignment internal.Assignment, courseNames map[uint]string) internal.AssignmentDTO {
+	return internal.AssignmentDTO{
+		AssignmentID: strconv.Itoa(int(assignment.ID)),
+		CourseID:     strconv.Itoa(int(assignment.CourseID)),
+		CourseName:   courseNames[assignment.CourseID],
+		DueAt:        assignment.DueAt,
+	}
+}
+
+func (c *ClassroomConverter) ManyAssignmentsToDTO(assignments []internal.Assignment, courseNames map[uint]string) []internal.AssignmentDTO {
+	result := make([]internal.AssignmentDTO, 0)
+	for _, assignment := range assignments {
+		result = append(result, c.AssignmentToDTO(assignment, courseNames))
+	}
+
+	return result
+}
+
+func (c *ClassroomConverter) FetchCourseIDs(assignments []internal.Assignment) []string {
+	result := make([]string, 0)
+	for _, assignment := range assignments {
+		result = append(result, strconv.Itoa(int(assignment.CourseID)))
+	}
+
+	return unique(result)
+}
+
+func (c *ClassroomConverter) DashboardToDTO(dashboard *service.ClassroomDashboard, users []internal.User, courseNames map[uint]string) internal.ClassroomDashboardDTO {
+	userByID := make(map[uint]internal.User, len(users))
+	for _, user := range users {
+		userByID[user.ID] = user
+	}
+
+	dto := internal.ClassroomDashboardDTO{
+		ClassroomID: strconv.Itoa(int(dashboard.Classroom.ID)),
+		Assignments: c.ManyAssignmentsToDTO(dashboard.Assignments, courseNames),
+		Students:    make([]internal.StudentProgressDTO, 0),
+	}
+	for _, student := range dashboard.Students {
+		user := userByID[student.UserID]
+		progressDTO := internal.StudentProgressDTO{
+			UserID:      student.UserID,
+			Email:       user.Email,
+			Avatar:      user.Avatar,
+			Overdue:     student.Overdue,
+			Assignments: make([]internal.AssignmentProgressDTO, 0),
+		}
+		for _, progress := range student.Assignments {
+			progressDTO.Assignments = append(progressDTO.Assignments, internal.AssignmentProgressDTO{
+				AssignmentID: strconv.Itoa(int(progress.ID)),
+				CourseID:     strconv.Itoa(int(progress.CourseID)),
+				BestPoints:   progress.BestPoints,
+				Passed:       progress.Passed,
+				Attempts:     progress.Attempts,
+				TimeSpentMs:  progress.TimeSpent,
+				Overdue:      progress.Overdue,
+			})
+		}
+		dto.Students = append(dto.Students, progressDTO)
+	}
+
+	return dto
+}
@@ -8,12 +8,26 @@ import (
 type Converter struct {
 	CourseConverter       *CourseConverter
 	CourseResultConverter *CourseResultConverter
+	MistakeConverter      *MistakeConverter
+	AchievementConverter  *AchievementConverter
+	LeagueConverter       *LeagueConverter
+	LeaderboardConverter  *LeaderboardConverter
+	FeedConverter         *FeedConverter
+	ClassroomConverter    *ClassroomConverter
+	NotificationConverter *NotificationConverter
 }
 
 func NewConverter() *Converter {
 	return &Converter{
 		CourseConverter:       NewCourseConverter(&TaskConverter{}),
 		CourseResultConverter: NewCourseResultConverter(),
+		MistakeConverter:      NewMistakeConverter(),
+		AchievementConverter:  NewAchievementConverter(),
+		LeagueConverter:       NewLeagueConverter(),
+		LeaderboardConverter:  NewLeaderboardConverter(),
+		FeedConverter:         NewFeedConverter(),
+		ClassroomConverter:    NewClassroomConverter(),
+		NotificationConverter: NewNotificationConverter(),
 	}
 }
 
@@ -69,6 +83,22 @@ func (u *Converter) ToDTO(dto internal.User) (*internal.UserDTO, error) {
 		Points:    dto.Points,
 		Email:     dto.Email,
 		ID:        int(dto.ID),
+
+		TimeZone:      dto.TimeZone,
+		DailyGoal:     dto.DailyGoal,
+		CurrentStreak: dto.CurrentStreak,
+		LongestStreak: dto.LongestStreak,
+		StreakFreezes: dto.StreakFreezes,
+
+		HideResults:  dto.HideResults,
+		HideLevelUps: dto.HideLevelUps,
+		HideBadges:   dto.HideBadges,
+		HideCourses:  dto.HideCourses,
+		HideFollows:  dto.HideFollows,
+
+		ChallengeWins:   dto.ChallengeWins,
+		ChallengeLosses: dto.ChallengeLosses,
+		ChallengeDraws:  dto.ChallengeDraws,
 	}, nil
 }
 
@@ -26,8 +26,13 @@ func (c *CourseConverter) ToModel(dto *internal.CourseDTO) *internal.Course {
 		MaxPoints:       dto.MaxPoints,
 		Rate:            dto.Rate,
 		Type:            dto.Type,
+		Private:         dto.Private,
 		UserID:          dto.UserID,
 		Task:            c.TaskConverter.ManyToModel(dto.Data),
+		PassPercent:     dto.PassPercent,
+		ExamTimeLimit:   dto.ExamTimeLimit,
+		ExamTaskCount:   dto.ExamTaskCount,
+		ExamCooldown:    dto.ExamCooldown,
 	}
 	if dto.CourseID != "" {
 		id, _ := strconv.Atoi(dto.CourseID)
@@ -51,6 +56,7 @@ func (c *CourseConverter) ToDTO(dto *internal.Course) (*internal.CourseDTO, erro
 	return &internal.CourseDTO{
 		CourseID:        strconv.Itoa(int(dto.ID)),
 		Type:            dto.Type,
+		Private:         dto.Private,
 		Name:            dto.Name,
 		Rate:            dto.Rate,
 		Image:           dto.Image,
@@ -60,6 +66,11 @@ func (c *CourseConverter) ToDTO(dto *internal.Course) (*internal.CourseDTO, erro
 		Description:     dto.Description,
 		Data:            c.TaskConverter.ManyToDTO(dto.Task),
 		UserID:          dto.UserID,
+		PassPercent:     dto.PassPercent,
+		ExamTimeLimit:   dto.ExamTimeLimit,
+		ExamTaskCount:   dto.ExamTaskCount,
+		ExamCooldown:    dto.ExamCooldown,
+		DifficultyScore: dto.DifficultyScore,
 	}, nil
 }
 
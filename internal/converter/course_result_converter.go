@@ -14,12 +14,17 @@ func NewCourseResultConverter() *CourseResultConverter {
 
 func (c *CourseResultConverter) ToModel(dto *internal.CourseResultDTO) *internal.CourseResult {
 	id, _ := strconv.Atoi(dto.CourseID)
+	direction := dto.Direction
+	if direction == "" {
+		direction = internal.ForwardDirection
+	}
 	return &internal.CourseResult{
-		Points:   dto.Points,
-		CourseID: uint(id),
-		UserID:   dto.UserID,
-		Phase:    dto.Phase,
-		Passed:   dto.Passed,
+		Points:    dto.Points,
+		CourseID:  uint(id),
+		UserID:    dto.UserID,
+		Phase:     dto.Phase,
+		Passed:    dto.Passed,
+		Direction: direction,
 	}
 }
 
@@ -44,11 +49,12 @@ func (c *CourseResultConverter) ManyToModel(dtos []internal.CourseResultDTO) []i
 
 func (c *CourseResultConverter) ToDTO(dto *internal.CourseResult) (*internal.CourseResultDTO, error) {
 	return &internal.CourseResultDTO{
-		CourseID: strconv.Itoa(int(dto.CourseID)),
-		Points:   dto.Points,
-		UserID:   dto.UserID,
-		Phase:    dto.Phase,
-		Passed:   dto.Passed,
+		CourseID:  strconv.Itoa(int(dto.CourseID)),
+		Points:    dto.Points,
+		UserID:    dto.UserID,
+		Phase:     dto.Phase,
+		Passed:    dto.Passed,
+		Direction: dto.Direction,
 	}, nil
 }
 
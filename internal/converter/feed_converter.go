@@ -0,0 +1,69 @@
+package converter
+
+import (
+	"github.com/best-project/api/internal"
+	"strconv"
+)
+
+type FeedConverter struct{}
+
+func NewFeedConverter() *FeedConverter {
+	return &FeedConverter{}
+}
+
+func (f *FeedConverter) FetchUserIDs(items []internal.FeedItem) []uint {
+	result := make([]uint, 0)
+	for _, item := range items {
+		result = append(result, item.UserID)
+	}
+
+	return result
+}
+
+func (f *FeedConverter) FetchCourseIDs(items []internal.FeedItem) []string {
+	result := make([]string, 0)
+	for _, item := range items {
+		if item.CourseID != 0 {
+			result = append(result, strconv.Itoa(int(item.CourseID)))
+		}
+	}
+
+	return unique(result)
+}
+
+// ManyToDTO describes feed items, items of removed users are skipped
+func (f *FeedConverter) ManyToDTO(items []internal.FeedItem, users []internal.User, courses []*internal.Course) []internal.FeedItemDTO {
+	userByID := make(map[uint]internal.User, len(users))
+	for _, user := range users {
+		userByID[user.ID] = user
+	}
+	courseByID := make(map[uint]*internal.Course, len(courses))
+	for _, course := range courses {
+		courseByID[course.ID] = course
+	}
+
+	result := make([]internal.FeedItemDTO, 0)
+	for _, item := range items {
+		user, ok := userByID[item.UserID]
+		if !ok {
+			continue
+		}
+		dto := internal.FeedItemDTO{
+			ID:        item.ID,
+			Type:      item.Type,
+			UserID:    user.ID,
+			Email:     user.Email,
+			Avatar:    user.Avatar,
+			Value:     item.Value,
+			Name:      item.Name,
+			CreatedAt: item.CreatedAt,
+		}
+		if course, ok := courseByID[item.CourseID]; ok {
+			dto.CourseID = strconv.Itoa(int(course.ID))
+			dto.CourseName = course.Name
+		}
+		result = append(result, dto)
+	}
+
+	return result
+}
@@ -0,0 +1,65 @@
+package converter
+
+import (
+	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/service"
+)
+
+type LeaderboardConverter struct{}
+
+func NewLeaderboardConverter() *LeaderboardConverter {
+	return &LeaderboardConverter{}
+}
+
+func (l *LeaderboardConverter) FetchUserIDs(board *service.Leaderboard) []uint {
+	result := make([]uint, 0)
+	for _, entry := range board.Entries {
+		result = append(result, entry.UserID)
+	}
+	if board.Me != nil {
+		result = append(result, board.Me.UserID)
+	}
+
+	return result
+}
+
+// ToDTO describes the leaderboard, entries without a matching user are skipped
+func (l *LeaderboardConverter) ToDTO(board *service.Leaderboard, users []internal.User) internal.LeaderboardDTO {
+	userByID := make(map[uint]internal.User, len(users))
+	for _, user := range users {
+		userByID[user.ID] = user
+	}
+
+	dto := internal.LeaderboardDTO{
+		Window:  board.Window,
+		Period:  board.Period,
+		Scope:   board.Scope,
+		ScopeID: board.ScopeID,
+		Total:   board.Total,
+		Entries: make([]internal.LeaderboardEntryDTO, 0),
+	}
+	for _, entry := range board.Entries {
+		if user, ok := userByID[entry.UserID]; ok {
+			dto.Entries = append(dto.Entries, l.entryToDTO(entry, user))
+		}
+	}
+	if board.Me != nil {
+		if user, ok := userByID[board.Me.UserID]; ok {
+			me := l.entryToDTO(*board.Me, user)
+			dto.Me = &me
+		}
+	}
+
+	return dto
+}
+
+func (l *LeaderboardConverter) entryToDTO(entry service.RankedScore, user internal.User) internal.LeaderboardEntryDTO {
+	return internal.LeaderboardEntryDTO{
+		Rank:   entry.Rank,
+		UserID: user.ID,
+		Email:  user.Email,
+		Avatar: user.Avatar,
+		Level:  user.Level,
+		XP:     entry.XP,
+	}
+}
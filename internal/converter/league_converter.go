@@ -0,0 +1,66 @@
+package converter
+
+import (
+	"github.com/best-project/api/internal"
+)
+
+type LeagueConverter struct{}
+
+func NewLeagueConverter() *LeagueConverter {
+	return &LeagueConverter{}
+}
+
+// ToDTO describes the league standings, members without a matching user are skipped
+func (l *LeagueConverter) ToDTO(league internal.League, members []internal.LeagueMember, users []internal.User) internal.LeagueDTO {
+	userByID := make(map[uint]internal.User, len(users))
+	for _, user := range users {
+		userByID[user.ID] = user
+	}
+
+	dto := internal.LeagueDTO{
+		Tier:      league.Tier,
+		WeekStart: league.Week,
+		WeekEnd:   league.Week.AddDate(0, 0, 7),
+		Members:   make([]internal.LeagueMemberDTO, 0),
+	}
+	for _, member := range members {
+		user, ok := userByID[member.UserID]
+		if !ok {
+			continue
+		}
+		dto.Members = append(dto.Members, internal.LeagueMemberDTO{
+			UserID: user.ID,
+			Email:  user.Email,
+			Avatar: user.Avatar,
+			XP:     member.XP,
+			Rank:   member.Rank,
+		})
+	}
+
+	return dto
+}
+
+func (l *LeagueConverter) ManyHistoryToDTO(members []internal.LeagueMember, leagues map[uint]internal.League) []internal.LeagueHistoryDTO {
+	result := make([]internal.LeagueHistoryDTO, 0)
+	for _, member := range members {
+		league := leagues[member.LeagueID]
+		result = append(result, internal.LeagueHistoryDTO{
+			Tier:      league.Tier,
+			WeekStart: league.Week,
+			XP:        member.XP,
+			Rank:      member.Rank,
+			Result:    member.Result,
+		})
+	}
+
+	return result
+}
+
+func (l *LeagueConverter) FetchUserIDs(members []internal.LeagueMember) []uint {
+	result := make([]uint, 0)
+	for _, member := range members {
+		result = append(result, member.UserID)
+	}
+
+	return result
+}
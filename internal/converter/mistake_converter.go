@@ -0,0 +1,70 @@
+package converter
+
+import (
+	"github.com/best-project/api/internal"
+	"strconv"
+)
+
+type MistakeConverter struct{}
+
+func NewMistakeConverter() *MistakeConverter {
+	return &MistakeConverter{}
+}
+
+func (m *MistakeConverter) FetchTaskIDs(mistakes []internal.Mistake) []uint {
+	result := make([]uint, 0)
+	for _, mistake := range mistakes {
+		result = append(result, mistake.TaskID)
+	}
+
+	return result
+}
+
+func (m *MistakeConverter) ToDTO(mistake internal.Mistake, task internal.Task) internal.MistakeDTO {
+	return internal.MistakeDTO{
+		TaskID:        strconv.Itoa(int(mistake.TaskID)),
+		CourseID:      strconv.Itoa(int(mistake.CourseID)),
+		Word:          task.Word,
+		Translate:     task.Translate,
+		Image:         task.Image,
+		Direction:     mistake.Direction,
+		Count:         mistake.Count,
+		CorrectStreak: mistake.CorrectStreak,
+		LastSeen:      mistake.LastSeen,
+	}
+}
+
+func (m *MistakeConverter) ManyToDTO(mistakes []internal.Mistake, tasks []internal.Task) []internal.MistakeDTO {
+	taskByID := make(map[uint]internal.Task, len(tasks))
+	for _, task := range tasks {
+		taskByID[task.ID] = task
+	}
+
+	result := make([]internal.MistakeDTO, 0)
+	for _, mistake := range mistakes {
+		task, ok := taskByID[mistake.TaskID]
+		if !ok {
+			continue
+		}
+		result = append(result, m.ToDTO(mistake, task))
+	}
+
+	return result
+}
+
+// ManyToTaskDTO converts mistakes to tasks asked in the direction in which they were missed
+func (m *MistakeConverter) ManyToTaskDTO(mistakes []internal.Mistake, tasks []internal.Task) []internal.TaskDTO {
+	result := make([]internal.TaskDTO, 0)
+	for _, mistake := range m.ManyToDTO(mistakes, tasks) {
+		result = append(result, internal.TaskDTO{
+			ID:        mistake.TaskID,
+			CourseID:  mistake.CourseID,
+			Word:      mistake.Word,
+			Translate: mistake.Translate,
+			Image:     mistake.Image,
+			Direction: mistake.Direction,
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,71 @@
+package converter
+
+import (
+	"github.com/best-project/api/internal"
+	"strconv"
+)
+
+type NotificationConverter struct{}
+
+func NewNotificationConverter() *NotificationConverter {
+	return &NotificationConverter{}
+}
+
+func (n *NotificationConverter) FetchActorIDs(notifications []internal.Notification) []uint {
+	result := make([]uint, 0)
+	for _, notification := range notifications {
+		if notification.ActorID != 0 {
+			result = append(result, notification.ActorID)
+		}
+	}
+
+	return result
+}
+
+func (n *NotificationConverter) FetchCourseIDs(notifications []internal.Notification) []string {
+	result := make([]string, 0)
+	for _, notification := range notifications {
+		if notification.CourseID != 0 {
+			result = append(result, strconv.Itoa(int(notification.CourseID)))
+		}
+	}
+
+	return unique(result)
+}
+
+// ManyToDTO describes notifications, names of removed users and courses are left out
+func (n *NotificationConverter) ManyToDTO(notifications []internal.Notification, actors []internal.User, courses []*internal.Course) []internal.NotificationDTO {
+	actorByID := make(map[uint]internal.User, len(actors))
+	for _, actor := range actors {
+		actorByID[actor.ID] = actor
+	}
+	courseByID := make(map[uint]*internal.Course, len(courses))
+	for _, course := range courses {
+		courseByID[course.ID] = course
+	}
+
+	result := make([]internal.NotificationDTO, 0)
+	for _, notification := range notifications {
+		dto := internal.NotificationDTO{
+			ID:        notification.ID,
+			Type:      notification.Type,
+			Read:      notification.Read,
+			ActorID:   notification.ActorID,
+			RefID:     notification.RefID,
+			Value:     notification.Value,
+			Name:      notification.Name,
+			CreatedAt: notification.CreatedAt,
+		}
+		if actor, ok := actorByID[notification.ActorID]; ok {
+			dto.ActorName = actor.Email
+			dto.ActorAvatar = actor.Avatar
+		}
+		if course, ok := courseByID[notification.CourseID]; ok {
+			dto.CourseID = strconv.Itoa(int(course.ID))
+			dto.CourseName = course.Name
+		}
+		result = append(result, dto)
+	}
+
+	return result
+}
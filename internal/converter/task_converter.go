@@ -11,6 +11,7 @@ func (*TaskConverter) ConvertToModel(dto internal.TaskDTO) internal.Task {
 	id, _ := strconv.Atoi(dto.CourseID)
 	return internal.Task{
 		Image:     dto.Image,
+		Audio:     dto.Audio,
 		Translate: dto.Translate,
 		Word:      dto.Word,
 		CourseID:  uint(id),
@@ -32,6 +33,7 @@ func (*TaskConverter) ConvertToDTO(dto internal.Task) internal.TaskDTO {
 		ID:        strconv.Itoa(int(dto.ID)),
 		CourseID:  strconv.Itoa(int(dto.ID)),
 		Image:     dto.Image,
+		Audio:     dto.Audio,
 		Translate: dto.Translate,
 		Word:      dto.Word,
 	}
@@ -49,3 +51,28 @@ func (t *TaskConverter) ManyToDTO(dto []internal.Task) []internal.TaskDTO {
 
 	return model
 }
+
+func (t *TaskConverter) ManyStatsToDTO(stats []internal.TaskStat, tasks []internal.Task) []internal.TaskStatDTO {
+	taskByID := make(map[uint]internal.Task, len(tasks))
+	for _, task := range tasks {
+		taskByID[task.ID] = task
+	}
+
+	result := make([]internal.TaskStatDTO, 0)
+	for _, stat := range stats {
+		task, ok := taskByID[stat.TaskID]
+		if !ok {
+			continue
+		}
+		result = append(result, internal.TaskStatDTO{
+			TaskID:      strconv.Itoa(int(stat.TaskID)),
+			Word:        task.Word,
+			Translate:   task.Translate,
+			Attempts:    stat.Attempts,
+			SuccessRate: float32(stat.Correct) / float32(stat.Attempts),
+			AvgTimeMs:   int(stat.AvgDuration),
+		})
+	}
+
+	return result
+}
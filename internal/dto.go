@@ -1,5 +1,9 @@
 package internal
 
+import (
+	"time"
+)
+
 type CourseDTO struct {
 	CourseID string `json:"id"`
 	UserID   uint   `json:"userId"`
@@ -16,7 +20,15 @@ type CourseDTO struct {
 
 	BestPoints int `json:"bestPoints"`
 
-	Type string `json:"type" validate:"required"`
+	Type    string `json:"type" validate:"required"`
+	Private bool   `json:"private"`
+
+	PassPercent   float32 `json:"passPercent"`
+	ExamTimeLimit int     `json:"examTimeLimit"`
+	ExamTaskCount int     `json:"examTaskCount"`
+	ExamCooldown  int     `json:"examCooldown"`
+
+	DifficultyScore float32 `json:"difficultyScore"`
 }
 
 type CourseResultDTO struct {
@@ -24,8 +36,9 @@ type CourseResultDTO struct {
 	CourseID string `json:"courseId" validate:"required"`
 	UserID   uint   `json:"userId"`
 
-	Points uint `json:"points"`
-	Passed bool `json:"passed"`
+	Points    uint   `json:"points"`
+	Passed    bool   `json:"passed"`
+	Direction string `json:"direction"`
 }
 
 type TaskDTO struct {
@@ -34,6 +47,8 @@ type TaskDTO struct {
 	Word      string `json:"word" validate:"required"`
 	Translate string `json:"translate" validate:"required"`
 	Image     string `json:"image"`
+	Audio     string `json:"audio,omitempty"`
+	Direction string `json:"direction,omitempty"`
 }
 
 type UserDTO struct {
@@ -51,6 +66,25 @@ type UserDTO struct {
 	Level     int    `json:"level"`
 	NextLevel string `json:"nextLevel"`
 	Points    int    `json:"points"`
+
+	TimeZone      string `json:"timeZone"`
+	DailyGoal     int    `json:"dailyGoal"`
+	DailyXP       int    `json:"dailyXp"`
+	CurrentStreak int    `json:"currentStreak"`
+	LongestStreak int    `json:"longestStreak"`
+	StreakFreezes int    `json:"streakFreezes"`
+
+	Achievements []AchievementDTO `json:"achievements"`
+
+	HideResults  bool `json:"hideResults"`
+	HideLevelUps bool `json:"hideLevelUps"`
+	HideBadges   bool `json:"hideBadges"`
+	HideCourses  bool `json:"hideCourses"`
+	HideFollows  bool `json:"hideFollows"`
+
+	ChallengeWins   int `json:"challengeWins"`
+	ChallengeLosses int `json:"challengeLosses"`
+	ChallengeDraws  int `json:"challengeDraws"`
 }
 
 type UserStatDTO struct {
@@ -60,3 +94,265 @@ type UserStatDTO struct {
 	Points int    `json:"points"`
 	Level  int    `json:"level"`
 }
+
+type AnswerDTO struct {
+	TaskID    string `json:"taskId" validate:"required"`
+	Answer    string `json:"answer"`
+	Direction string `json:"direction"`
+	TimeMs    int    `json:"timeMs" validate:"min=0,max=3600000"`
+}
+
+type MistakeDTO struct {
+	TaskID    string `json:"taskId"`
+	CourseID  string `json:"courseId"`
+	Word      string `json:"word"`
+	Translate string `json:"translate"`
+	Image     string `json:"image"`
+	Direction string `json:"direction"`
+
+	Count         int       `json:"count"`
+	CorrectStreak int       `json:"correctStreak"`
+	LastSeen      time.Time `json:"lastSeen"`
+}
+
+type PuzzleSolutionDTO struct {
+	Seed       int64        `json:"seed"`
+	Anagrams   []AnswerDTO  `json:"anagrams"`
+	Crossword  []AnswerDTO  `json:"crossword"`
+	WordSearch []PuzzleSpan `json:"wordSearch"`
+}
+
+type PuzzleSpan struct {
+	Row    int `json:"row"`
+	Col    int `json:"col"`
+	EndRow int `json:"endRow"`
+	EndCol int `json:"endCol"`
+}
+
+type PuzzleResultDTO struct {
+	CourseID   string   `json:"courseId"`
+	Seed       int64    `json:"seed"`
+	Anagrams   []string `json:"anagrams"`
+	Crossword  []string `json:"crossword"`
+	WordSearch []string `json:"wordSearch"`
+	Points     uint     `json:"points"`
+	MaxPoints  int      `json:"maxPoints"`
+}
+
+type ExamDTO struct {
+	ExamID    string    `json:"examId"`
+	CourseID  string    `json:"courseId"`
+	Deadline  time.Time `json:"deadline"`
+	TimeLimit int       `json:"timeLimit"`
+	Tasks     []TaskDTO `json:"tasks"`
+}
+
+type ExamAnswersDTO struct {
+	ExamID  string      `json:"examId" validate:"required"`
+	Answers []AnswerDTO `json:"answers"`
+}
+
+type ExamResultDTO struct {
+	ExamID          string `json:"examId"`
+	CourseID        string `json:"courseId"`
+	Points          uint   `json:"points"`
+	MaxPoints       int    `json:"maxPoints"`
+	Passed          bool   `json:"passed"`
+	CertificateCode string `json:"certificateCode,omitempty"`
+}
+
+type CertificateDTO struct {
+	Code       string    `json:"code"`
+	UserID     uint      `json:"userId"`
+	UserName   string    `json:"userName"`
+	CourseID   string    `json:"courseId"`
+	CourseName string    `json:"courseName"`
+	Points     uint      `json:"points"`
+	MaxPoints  int       `json:"maxPoints"`
+	IssuedAt   time.Time `json:"issuedAt"`
+}
+
+type TaskStatDTO struct {
+	TaskID      string  `json:"taskId"`
+	Word        string  `json:"word"`
+	Translate   string  `json:"translate"`
+	Attempts    int     `json:"attempts"`
+	SuccessRate float32 `json:"successRate"`
+	AvgTimeMs   int     `json:"avgTimeMs"`
+}
+
+type CourseDifficultyDTO struct {
+	CourseID        string        `json:"courseId"`
+	DifficultyScore float32       `json:"difficultyScore"`
+	Tasks           []TaskStatDTO `json:"tasks"`
+}
+
+type AchievementDTO struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	XP          int        `json:"xp"`
+	UnlockedAt  *time.Time `json:"unlockedAt,omitempty"`
+}
+
+type LeagueDTO struct {
+	Tier      int               `json:"tier"`
+	WeekStart time.Time         `json:"weekStart"`
+	WeekEnd   time.Time         `json:"weekEnd"`
+	Promote   int               `json:"promote"`
+	Relegate  int               `json:"relegate"`
+	Members   []LeagueMemberDTO `json:"members"`
+}
+
+type LeagueMemberDTO struct {
+	UserID uint   `json:"userId"`
+	Email  string `json:"username"`
+	Avatar string `json:"avatar"`
+	XP     int    `json:"xp"`
+	Rank   int    `json:"rank"`
+}
+
+type LeagueHistoryDTO struct {
+	Tier      int       `json:"tier"`
+	WeekStart time.Time `json:"weekStart"`
+	XP        int       `json:"xp"`
+	Rank      int       `json:"rank"`
+	Result    string    `json:"result"`
+}
+
+type LeaderboardDTO struct {
+	Window  string                `json:"window"`
+	Period  string                `json:"period"`
+	Scope   string                `json:"scope"`
+	ScopeID string                `json:"scopeId,omitempty"`
+	Total   int                   `json:"total"`
+	Entries []LeaderboardEntryDTO `json:"entries"`
+	Me      *LeaderboardEntryDTO  `json:"me,omitempty"`
+}
+
+type LeaderboardEntryDTO struct {
+	Rank   int    `json:"rank"`
+	UserID uint   `json:"userId"`
+	Email  string `json:"username"`
+	Avatar string `json:"avatar"`
+	Level  int    `json:"level"`
+	XP     int    `json:"xp"`
+}
+
+type FeedItemDTO struct {
+	ID         uint      `json:"id"`
+	Type       string    `json:"type"`
+	UserID     uint      `json:"userId"`
+	Email      string    `json:"username"`
+	Avatar     string    `json:"avatar"`
+	CourseID   string    `json:"courseId,omitempty"`
+	CourseName string    `json:"courseName,omitempty"`
+	Value      int       `json:"value,omitempty"`
+	Name       string    `json:"name,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type ChallengeCreateDTO struct {
+	CourseID   string `json:"courseId" validate:"required"`
+	OpponentID uint   `json:"opponentId" validate:"required"`
+	// ExpiresIn in seconds, the configured default is used when not set
+	ExpiresIn int `json:"expiresIn" validate:"min=0"`
+}
+
+type ChallengeDTO struct {
+	ChallengeID      string     `json:"challengeId"`
+	CourseID         string     `json:"courseId"`
+	ChallengerID     uint       `json:"challengerId"`
+	OpponentID       uint       `json:"opponentId"`
+	ExpiresAt        time.Time  `json:"expiresAt"`
+	Status           string     `json:"status"`
+	MaxPoints        int        `json:"maxPoints"`
+	ChallengerPoints *uint      `json:"challengerPoints,omitempty"`
+	OpponentPoints   *uint      `json:"opponentPoints,omitempty"`
+	WinnerID         uint       `json:"winnerId,omitempty"`
+	Tasks            []TaskDTO  `json:"tasks,omitempty"`
+	FinishedAt       *time.Time `json:"finishedAt,omitempty"`
+}
+
+type ChallengeAnswersDTO struct {
+	Answers []AnswerDTO `json:"answers"`
+}
+
+type ClassroomDTO struct {
+	ClassroomID string          `json:"classroomId"`
+	Name        string          `json:"name" validate:"required,max=250"`
+	TeacherID   uint            `json:"teacherId"`
+	Role        string          `json:"role"`
+	JoinCode    string          `json:"joinCode,omitempty"`
+	Assignments []AssignmentDTO `json:"assignments"`
+}
+
+type AssignmentDTO struct {
+	AssignmentID string    `json:"assignmentId"`
+	CourseID     string    `json:"courseId" validate:"required"`
+	CourseName   string    `json:"courseName"`
+	DueAt        time.Time `json:"dueAt" validate:"required"`
+}
+
+type ClassroomDashboardDTO struct {
+	ClassroomID string               `json:"classroomId"`
+	Assignments []AssignmentDTO      `json:"assignments"`
+	Students    []StudentProgressDTO `json:"students"`
+}
+
+type StudentProgressDTO struct {
+	UserID      uint                    `json:"userId"`
+	Email       string                  `json:"username"`
+	Avatar      string                  `json:"avatar"`
+	Overdue     int                     `json:"overdue"`
+	Assignments []AssignmentProgressDTO `json:"assignments"`
+}
+
+type AssignmentProgressDTO struct {
+	AssignmentID string `json:"assignmentId"`
+	CourseID     string `json:"courseId"`
+	BestPoints   uint   `json:"bestPoints"`
+	Passed       bool   `json:"passed"`
+	Attempts     int    `json:"attempts"`
+	TimeSpentMs  int    `json:"timeSpentMs"`
+	Overdue      bool   `json:"overdue"`
+}
+
+type LiveRoomCreateDTO struct {
+	CourseID string `json:"courseId" validate:"required"`
+	// QuestionCount limits the asked tasks, all tasks of the course are asked when not set
+	QuestionCount int `json:"questionCount" validate:"min=0"`
+}
+
+type LiveRoomDTO struct {
+	PIN       string `json:"pin"`
+	CourseID  string `json:"courseId"`
+	Questions int    `json:"questions"`
+}
+
+type NotificationDTO struct {
+	ID          uint      `json:"id"`
+	Type        string    `json:"type"`
+	Read        bool      `json:"read"`
+	ActorID     uint      `json:"actorId,omitempty"`
+	ActorName   string    `json:"actorName,omitempty"`
+	ActorAvatar string    `json:"actorAvatar,omitempty"`
+	CourseID    string    `json:"courseId,omitempty"`
+	CourseName  string    `json:"courseName,omitempty"`
+	RefID       uint      `json:"refId,omitempty"`
+	Value       float32   `json:"value,omitempty"`
+	Name        string    `json:"name,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type NotificationListDTO struct {
+	Unread        int               `json:"unread"`
+	Page          int               `json:"page"`
+	Notifications []NotificationDTO `json:"notifications"`
+}
+
+// NotificationReadDTO marks the listed notifications, or all of them, as read
+type NotificationReadDTO struct {
+	IDs []uint `json:"ids"`
+	All bool   `json:"all"`
+}
@@ -0,0 +1,86 @@
+// Package event dispatches domain events to the parts of the service interested in them
+package event
+
+import (
+	"github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+type Type string
+
+const (
+	ResultSaved     Type = "result_saved"
+	CoursePublished Type = "course_published"
+	RatingReceived  Type = "rating_received"
+	BadgeEarned     Type = "badge_earned"
+	XPEarned        Type = "xp_earned"
+	LevelUp         Type = "level_up"
+	// AssignmentCreated is published for every student of the classroom
+	AssignmentCreated Type = "assignment_created"
+	ChallengeReceived Type = "challenge_received"
+)
+
+// Event describes something which happened to the user
+type Event struct {
+	Type     Type
+	Time     time.Time
+	UserID   uint
+	CourseID uint
+	// ActorID is the other user who caused the event, e.g. the one who rated the course
+	ActorID uint
+	// RefID is the id of the related entity, e.g. the classroom or the challenge
+	RefID uint
+
+	Points uint
+	Passed bool
+	// Value carries the event specific number, e.g. the given rate
+	Value float32
+	// Name carries the event specific identifier, e.g. the badge id
+	Name string
+}
+
+type Handler func(e Event) error
+
+type Publisher interface {
+	Publish(e Event)
+}
+
+// Bus calls handlers synchronously in the order of subscription
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[Type][]Handler
+	logger   logrus.FieldLogger
+}
+
+func NewBus(logger logrus.FieldLogger) *Bus {
+	return &Bus{
+		handlers: make(map[Type][]Handler),
+		logger:   logger,
+	}
+}
+
+func (b *Bus) Subscribe(t Type, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers[t] = append(b.handlers[t], handler)
+}
+
+// Publish delivers the event to every handler, handler errors are logged
+// so a failing subscriber does not break the action which caused the event
+func (b *Bus) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	b.mu.RLock()
+	handlers := b.handlers[e.Type]
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		if err := handler(e); err != nil {
+			b.logger.Errorf("while handling %s event of user %d: %s", e.Type, e.UserID, err)
+		}
+	}
+}
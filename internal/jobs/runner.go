@@ -0,0 +1,74 @@
+// Package jobs runs periodic background work inside the service
+package jobs
+
+import (
+	"github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+// Job does the work due at the given moment
+type Job func(now time.Time) error
+
+type entry struct {
+	name  string
+	every time.Duration
+	job   Job
+}
+
+// Runner calls every job in its own goroutine, once at start and then periodically
+type Runner struct {
+	logger  logrus.FieldLogger
+	entries []entry
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewRunner(logger logrus.FieldLogger) *Runner {
+	return &Runner{
+		logger: logger,
+		stop:   make(chan struct{}),
+	}
+}
+
+// Add registers the job, it has to be called before Start
+func (r *Runner) Add(name string, every time.Duration, job Job) {
+	r.entries = append(r.entries, entry{name: name, every: every, job: job})
+}
+
+func (r *Runner) Start() {
+	for _, e := range r.entries {
+		r.wg.Add(1)
+		go r.run(e)
+	}
+}
+
+// Stop waits until running jobs finish, no job is started afterwards
+func (r *Runner) Stop() {
+	close(r.stop)
+	r.wg.Wait()
+}
+
+func (r *Runner) run(e entry) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(e.every)
+	defer ticker.Stop()
+
+	r.call(e, time.Now())
+	for {
+		select {
+		case <-r.stop:
+			return
+		case now := <-ticker.C:
+			r.call(e, now)
+		}
+	}
+}
+
+func (r *Runner) call(e entry, now time.Time) {
+	if err := e.job(now); err != nil {
+		r.logger.Errorf("while running job %s: %s", e.name, err)
+	}
+}
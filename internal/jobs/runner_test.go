@@ -0,0 +1,35 @@
+package jobs
+
+import (
+	"errors"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/go-playground/assert.v1"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunnerCallsJobsUntilStopped(t *testing.T) {
+	runner := NewRunner(logrus.New())
+
+	var calls, failures int32
+	runner.Add("count", time.Millisecond, func(now time.Time) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	})
+	runner.Add("fail", time.Millisecond, func(now time.Time) error {
+		atomic.AddInt32(&failures, 1)
+		return errors.New("failed")
+	})
+
+	runner.Start()
+	time.Sleep(20 * time.Millisecond)
+	runner.Stop()
+
+	stopped := atomic.LoadInt32(&calls)
+	time.Sleep(5 * time.Millisecond)
+
+	assert.Equal(t, stopped > 1, true)
+	assert.Equal(t, atomic.LoadInt32(&failures) > 1, true)
+	assert.Equal(t, atomic.LoadInt32(&calls), stopped)
+}
@@ -0,0 +1,109 @@
+// Package live runs real-time quiz rooms, players join with a PIN
+// and answer the questions of a course against a countdown
+package live
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+var (
+	ErrRoomNotFound = errors.New("live room not found")
+	ErrRoomFull     = errors.New("live room is full")
+	ErrRoomStarted  = errors.New("live room has already started")
+	ErrNoQuestions  = errors.New("live room needs at least one question")
+	ErrHubClosed    = errors.New("live rooms are shutting down")
+)
+
+// Settings configure every room of a hub
+type Settings struct {
+	QuestionTime time.Duration
+	RevealTime   time.Duration
+	LobbyTimeout time.Duration
+	MaxPoints    int
+	MaxPlayers   int
+}
+
+// Hub keeps the open rooms by their PIN
+type Hub struct {
+	settings Settings
+
+	mu     sync.Mutex
+	rooms  map[string]*Room
+	rnd    *rand.Rand
+	closed bool
+	wg     sync.WaitGroup
+}
+
+func NewHub(settings Settings) *Hub {
+	return &Hub{
+		settings: settings,
+		rooms:    make(map[string]*Room),
+		rnd:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Open starts the goroutine of a new room, the room lives until
+// the quiz is finished, the lobby times out or the hub is shut down
+func (h *Hub) Open(hostID, courseID uint, questions []Question) (*Room, error) {
+	if len(questions) == 0 {
+		return nil, ErrNoQuestions
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, ErrHubClosed
+	}
+
+	pin := h.newPIN()
+	room := newRoom(pin, hostID, courseID, questions, h.settings)
+	h.rooms[pin] = room
+
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+		room.run()
+		h.mu.Lock()
+		delete(h.rooms, pin)
+		h.mu.Unlock()
+	}()
+
+	return room, nil
+}
+
+func (h *Hub) Get(pin string) (*Room, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	room, ok := h.rooms[pin]
+	if !ok {
+		return nil, ErrRoomNotFound
+	}
+	return room, nil
+}
+
+// Shutdown closes every room and waits until their connections are closed
+func (h *Hub) Shutdown() {
+	h.mu.Lock()
+	h.closed = true
+	for _, room := range h.rooms {
+		room.close()
+	}
+	h.mu.Unlock()
+
+	h.wg.Wait()
+}
+
+// newPIN returns a six digit PIN not used by any open room, h.mu has to be held
+func (h *Hub) newPIN() string {
+	for {
+		pin := fmt.Sprintf("%06d", h.rnd.Intn(1000000))
+		if _, ok := h.rooms[pin]; !ok {
+			return pin
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package live
+
+import (
+	"fmt"
+	"github.com/best-project/api/internal"
+	"github.com/gorilla/websocket"
+	"gopkg.in/go-playground/assert.v1"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var testTasks = []internal.Task{
+	{ID: 1, Word: "dog", Translate: "pies"},
+	{ID: 2, Word: "cat", Translate: "kot"},
+	{ID: 3, Word: "bird", Translate: "ptak"},
+	{ID: 4, Word: "fish", Translate: "ryba"},
+}
+
+func TestSpeedScore(t *testing.T) {
+	assert.Equal(t, SpeedScore(1000, 0, 10*time.Second), 1000)
+	assert.Equal(t, SpeedScore(1000, 5*time.Second, 10*time.Second), 750)
+	assert.Equal(t, SpeedScore(1000, 10*time.Second, 10*time.Second), 500)
+	assert.Equal(t, SpeedScore(1000, 15*time.Second, 10*time.Second), 500)
+	assert.Equal(t, SpeedScore(1000, -time.Second, 10*time.Second), 1000)
+}
+
+func TestRankStandings(t *testing.T) {
+	standings := []Standing{{UserID: 1, Score: 500}, {UserID: 2, Score: 900}, {UserID: 3, Score: 500}, {UserID: 4}}
+
+	rankStandings(standings)
+
+	assert.Equal(t, standings, []Standing{
+		{UserID: 2, Score: 900, Rank: 1},
+		{UserID: 1, Score: 500, Rank: 2},
+		{UserID: 3, Score: 500, Rank: 2},
+		{UserID: 4, Rank: 4},
+	})
+}
+
+func TestNewQuestions(t *testing.T) {
+	questions := NewQuestions(testTasks, 3, rand.New(rand.NewSource(1)))
+
+	assert.Equal(t, len(questions), 3)
+	for _, question := range questions {
+		assert.Equal(t, len(question.Choices), maxChoices)
+		assert.Equal(t, contains(question.Choices, question.expected), true)
+	}
+
+	questions = NewQuestions(testTasks[:2], 0, rand.New(rand.NewSource(1)))
+	assert.Equal(t, len(questions), 2)
+	assert.Equal(t, len(questions[0].Choices), 0)
+}
+
+func TestLiveRoom(t *testing.T) {
+	hub := NewHub(Settings{
+		QuestionTime: 5 * time.Second,
+		RevealTime:   20 * time.Millisecond,
+		LobbyTimeout: 5 * time.Second,
+		MaxPoints:    1000,
+		MaxPlayers:   3,
+	})
+	defer hub.Shutdown()
+	room, err := hub.Open(100, 1, NewQuestions(testTasks, 2, rand.New(rand.NewSource(1))))
+	assert.Equal(t, err, nil)
+	srv := httptest.NewServer(roomHandler(t, hub))
+	defer srv.Close()
+
+	host := dial(t, srv, room.PIN, 100)
+	defer host.Close()
+	joined := readUntil(t, host, JoinedMessage)
+	assert.Equal(t, joined.Host, true)
+	assert.Equal(t, joined.Total, 2)
+
+	// players answer in order of their ids, the last one always wrong
+	delays := map[uint]time.Duration{1: 0, 2: 300 * time.Millisecond, 3: 0}
+	results := make(map[uint][]Standing)
+	ready := make(chan struct{})
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for id := uint(1); id <= 3; id++ {
+		wg.Add(1)
+		go func(id uint) {
+			defer wg.Done()
+			conn := dial(t, srv, room.PIN, id)
+			defer conn.Close()
+			readUntil(t, conn, JoinedMessage)
+			ready <- struct{}{}
+
+			for i := 0; i < 2; i++ {
+				question := readUntil(t, conn, QuestionMessage)
+				time.Sleep(delays[id])
+				answer := translationOf(question.Question.TaskID)
+				if id == 3 {
+					answer = "nie wiem"
+				}
+				send(t, conn, Command{Type: AnswerCommand, TaskID: question.Question.TaskID, Answer: answer})
+				result := readUntil(t, conn, ResultMessage)
+				assert.Equal(t, result.Correct, id != 3)
+				readUntil(t, conn, LeaderboardMessage)
+			}
+			finished := readUntil(t, conn, FinishedMessage)
+
+			mu.Lock()
+			results[id] = finished.Leaderboard
+			mu.Unlock()
+		}(id)
+	}
+	for i := 0; i < 3; i++ {
+		<-ready
+	}
+
+	late := dial(t, srv, room.PIN, 4)
+	defer late.Close()
+	_, _, err = late.ReadMessage()
+	assert.NotEqual(t, err, nil)
+
+	send(t, host, Command{Type: StartCommand})
+	wg.Wait()
+
+	for id := uint(1); id <= 3; id++ {
+		standings := results[id]
+		assert.Equal(t, len(standings), 3)
+		assert.Equal(t, []uint{standings[0].UserID, standings[1].UserID, standings[2].UserID}, []uint{1, 2, 3})
+		assert.Equal(t, standings[0].Score > standings[1].Score, true)
+		assert.Equal(t, standings[2].Score, 0)
+		assert.Equal(t, standings[2].Rank, 3)
+	}
+
+	<-room.Done()
+	_, err = hub.Get(room.PIN)
+	assert.Equal(t, err, ErrRoomNotFound)
+}
+
+func TestLiveShutdown(t *testing.T) {
+	hub := NewHub(Settings{QuestionTime: time.Second, RevealTime: time.Second, LobbyTimeout: time.Minute, MaxPoints: 1000})
+	room, err := hub.Open(100, 1, NewQuestions(testTasks, 0, rand.New(rand.NewSource(1))))
+	assert.Equal(t, err, nil)
+	srv := httptest.NewServer(roomHandler(t, hub))
+	defer srv.Close()
+
+	conns := make([]*websocket.Conn, 0)
+	for id := uint(1); id <= 3; id++ {
+		conn := dial(t, srv, room.PIN, id)
+		defer conn.Close()
+		readUntil(t, conn, JoinedMessage)
+		conns = append(conns, conn)
+	}
+
+	hub.Shutdown()
+
+	for _, conn := range conns {
+		readUntil(t, conn, ClosedMessage)
+		_, _, err := conn.ReadMessage()
+		assert.NotEqual(t, err, nil)
+	}
+	_, err = hub.Open(100, 1, NewQuestions(testTasks, 0, rand.New(rand.NewSource(1))))
+	assert.Equal(t, err, ErrHubClosed)
+}
+
+func TestLiveLobbyTimeout(t *testing.T) {
+	hub := NewHub(Settings{QuestionTime: time.Second, RevealTime: time.Second, LobbyTimeout: 50 * time.Millisecond, MaxPoints: 1000})
+	defer hub.Shutdown()
+	room, err := hub.Open(100, 1, NewQuestions(testTasks, 0, rand.New(rand.NewSource(1))))
+	assert.Equal(t, err, nil)
+
+	select {
+	case <-room.Done():
+	case <-time.After(time.Second):
+		t.Fatal("room is still open after the lobby timeout")
+	}
+	_, err = hub.Get(room.PIN)
+	assert.Equal(t, err, ErrRoomNotFound)
+}
+
+func roomHandler(t *testing.T, hub *Hub) http.Handler {
+	upgrader := websocket.Upgrader{}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		room, err := hub.Get(r.URL.Query().Get("pin"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		id, _ := strconv.Atoi(r.URL.Query().Get("user"))
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if err := room.Serve(conn, uint(id), fmt.Sprintf("player %d", id)); err != nil {
+			conn.Close()
+		}
+	})
+}
+
+func dial(t *testing.T, srv *httptest.Server, pin string, userID uint) *websocket.Conn {
+	url := fmt.Sprintf("ws%s?pin=%s&user=%d", strings.TrimPrefix(srv.URL, "http"), pin, userID)
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+func send(t *testing.T, conn *websocket.Conn, cmd Command) {
+	if err := conn.WriteJSON(cmd); err != nil {
+		t.Error(err)
+	}
+}
+
+// readUntil skips messages until one of the given type arrives
+func readUntil(t *testing.T, conn *websocket.Conn, kind string) Message {
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		var msg Message
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Errorf("while waiting for %s: %s", kind, err)
+			return msg
+		}
+		if msg.Type == kind {
+			return msg
+		}
+	}
+}
+
+func translationOf(taskID uint) string {
+	for _, task := range testTasks {
+		if task.ID == taskID {
+			return task.Translate
+		}
+	}
+	return ""
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
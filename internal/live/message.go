@@ -0,0 +1,56 @@
+package live
+
+// Messages pushed by the server
+const (
+	JoinedMessage      = "joined"
+	PlayersMessage     = "players"
+	QuestionMessage    = "question"
+	ResultMessage      = "result"
+	LeaderboardMessage = "leaderboard"
+	FinishedMessage    = "finished"
+	ClosedMessage      = "closed"
+	ErrorMessage       = "error"
+)
+
+// Commands sent by the clients
+const (
+	StartCommand  = "start"
+	AnswerCommand = "answer"
+)
+
+// Message is a single frame pushed to the clients of a room
+type Message struct {
+	Type string `json:"type"`
+	PIN  string `json:"pin,omitempty"`
+	Host bool   `json:"host,omitempty"`
+
+	Players []string `json:"players,omitempty"`
+
+	Question  *Question `json:"question,omitempty"`
+	Number    int       `json:"number,omitempty"`
+	Total     int       `json:"total,omitempty"`
+	TimeLimit float64   `json:"timeLimit,omitempty"`
+
+	Correct bool   `json:"correct,omitempty"`
+	Points  int    `json:"points,omitempty"`
+	Answer  string `json:"answer,omitempty"`
+
+	Leaderboard []Standing `json:"leaderboard,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// Command is a single frame sent by a client
+type Command struct {
+	Type   string `json:"type"`
+	TaskID uint   `json:"taskId"`
+	Answer string `json:"answer"`
+}
+
+// Standing is the place of a player on the live leaderboard
+type Standing struct {
+	UserID uint   `json:"userId"`
+	Name   string `json:"name"`
+	Score  int    `json:"score"`
+	Rank   int    `json:"rank"`
+}
@@ -0,0 +1,497 @@
+package live
+
+import (
+	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/service"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// sendBuffer is the number of messages queued for a client,
+	// a client falling further behind is disconnected
+	sendBuffer = 16
+	writeWait  = 10 * time.Second
+	maxChoices = 4
+)
+
+type state int
+
+const (
+	lobbyState state = iota
+	questionState
+	revealState
+	finishedState
+)
+
+// Conn is a client connection, *websocket.Conn implements it
+type Conn interface {
+	ReadJSON(v interface{}) error
+	WriteJSON(v interface{}) error
+	SetWriteDeadline(t time.Time) error
+	Close() error
+}
+
+// Question is a task of the course asked in a room
+type Question struct {
+	TaskID  uint     `json:"taskId"`
+	Word    string   `json:"word"`
+	Image   string   `json:"image,omitempty"`
+	Choices []string `json:"choices,omitempty"`
+
+	expected string
+}
+
+// NewQuestions picks count random tasks, or all of them when count is not set,
+// choices are offered when the course has enough tasks to draw wrong ones from
+func NewQuestions(tasks []internal.Task, count int, rnd *rand.Rand) []Question {
+	picked := make([]internal.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if task.Word != "" && task.Translate != "" {
+			picked = append(picked, task)
+		}
+	}
+	rnd.Shuffle(len(picked), func(i, j int) {
+		picked[i], picked[j] = picked[j], picked[i]
+	})
+	if count > 0 && count < len(picked) {
+		picked = picked[:count]
+	}
+
+	questions := make([]Question, 0, len(picked))
+	for _, task := range picked {
+		question := Question{TaskID: task.ID, Word: task.Word, Image: task.Image, expected: task.Translate}
+		if len(tasks) >= maxChoices {
+			question.Choices = drawChoices(task, tasks, rnd)
+		}
+		questions = append(questions, question)
+	}
+	return questions
+}
+
+func drawChoices(task internal.Task, tasks []internal.Task, rnd *rand.Rand) []string {
+	choices := []string{task.Translate}
+	for _, i := range rnd.Perm(len(tasks)) {
+		if len(choices) == maxChoices {
+			break
+		}
+		if tasks[i].ID != task.ID && tasks[i].Translate != "" && tasks[i].Translate != task.Translate {
+			choices = append(choices, tasks[i].Translate)
+		}
+	}
+	rnd.Shuffle(len(choices), func(i, j int) {
+		choices[i], choices[j] = choices[j], choices[i]
+	})
+	return choices
+}
+
+// SpeedScore gives full points for an instant answer,
+// falling linearly to a half of them at the end of the countdown
+func SpeedScore(maxPoints int, elapsed, limit time.Duration) int {
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	if elapsed > limit {
+		elapsed = limit
+	}
+	return int(math.Round(float64(maxPoints) * (1 - float64(elapsed)/float64(limit)/2)))
+}
+
+type client struct {
+	userID uint
+	name   string
+	host   bool
+	conn   Conn
+	send   chan Message
+}
+
+type command struct {
+	client  *client
+	command Command
+}
+
+type joinRequest struct {
+	client *client
+	err    chan error
+}
+
+// Room is a single live quiz, its state is owned by the run goroutine
+// and clients talk to it through channels only
+type Room struct {
+	PIN      string
+	HostID   uint
+	CourseID uint
+
+	settings  Settings
+	questions []Question
+	now       func() time.Time
+
+	join      chan joinRequest
+	leave     chan *client
+	inbox     chan command
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+
+	// fields below are used by the run goroutine only
+	state     state
+	current   int
+	askedAt   time.Time
+	timer     *time.Timer
+	host      *client
+	clients   map[uint]*client
+	standings map[uint]*Standing
+	answered  map[uint]bool
+	order     []uint
+	writers   sync.WaitGroup
+}
+
+func newRoom(pin string, hostID, courseID uint, questions []Question, settings Settings) *Room {
+	return &Room{
+		PIN:       pin,
+		HostID:    hostID,
+		CourseID:  courseID,
+		settings:  settings,
+		questions: questions,
+		now:       time.Now,
+
+		join:  make(chan joinRequest),
+		leave: make(chan *client),
+		inbox: make(chan command),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+
+		clients:   make(map[uint]*client),
+		standings: make(map[uint]*Standing),
+		answered:  make(map[uint]bool),
+	}
+}
+
+// Serve attaches the connection to the room and reads its commands
+// until the connection or the room is closed, the host joins as a spectator
+func (r *Room) Serve(conn Conn, userID uint, name string) error {
+	c := &client{
+		userID: userID,
+		name:   name,
+		host:   userID == r.HostID,
+		conn:   conn,
+		send:   make(chan Message, sendBuffer),
+	}
+	errc := make(chan error, 1)
+	select {
+	case r.join <- joinRequest{client: c, err: errc}:
+	case <-r.done:
+		return ErrRoomNotFound
+	}
+	if err := <-errc; err != nil {
+		return err
+	}
+
+	for {
+		var cmd Command
+		if err := conn.ReadJSON(&cmd); err != nil {
+			break
+		}
+		select {
+		case r.inbox <- command{client: c, command: cmd}:
+		case <-r.done:
+			return nil
+		}
+	}
+
+	select {
+	case r.leave <- c:
+	case <-r.done:
+	}
+	return nil
+}
+
+// Done is closed when the room goroutine has exited and every connection is closed
+func (r *Room) Done() <-chan struct{} {
+	return r.done
+}
+
+func (r *Room) close() {
+	r.closeOnce.Do(func() {
+		close(r.stop)
+	})
+}
+
+func (r *Room) run() {
+	defer close(r.done)
+	defer r.writers.Wait()
+
+	r.schedule(r.settings.LobbyTimeout)
+	defer r.timer.Stop()
+
+	for r.state != finishedState {
+		select {
+		case <-r.stop:
+			r.broadcast(Message{Type: ClosedMessage})
+			r.state = finishedState
+		case req := <-r.join:
+			req.err <- r.add(req.client)
+		case c := <-r.leave:
+			r.remove(c)
+		case cmd := <-r.inbox:
+			r.handle(cmd.client, cmd.command)
+		case <-r.timer.C:
+			r.advance()
+		}
+	}
+
+	for _, c := range r.clients {
+		r.disconnect(c)
+	}
+	if r.host != nil {
+		r.disconnect(r.host)
+	}
+}
+
+func (r *Room) schedule(d time.Duration) {
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	r.timer = time.NewTimer(d)
+}
+
+func (r *Room) add(c *client) error {
+	if c.host {
+		if r.host != nil {
+			r.disconnect(r.host)
+		}
+		r.host = c
+	} else {
+		_, known := r.standings[c.userID]
+		switch {
+		case r.state != lobbyState && !known:
+			return ErrRoomStarted
+		case !known && r.settings.MaxPlayers > 0 && len(r.standings) >= r.settings.MaxPlayers:
+			return ErrRoomFull
+		}
+		if old, ok := r.clients[c.userID]; ok {
+			r.disconnect(old)
+		}
+		r.clients[c.userID] = c
+		if !known {
+			r.standings[c.userID] = &Standing{UserID: c.userID, Name: c.name}
+			r.order = append(r.order, c.userID)
+		}
+	}
+
+	r.writers.Add(1)
+	go r.write(c.conn, c.send)
+
+	r.send(c, Message{Type: JoinedMessage, PIN: r.PIN, Host: c.host, Total: len(r.questions)})
+	r.broadcast(Message{Type: PlayersMessage, Players: r.playerNames()})
+	if r.state == questionState && !c.host && !r.answered[c.userID] {
+		r.send(c, r.questionMessage())
+	}
+	return nil
+}
+
+func (r *Room) remove(c *client) {
+	switch {
+	case c == r.host:
+		r.disconnect(c)
+		r.host = nil
+		// nobody is left to start the quiz
+		if r.state == lobbyState {
+			r.broadcast(Message{Type: ClosedMessage})
+			r.state = finishedState
+		}
+	case r.clients[c.userID] == c:
+		r.disconnect(c)
+		delete(r.clients, c.userID)
+		if r.state == lobbyState {
+			delete(r.standings, c.userID)
+			r.order = removeID(r.order, c.userID)
+		}
+		r.broadcast(Message{Type: PlayersMessage, Players: r.playerNames()})
+		if r.state == questionState && r.allAnswered() {
+			r.reveal()
+		}
+	}
+}
+
+func (r *Room) handle(c *client, cmd Command) {
+	switch cmd.Type {
+	case StartCommand:
+		switch {
+		case !c.host:
+			r.send(c, Message{Type: ErrorMessage, Error: "only the host can start the quiz"})
+		case r.state != lobbyState:
+			r.send(c, Message{Type: ErrorMessage, Error: "quiz has already started"})
+		case len(r.clients) == 0:
+			r.send(c, Message{Type: ErrorMessage, Error: "no players have joined"})
+		default:
+			r.ask(0)
+		}
+	case AnswerCommand:
+		question := r.questions[r.current]
+		if c.host || r.state != questionState || cmd.TaskID != question.TaskID || r.answered[c.userID] {
+			r.send(c, Message{Type: ErrorMessage, Error: "answer is not accepted"})
+			return
+		}
+		r.answered[c.userID] = true
+
+		result := Message{Type: ResultMessage, Number: r.current + 1}
+		if service.IsCorrectAnswer(question.expected, cmd.Answer) {
+			result.Correct = true
+			result.Points = SpeedScore(r.settings.MaxPoints, r.now().Sub(r.askedAt), r.settings.QuestionTime)
+			r.standings[c.userID].Score += result.Points
+		}
+		r.send(c, result)
+
+		if r.allAnswered() {
+			r.reveal()
+		}
+	default:
+		r.send(c, Message{Type: ErrorMessage, Error: "unknown command"})
+	}
+}
+
+// advance moves the room on when its countdown runs out
+func (r *Room) advance() {
+	switch r.state {
+	case lobbyState:
+		r.broadcast(Message{Type: ClosedMessage})
+		r.state = finishedState
+	case questionState:
+		r.reveal()
+	case revealState:
+		if r.current+1 < len(r.questions) {
+			r.ask(r.current + 1)
+			return
+		}
+		r.broadcast(Message{Type: FinishedMessage, Leaderboard: r.leaderboard()})
+		r.state = finishedState
+	}
+}
+
+func (r *Room) ask(i int) {
+	r.state = questionState
+	r.current = i
+	r.askedAt = r.now()
+	r.answered = make(map[uint]bool)
+	r.schedule(r.settings.QuestionTime)
+	r.broadcast(r.questionMessage())
+}
+
+func (r *Room) reveal() {
+	r.state = revealState
+	r.schedule(r.settings.RevealTime)
+	r.broadcast(Message{
+		Type:        LeaderboardMessage,
+		Number:      r.current + 1,
+		Total:       len(r.questions),
+		Answer:      r.questions[r.current].expected,
+		Leaderboard: r.leaderboard(),
+	})
+}
+
+func (r *Room) questionMessage() Message {
+	question := r.questions[r.current]
+	return Message{
+		Type:      QuestionMessage,
+		Question:  &question,
+		Number:    r.current + 1,
+		Total:     len(r.questions),
+		TimeLimit: r.settings.QuestionTime.Seconds(),
+	}
+}
+
+func (r *Room) allAnswered() bool {
+	for id := range r.clients {
+		if !r.answered[id] {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Room) leaderboard() []Standing {
+	result := make([]Standing, 0, len(r.order))
+	for _, id := range r.order {
+		result = append(result, *r.standings[id])
+	}
+	rankStandings(result)
+	return result
+}
+
+// rankStandings orders by score, players with equal scores share the rank
+func rankStandings(standings []Standing) {
+	sort.SliceStable(standings, func(i, j int) bool {
+		return standings[i].Score > standings[j].Score
+	})
+	for i := range standings {
+		if i > 0 && standings[i].Score == standings[i-1].Score {
+			standings[i].Rank = standings[i-1].Rank
+			continue
+		}
+		standings[i].Rank = i + 1
+	}
+}
+
+func (r *Room) playerNames() []string {
+	names := make([]string, 0, len(r.order))
+	for _, id := range r.order {
+		if _, ok := r.clients[id]; ok {
+			names = append(names, r.standings[id].Name)
+		}
+	}
+	return names
+}
+
+func (r *Room) broadcast(msg Message) {
+	if r.host != nil {
+		r.send(r.host, msg)
+	}
+	for _, c := range r.clients {
+		r.send(c, msg)
+	}
+}
+
+// send never blocks the room, a client which cannot keep up is disconnected
+func (r *Room) send(c *client, msg Message) {
+	if c.send == nil {
+		return
+	}
+	select {
+	case c.send <- msg:
+	default:
+		r.disconnect(c)
+	}
+}
+
+// disconnect makes the writer flush the queued messages and close the connection
+func (r *Room) disconnect(c *client) {
+	if c.send != nil {
+		close(c.send)
+		c.send = nil
+	}
+}
+
+func (r *Room) write(conn Conn, send <-chan Message) {
+	defer r.writers.Done()
+	defer conn.Close()
+
+	for msg := range send {
+		conn.SetWriteDeadline(time.Now().Add(writeWait))
+		if err := conn.WriteJSON(msg); err != nil {
+			return
+		}
+	}
+}
+
+func removeID(ids []uint, id uint) []uint {
+	for i := range ids {
+		if ids[i] == id {
+			return append(ids[:i], ids[i+1:]...)
+		}
+	}
+	return ids
+}
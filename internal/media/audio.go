@@ -0,0 +1,179 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"time"
+)
+
+const (
+	MP3Type = "audio/mpeg"
+	OGGType = "audio/ogg"
+	WAVType = "audio/wav"
+)
+
+// AudioTypes lists the accepted audio formats
+var AudioTypes = []string{MP3Type, OGGType, WAVType}
+
+var audioExtensions = map[string]string{
+	MP3Type: ".mp3",
+	OGGType: ".ogg",
+	WAVType: ".wav",
+}
+
+// Audio is a validated clip, it is stored as uploaded
+type Audio struct {
+	Data        []byte
+	ContentType string
+	Ext         string
+}
+
+// ProcessAudio detects the format from the content and checks its headers,
+// a file renamed to .mp3 is rejected as well as a truncated one
+func ProcessAudio(r io.Reader, maxBytes int64) (*Audio, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(r, maxBytes+1))
+	if err != nil {
+		return nil, errors.Wrap(err, "while reading audio")
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, ErrTooLarge
+	}
+
+	contentType := detectAudio(data)
+	switch contentType {
+	case MP3Type:
+		err = checkMP3(data)
+	case OGGType:
+		err = checkOGG(data)
+	case WAVType:
+		err = checkWAV(data)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Audio{Data: data, ContentType: contentType, Ext: audioExtensions[contentType]}, nil
+}
+
+func detectAudio(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("ID3")) || isMP3Frame(data):
+		return MP3Type
+	case bytes.HasPrefix(data, []byte("OggS")):
+		return OGGType
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WAVE":
+		return WAVType
+	default:
+		return ""
+	}
+}
+
+// checkMP3 skips the ID3v2 tag and expects a MPEG layer III frame after it
+func checkMP3(data []byte) error {
+	if bytes.HasPrefix(data, []byte("ID3")) {
+		if len(data) < 10 {
+			return ErrMalformed
+		}
+		// the tag size is stored in 7 bits per byte
+		size := int(data[6]&0x7F)<<21 | int(data[7]&0x7F)<<14 | int(data[8]&0x7F)<<7 | int(data[9]&0x7F)
+		size += 10
+		if data[5]&0x10 != 0 {
+			size += 10
+		}
+		if size > len(data) {
+			return ErrMalformed
+		}
+		data = data[size:]
+	}
+	if !isMP3Frame(data) {
+		return ErrMalformed
+	}
+	return nil
+}
+
+func isMP3Frame(data []byte) bool {
+	if len(data) < 4 || data[0] != 0xFF || data[1]&0xE0 != 0xE0 {
+		return false
+	}
+	version := (data[1] >> 3) & 0x03
+	layer := (data[1] >> 1) & 0x03
+	bitrate := data[2] >> 4
+	sampleRate := (data[2] >> 2) & 0x03
+	return version != 1 && layer == 1 && bitrate != 0x0F && sampleRate != 0x03
+}
+
+// checkOGG expects the first page to start a Vorbis or Opus stream
+func checkOGG(data []byte) error {
+	if len(data) < 27 || data[4] != 0 || data[5]&0x02 == 0 {
+		return ErrMalformed
+	}
+	segments := int(data[26])
+	start := 27 + segments
+	if start > len(data) {
+		return ErrMalformed
+	}
+	packet := data[start:]
+	if !bytes.HasPrefix(packet, []byte("\x01vorbis")) && !bytes.HasPrefix(packet, []byte("OpusHead")) {
+		return ErrUnsupportedFormat
+	}
+	return nil
+}
+
+// checkWAV walks the RIFF chunks looking for a valid format and the samples
+func checkWAV(data []byte) error {
+	hasFormat, hasData := false, false
+	for i := 12; i+8 <= len(data); {
+		size := int(binary.LittleEndian.Uint32(data[i+4 : i+8]))
+		end := i + 8 + size
+		if size < 0 || end > len(data) {
+			return ErrMalformed
+		}
+		switch string(data[i : i+4]) {
+		case "fmt ":
+			if size < 16 {
+				return ErrMalformed
+			}
+			channels := binary.LittleEndian.Uint16(data[i+10 : i+12])
+			sampleRate := binary.LittleEndian.Uint32(data[i+12 : i+16])
+			if channels == 0 || sampleRate == 0 {
+				return ErrMalformed
+			}
+			hasFormat = true
+		case "data":
+			hasData = true
+		}
+		i = end + size%2
+	}
+	if !hasFormat || !hasData {
+		return ErrMalformed
+	}
+	return nil
+}
+
+const silenceSampleRate = 8000
+
+// SilentWAV returns a mono 16-bit PCM clip of silence lasting d
+func SilentWAV(d time.Duration) []byte {
+	samples := int(d.Seconds() * silenceSampleRate)
+	size := samples * 2
+
+	out := make([]byte, 44+size)
+	copy(out[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(out[4:8], uint32(36+size))
+	copy(out[8:16], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(out[16:20], 16)
+	binary.LittleEndian.PutUint16(out[20:22], 1)
+	binary.LittleEndian.PutUint16(out[22:24], 1)
+	binary.LittleEndian.PutUint32(out[24:28], silenceSampleRate)
+	binary.LittleEndian.PutUint32(out[28:32], silenceSampleRate*2)
+	binary.LittleEndian.PutUint16(out[32:34], 2)
+	binary.LittleEndian.PutUint16(out[34:36], 16)
+	copy(out[36:40], "data")
+	binary.LittleEndian.PutUint32(out[40:44], uint32(size))
+	return out
+}
@@ -0,0 +1,56 @@
+package media
+
+import (
+	"bytes"
+	"gopkg.in/go-playground/assert.v1"
+	"testing"
+	"time"
+)
+
+// MPEG-1 layer III, 128 kbit/s, 44.1 kHz
+var mp3Frame = []byte{0xFF, 0xFB, 0x90, 0x64}
+
+func TestProcessAudio(t *testing.T) {
+	id3 := append([]byte("ID3\x03\x00\x00\x00\x00\x00\x04TIT2"), mp3Frame...)
+	ogg := append([]byte("OggS\x00\x02"), make([]byte, 20)...)
+	ogg = append(append(ogg, 1, 19), []byte("OpusHead\x01\x01\x38\x01\x80\xbb\x00\x00\x00\x00\x00")...)
+
+	for _, tc := range []struct {
+		data        []byte
+		contentType string
+		ext         string
+	}{
+		{data: append(mp3Frame, make([]byte, 100)...), contentType: MP3Type, ext: ".mp3"},
+		{data: id3, contentType: MP3Type, ext: ".mp3"},
+		{data: ogg, contentType: OGGType, ext: ".ogg"},
+		{data: SilentWAV(100 * time.Millisecond), contentType: WAVType, ext: ".wav"},
+	} {
+		audio, err := ProcessAudio(bytes.NewReader(tc.data), 1<<20)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, audio.ContentType, tc.contentType)
+		assert.Equal(t, audio.Ext, tc.ext)
+		assert.Equal(t, audio.Data, tc.data)
+	}
+}
+
+func TestProcessAudioRejected(t *testing.T) {
+	wav := SilentWAV(100 * time.Millisecond)
+
+	for _, tc := range []struct {
+		data     []byte
+		max      int64
+		expected error
+	}{
+		{data: []byte("<html>not audio</html>"), max: 1 << 20, expected: ErrUnsupportedFormat},
+		{data: encodePNG(t, 2, 2), max: 1 << 20, expected: ErrUnsupportedFormat},
+		{data: wav, max: 100, expected: ErrTooLarge},
+		{data: wav[:len(wav)-10], max: 1 << 20, expected: ErrMalformed},
+		{data: []byte("ID3\x03\x00\x00\x00\x00\x00\x04TIT2garbage"), max: 1 << 20, expected: ErrMalformed},
+		{data: []byte("OggS\x00\x00"), max: 1 << 20, expected: ErrMalformed},
+	} {
+		_, err := ProcessAudio(bytes.NewReader(tc.data), tc.max)
+
+		assert.Equal(t, err, tc.expected)
+	}
+}
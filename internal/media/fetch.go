@@ -0,0 +1,164 @@
+package media
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+const maxRedirects = 3
+
+var (
+	ErrBlockedAddress = errors.New("address is not allowed")
+	ErrFetchFailed    = errors.New("remote file cannot be fetched")
+)
+
+// Fetcher downloads remote images, addresses are checked after name resolution
+// so the server cannot be pointed at itself or the internal network
+type Fetcher struct {
+	limits ImageLimits
+	client *http.Client
+	// checkIP is replaced in tests to reach httptest servers on the loopback interface
+	checkIP func(ip net.IP) error
+}
+
+func NewFetcher(limits ImageLimits, timeout time.Duration) *Fetcher {
+	f := &Fetcher{limits: limits, checkIP: PublicIP}
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		// Control sees the resolved address of every connection, including redirects
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil {
+				return ErrBlockedAddress
+			}
+			return f.checkIP(ip)
+		},
+	}
+	f.client = &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// a proxy from the environment would make the connection instead of the checked dialer
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     time.Minute,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return errors.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return checkScheme(req.URL)
+		},
+	}
+	return f
+}
+
+// Fetch downloads the image and processes it like an upload
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (*Image, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Wrapf(ErrFetchFailed, "invalid url %s", rawURL)
+	}
+	if err := checkScheme(target); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, errors.Wrapf(ErrFetchFailed, "invalid url %s", rawURL)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "image/*")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		if cause := blockedCause(err); cause != nil {
+			return nil, cause
+		}
+		return nil, errors.Wrapf(ErrFetchFailed, "%s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Wrapf(ErrFetchFailed, "responded with %d", resp.StatusCode)
+	}
+	if resp.ContentLength > f.limits.MaxBytes {
+		return nil, ErrTooLarge
+	}
+	return ProcessImage(resp.Body, f.limits)
+}
+
+// PublicIP rejects loopback, private, link-local and other addresses not routed on the internet
+func PublicIP(ip net.IP) error {
+	if ip.IsLoopback() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return ErrBlockedAddress
+	}
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return ErrBlockedAddress
+		}
+	}
+	return nil
+}
+
+var blockedNetworks = parseNetworks(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"240.0.0.0/4",
+	"fc00::/7",
+	"64:ff9b::/96",
+)
+
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(fmt.Sprintf("invalid network %s", cidr))
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+func checkScheme(target *url.URL) error {
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return errors.Wrapf(ErrBlockedAddress, "scheme %q", target.Scheme)
+	}
+	return nil
+}
+
+// blockedCause finds the dialer rejection in the error chain of the client
+func blockedCause(err error) error {
+	for err != nil {
+		if err == ErrBlockedAddress {
+			return err
+		}
+		switch e := err.(type) {
+		case *url.Error:
+			err = e.Err
+		case *net.OpError:
+			err = e.Err
+		default:
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package media
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"gopkg.in/go-playground/assert.v1"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFetch(t *testing.T) {
+	png := encodePNG(t, 8, 4)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/image.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(png)
+	})
+	mux.HandleFunc("/page.html", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><body>not an image</body></html>"))
+	})
+	mux.HandleFunc("/large.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(make([]byte, testLimits.MaxBytes+1))
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/image.png", http.StatusFound)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	fetcher := NewFetcher(testLimits, time.Second)
+	fetcher.checkIP = func(ip net.IP) error { return nil }
+	ctx := context.Background()
+
+	img, err := fetcher.Fetch(ctx, srv.URL+"/image.png")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, img.ContentType, PNGType)
+	assert.Equal(t, img.Width, 8)
+
+	img, err = fetcher.Fetch(ctx, srv.URL+"/moved")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, img.Height, 4)
+
+	_, err = fetcher.Fetch(ctx, srv.URL+"/page.html")
+	assert.Equal(t, err, ErrUnsupportedFormat)
+	_, err = fetcher.Fetch(ctx, srv.URL+"/large.png")
+	assert.Equal(t, err, ErrTooLarge)
+	_, err = fetcher.Fetch(ctx, srv.URL+"/missing.png")
+	assert.Equal(t, errors.Cause(err), ErrFetchFailed)
+	_, err = fetcher.Fetch(ctx, "file:///etc/passwd")
+	assert.Equal(t, errors.Cause(err), ErrBlockedAddress)
+}
+
+func TestFetchBlocksPrivateAddresses(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	defer srv.Close()
+
+	_, err := NewFetcher(testLimits, time.Second).Fetch(context.Background(), srv.URL+"/image.png")
+
+	assert.Equal(t, err, ErrBlockedAddress)
+	assert.Equal(t, calls, 0)
+}
+
+func TestPublicIP(t *testing.T) {
+	for _, blocked := range []string{"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254",
+		"100.64.0.1", "0.0.0.0", "::1", "fd00::1", "fe80::1", "::ffff:127.0.0.1", "::ffff:10.0.0.1"} {
+		assert.Equal(t, PublicIP(net.ParseIP(blocked)), ErrBlockedAddress)
+	}
+	for _, public := range []string{"8.8.8.8", "151.101.1.69", "2a00:1450:4001:82a::200e"} {
+		assert.Equal(t, PublicIP(net.ParseIP(public)), nil)
+	}
+}
@@ -0,0 +1,293 @@
+// Package media validates uploaded files and normalizes them before they are stored
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/pkg/errors"
+	"golang.org/x/image/webp"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+const (
+	JPEGType = "image/jpeg"
+	PNGType  = "image/png"
+	GIFType  = "image/gif"
+	WebPType = "image/webp"
+
+	jpegQuality = 90
+)
+
+// ImageTypes lists the accepted image formats
+var ImageTypes = []string{JPEGType, PNGType, GIFType, WebPType}
+
+var imageExtensions = map[string]string{
+	JPEGType: ".jpg",
+	PNGType:  ".png",
+	GIFType:  ".gif",
+	WebPType: ".webp",
+}
+
+var (
+	ErrTooLarge          = errors.New("file is too large")
+	ErrUnsupportedFormat = errors.New("file format is not supported")
+	ErrMalformed         = errors.New("file cannot be decoded")
+	ErrDimensions        = errors.New("image dimensions exceed the limit")
+)
+
+// Rejected tells if the error is caused by the uploaded or linked file rather than by the service
+func Rejected(err error) bool {
+	switch errors.Cause(err) {
+	case ErrTooLarge, ErrUnsupportedFormat, ErrMalformed, ErrDimensions, ErrBlockedAddress, ErrFetchFailed:
+		return true
+	default:
+		return false
+	}
+}
+
+type ImageLimits struct {
+	MaxBytes  int64
+	MaxWidth  int
+	MaxHeight int
+}
+
+// Image is an upload ready to be stored, without EXIF, GPS or other metadata
+type Image struct {
+	Data        []byte
+	ContentType string
+	Ext         string
+	Width       int
+	Height      int
+}
+
+// ProcessImage sniffs the real format of the upload, checks the limits before
+// decoding the pixels and re-encodes the image, which drops its metadata.
+// WebP cannot be encoded without cgo so its metadata chunks are removed instead.
+func ProcessImage(r io.Reader, limits ImageLimits) (*Image, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(r, limits.MaxBytes+1))
+	if err != nil {
+		return nil, errors.Wrap(err, "while reading image")
+	}
+	if int64(len(data)) > limits.MaxBytes {
+		return nil, ErrTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	if _, ok := imageExtensions[contentType]; !ok {
+		return nil, ErrUnsupportedFormat
+	}
+
+	cfg, err := decodeConfig(contentType, data)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	orientation := 1
+	if contentType == JPEGType {
+		orientation = jpegOrientation(data)
+	}
+	width, height := cfg.Width, cfg.Height
+	if orientation >= 5 {
+		width, height = height, width
+	}
+	if width > limits.MaxWidth || height > limits.MaxHeight {
+		return nil, ErrDimensions
+	}
+
+	var out bytes.Buffer
+	switch contentType {
+	case JPEGType:
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, ErrMalformed
+		}
+		// the orientation tag is dropped with the rest of EXIF so it is applied to the pixels
+		if err := jpeg.Encode(&out, orient(img, orientation), &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, errors.Wrap(err, "while encoding jpeg")
+		}
+	case PNGType:
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, ErrMalformed
+		}
+		if err := png.Encode(&out, img); err != nil {
+			return nil, errors.Wrap(err, "while encoding png")
+		}
+	case GIFType:
+		animation, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return nil, ErrMalformed
+		}
+		if err := gif.EncodeAll(&out, animation); err != nil {
+			return nil, errors.Wrap(err, "while encoding gif")
+		}
+	case WebPType:
+		if _, err := webp.Decode(bytes.NewReader(data)); err != nil {
+			return nil, ErrMalformed
+		}
+		stripped, err := stripWebPMetadata(data)
+		if err != nil {
+			return nil, err
+		}
+		out.Write(stripped)
+	}
+
+	return &Image{
+		Data:        out.Bytes(),
+		ContentType: contentType,
+		Ext:         imageExtensions[contentType],
+		Width:       width,
+		Height:      height,
+	}, nil
+}
+
+func decodeConfig(contentType string, data []byte) (image.Config, error) {
+	r := bytes.NewReader(data)
+	switch contentType {
+	case JPEGType:
+		return jpeg.DecodeConfig(r)
+	case PNGType:
+		return png.DecodeConfig(r)
+	case GIFType:
+		return gif.DecodeConfig(r)
+	default:
+		return webp.DecodeConfig(r)
+	}
+}
+
+// jpegOrientation reads the EXIF orientation tag, 1 means no transformation
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		length := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		// the image data starts at SOS, metadata segments are all before it
+		if marker == 0xDA || length < 2 || i+2+length > len(data) {
+			return 1
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	offset := int(order.Uint32(tiff[4:8]))
+	if offset+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[offset : offset+2]))
+	for i := 0; i < entries; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 {
+			value := int(order.Uint16(tiff[entry+8 : entry+10]))
+			if value < 1 || value > 8 {
+				return 1
+			}
+			return value
+		}
+	}
+	return 1
+}
+
+// orient applies the EXIF orientation so the image is displayed upright without the tag
+func orient(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			}
+			dst.Set(x, y, img.At(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+	return dst
+}
+
+// stripWebPMetadata removes EXIF and XMP chunks from the RIFF container
+func stripWebPMetadata(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, ErrMalformed
+	}
+
+	out := make([]byte, 12, len(data))
+	copy(out, data[:12])
+	for i := 12; i < len(data); {
+		if i+8 > len(data) {
+			return nil, ErrMalformed
+		}
+		fourCC := string(data[i : i+4])
+		size := int(binary.LittleEndian.Uint32(data[i+4 : i+8]))
+		end := i + 8 + size + size%2
+		if size < 0 || end > len(data) {
+			return nil, ErrMalformed
+		}
+		switch fourCC {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk := append([]byte(nil), data[i:end]...)
+			if size > 0 {
+				// clear the EXIF and XMP presence flags
+				chunk[8] &^= 0x0C
+			}
+			out = append(out, chunk...)
+		default:
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+	binary.LittleEndian.PutUint32(out[4:8], uint32(len(out)-8))
+	return out, nil
+}
@@ -0,0 +1,152 @@
+package media
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"gopkg.in/go-playground/assert.v1"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+var testLimits = ImageLimits{MaxBytes: 1 << 20, MaxWidth: 100, MaxHeight: 100}
+
+// smallest lossless WebP, a single pixel
+const tinyWebP = "UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA=="
+
+func TestProcessImagePNG(t *testing.T) {
+	data := encodePNG(t, 8, 4)
+	// a text chunk is dropped by re-encoding
+	data = append(data[:len(data)-12], append(pngChunk("tEXt", []byte("GPS\x0052.2,21.0")), data[len(data)-12:]...)...)
+
+	img, err := ProcessImage(bytes.NewReader(data), testLimits)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, img.ContentType, PNGType)
+	assert.Equal(t, img.Ext, ".png")
+	assert.Equal(t, img.Width, 8)
+	assert.Equal(t, img.Height, 4)
+	assert.Equal(t, bytes.Contains(img.Data, []byte("tEXt")), false)
+}
+
+func TestProcessImageJPEGOrientation(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Equal(t, jpeg.Encode(&buf, testImage(8, 4), nil), nil)
+	data := withExifOrientation(buf.Bytes(), 6)
+	assert.Equal(t, jpegOrientation(data), 6)
+
+	img, err := ProcessImage(bytes.NewReader(data), testLimits)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, img.ContentType, JPEGType)
+	assert.Equal(t, img.Ext, ".jpg")
+	assert.Equal(t, bytes.Contains(img.Data, []byte("Exif")), false)
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(img.Data))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, []int{cfg.Width, cfg.Height}, []int{4, 8})
+}
+
+func TestProcessImageGIF(t *testing.T) {
+	frame := image.NewPaletted(image.Rect(0, 0, 3, 3), []color.Color{color.Black, color.White})
+	var buf bytes.Buffer
+	assert.Equal(t, gif.EncodeAll(&buf, &gif.GIF{Image: []*image.Paletted{frame, frame}, Delay: []int{10, 10}}), nil)
+
+	img, err := ProcessImage(&buf, testLimits)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, img.ContentType, GIFType)
+	animation, err := gif.DecodeAll(bytes.NewReader(img.Data))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(animation.Image), 2)
+}
+
+func TestProcessImageWebP(t *testing.T) {
+	tiny, _ := base64.StdEncoding.DecodeString(tinyWebP)
+	vp8x := make([]byte, 10)
+	vp8x[0] = 0x08
+	data := riff(append(append(webpChunk("VP8X", vp8x), tiny[12:]...), webpChunk("EXIF", []byte("GPS 52.2,21.0"))...))
+
+	img, err := ProcessImage(bytes.NewReader(data), testLimits)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, img.ContentType, WebPType)
+	assert.Equal(t, img.Ext, ".webp")
+	assert.Equal(t, bytes.Contains(img.Data, []byte("EXIF")), false)
+	assert.Equal(t, int(binary.LittleEndian.Uint32(img.Data[4:8])), len(img.Data)-8)
+	assert.Equal(t, img.Data[20]&0x08, byte(0))
+}
+
+func TestProcessImageRejected(t *testing.T) {
+	_, err := ProcessImage(bytes.NewReader([]byte("<html>not an image</html>")), testLimits)
+	assert.Equal(t, err, ErrUnsupportedFormat)
+
+	_, err = ProcessImage(bytes.NewReader(encodePNG(t, 101, 10)), testLimits)
+	assert.Equal(t, err, ErrDimensions)
+
+	_, err = ProcessImage(bytes.NewReader(encodePNG(t, 10, 10)), ImageLimits{MaxBytes: 10, MaxWidth: 100, MaxHeight: 100})
+	assert.Equal(t, err, ErrTooLarge)
+
+	broken := encodePNG(t, 10, 10)
+	_, err = ProcessImage(bytes.NewReader(broken[:40]), testLimits)
+	assert.Equal(t, err, ErrMalformed)
+
+	assert.Equal(t, Rejected(err), true)
+}
+
+func testImage(w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		img.Set(x, 0, color.RGBA{R: 255, A: 255})
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(w, h)); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func pngChunk(kind string, data []byte) []byte {
+	chunk := make([]byte, 8, 12+len(data))
+	binary.BigEndian.PutUint32(chunk[:4], uint32(len(data)))
+	copy(chunk[4:8], kind)
+	chunk = append(chunk, data...)
+	sum := make([]byte, 4)
+	binary.BigEndian.PutUint32(sum, crc32.ChecksumIEEE(chunk[4:]))
+	return append(chunk, sum...)
+}
+
+func withExifOrientation(jpg []byte, orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01\x00\x00\x00\x00\x00\x00\x00\x00")
+	binary.BigEndian.PutUint16(tiff[18:20], orientation)
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	segment = append(segment, payload...)
+	return append(append([]byte{0xFF, 0xD8}, segment...), jpg[2:]...)
+}
+
+func webpChunk(fourCC string, data []byte) []byte {
+	chunk := make([]byte, 8, 9+len(data))
+	copy(chunk[:4], fourCC)
+	binary.LittleEndian.PutUint32(chunk[4:8], uint32(len(data)))
+	chunk = append(chunk, data...)
+	if len(data)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+func riff(chunks []byte) []byte {
+	header := []byte("RIFF\x00\x00\x00\x00WEBP")
+	binary.LittleEndian.PutUint32(header[4:8], uint32(len(chunks)+4))
+	return append(header, chunks...)
+}
@@ -0,0 +1,45 @@
+package media
+
+import (
+	"gopkg.in/go-playground/assert.v1"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestURLSigner(t *testing.T) {
+	signer := NewURLSigner([]byte("secret"), time.Hour)
+	now := time.Date(2019, 12, 10, 12, 30, 0, 0, time.UTC)
+
+	signed := signer.Sign("/images/abc.png", now)
+	parsed, err := url.Parse(signed)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, parsed.Path, "/images/abc.png")
+	expires, signature := parsed.Query().Get(ExpiresParam), parsed.Query().Get(SignatureParam)
+
+	// the url does not change within the ttl window
+	assert.Equal(t, signer.Sign("/images/abc.png", now.Add(20*time.Minute)), signed)
+
+	expiresAt, err := signer.Verify("abc.png", expires, signature, now)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, expiresAt.After(now.Add(time.Hour)), true)
+	assert.Equal(t, expiresAt.Before(now.Add(2*time.Hour)), true)
+
+	_, err = signer.Verify("abc.png", expires, signature, now.Add(2*time.Hour))
+	assert.Equal(t, err, ErrExpiredSignature)
+	_, err = signer.Verify("other.png", expires, signature, now)
+	assert.Equal(t, err, ErrInvalidSignature)
+	_, err = signer.Verify("abc.png", expires+"0", signature, now)
+	assert.Equal(t, err, ErrInvalidSignature)
+	_, err = NewURLSigner([]byte("other"), time.Hour).Verify("abc.png", expires, signature, now)
+	assert.Equal(t, err, ErrInvalidSignature)
+}
+
+func TestURLSignerKeepsQuery(t *testing.T) {
+	signer := NewURLSigner([]byte("secret"), time.Hour)
+
+	parsed, _ := url.Parse(signer.Sign("https://api.example.com/images/abc.png?size=thumb", time.Now()))
+
+	assert.Equal(t, parsed.Query().Get("size"), "thumb")
+	assert.NotEqual(t, parsed.Query().Get(SignatureParam), "")
+}
@@ -0,0 +1,109 @@
+package media
+
+import (
+	"bytes"
+	"github.com/pkg/errors"
+	"golang.org/x/image/draw"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"net/http"
+	"path"
+	"strings"
+
+	// registers WebP for image.Decode, the other formats are registered by the encoders
+	_ "golang.org/x/image/webp"
+)
+
+// Variant is a resized copy of an uploaded image served to smaller screens
+type Variant struct {
+	Name  string
+	Width int
+}
+
+var (
+	Thumbnail = Variant{Name: "thumb", Width: 160}
+	Medium    = Variant{Name: "medium", Width: 480}
+	Large     = Variant{Name: "large", Width: 1024}
+)
+
+// Variants lists the generated sizes from the smallest
+var Variants = []Variant{Thumbnail, Medium, Large}
+
+// VariantsPrefix starts the keys of all variants
+const VariantsPrefix = "variants/"
+
+func VariantByName(name string) (Variant, bool) {
+	for _, v := range Variants {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return Variant{}, false
+}
+
+// ClosestVariant returns the smallest variant at least width pixels wide, the largest one for wider requests
+func ClosestVariant(width int) Variant {
+	for _, v := range Variants {
+		if v.Width >= width {
+			return v
+		}
+	}
+	return Variants[len(Variants)-1]
+}
+
+// VariantKey is the blob key of the variant of the image stored under key
+func VariantKey(key string, v Variant) string {
+	return path.Join(VariantsPrefix, strings.TrimSuffix(key, path.Ext(key)), v.Name+".jpg")
+}
+
+// Decode reads an image of any of the accepted formats, only the first frame of an animation is used
+func Decode(data []byte) (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	return img, nil
+}
+
+// CheckDimensions reads the size of the image without decoding its pixels, images above the limits
+// are rejected before decoding them takes the memory
+func CheckDimensions(data []byte, limits ImageLimits) error {
+	contentType := http.DetectContentType(data)
+	if _, ok := imageExtensions[contentType]; !ok {
+		return ErrUnsupportedFormat
+	}
+	cfg, err := decodeConfig(contentType, data)
+	if err != nil {
+		return ErrMalformed
+	}
+	if cfg.Width > limits.MaxWidth || cfg.Height > limits.MaxHeight {
+		return ErrDimensions
+	}
+	return nil
+}
+
+// Resize scales the image down to the width of the variant and encodes it as JPEG,
+// narrower images keep their size. Transparent areas become white.
+// Variants are JPEG only as WebP cannot be encoded without cgo
+func Resize(src image.Image, v Variant) ([]byte, error) {
+	b := src.Bounds()
+	width, height := b.Dx(), b.Dy()
+	if width > v.Width {
+		height = height * v.Width / width
+		width = v.Width
+		if height < 1 {
+			height = 1
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Over, nil)
+
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, dst, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, errors.Wrapf(err, "while encoding %s variant", v.Name)
+	}
+	return out.Bytes(), nil
+}
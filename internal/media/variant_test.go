@@ -0,0 +1,71 @@
+package media
+
+import (
+	"bytes"
+	"gopkg.in/go-playground/assert.v1"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+func TestClosestVariant(t *testing.T) {
+	assert.Equal(t, ClosestVariant(1), Thumbnail)
+	assert.Equal(t, ClosestVariant(160), Thumbnail)
+	assert.Equal(t, ClosestVariant(161), Medium)
+	assert.Equal(t, ClosestVariant(800), Large)
+	assert.Equal(t, ClosestVariant(4000), Large)
+
+	v, ok := VariantByName("medium")
+	assert.Equal(t, ok, true)
+	assert.Equal(t, v, Medium)
+	_, ok = VariantByName("huge")
+	assert.Equal(t, ok, false)
+}
+
+func TestVariantKey(t *testing.T) {
+	assert.Equal(t, VariantKey("bn3k2.png", Thumbnail), "variants/bn3k2/thumb.jpg")
+	assert.Equal(t, VariantKey("avatars/bn3k2.webp", Large), "variants/avatars/bn3k2/large.jpg")
+}
+
+func TestResize(t *testing.T) {
+	for _, tc := range []struct {
+		width, height  int
+		expectedWidth  int
+		expectedHeight int
+	}{
+		{width: 640, height: 480, expectedWidth: 160, expectedHeight: 120},
+		{width: 100, height: 50, expectedWidth: 100, expectedHeight: 50},
+		{width: 1000, height: 2, expectedWidth: 160, expectedHeight: 1},
+	} {
+		data, err := Resize(testImage(tc.width, tc.height), Thumbnail)
+		assert.Equal(t, err, nil)
+
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+		assert.Equal(t, err, nil)
+		assert.Equal(t, []int{cfg.Width, cfg.Height}, []int{tc.expectedWidth, tc.expectedHeight})
+	}
+}
+
+func TestResizeTransparent(t *testing.T) {
+	data, err := Resize(image.NewNRGBA(image.Rect(0, 0, 4, 4)), Thumbnail)
+	assert.Equal(t, err, nil)
+
+	img, err := Decode(data)
+	assert.Equal(t, err, nil)
+	r, g, b, _ := img.At(1, 1).RGBA()
+	white, _, _, _ := color.White.RGBA()
+	assert.Equal(t, r > white-0x0400 && g > white-0x0400 && b > white-0x0400, true)
+
+	_, err = Decode([]byte("not an image"))
+	assert.Equal(t, err, ErrMalformed)
+}
+
+func TestCheckDimensions(t *testing.T) {
+	limits := ImageLimits{MaxBytes: 1 << 20, MaxWidth: 100, MaxHeight: 50}
+	assert.Equal(t, CheckDimensions(encodePNG(t, 100, 50), limits), nil)
+	assert.Equal(t, CheckDimensions(encodePNG(t, 101, 50), limits), ErrDimensions)
+	assert.Equal(t, CheckDimensions(encodePNG(t, 100, 51), limits), ErrDimensions)
+	assert.Equal(t, CheckDimensions(encodePNG(t, 100, 50)[:30], limits), ErrMalformed)
+	assert.Equal(t, CheckDimensions([]byte("plain text"), limits), ErrUnsupportedFormat)
+}
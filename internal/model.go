@@ -21,6 +21,18 @@ type Course struct {
 
 	Type      string
 	MaxPoints int
+	// Private courses are seen by the author and classrooms they are assigned to, their media urls are signed
+	Private bool
+
+	// PassPercent overrides the configured pass threshold when greater than zero
+	PassPercent float32
+	// ExamTimeLimit in seconds enables the exam mode when greater than zero
+	ExamTimeLimit int
+	ExamTaskCount int
+	ExamCooldown  int
+
+	// DifficultyScore is computed from answers of all learners, from 0 (easy) to 1 (hard)
+	DifficultyScore float32
 }
 
 const (
@@ -36,9 +48,14 @@ type CourseResult struct {
 	UserID   uint
 	CourseID uint
 
-	Points uint
-	Phase  string
-	Passed bool
+	Points    uint
+	Phase     string
+	Passed    bool
+	Direction string
+
+	// MaxPoints overrides the course maximum for results of a subset of tasks, e.g. challenges.
+	// Adaptive sessions store it on their started result when the tasks are selected
+	MaxPoints int
 }
 
 const (
@@ -46,6 +63,12 @@ const (
 	FinishedPhase string = "finished"
 )
 
+const (
+	ForwardDirection string = "forward"
+	ReverseDirection string = "reverse"
+	MixedDirection   string = "mixed"
+)
+
 type Task struct {
 	ID        uint `gorm:"primary_key"`
 	CreatedAt time.Time
@@ -55,6 +78,11 @@ type Task struct {
 	Word      string
 	Translate string
 	Image     string
+	// Audio is the pronunciation of the word, uploaded or synthesized
+	Audio string
+	// AudioAttempts counts failed syntheses, the task is skipped until AudioRetryAt
+	AudioAttempts int
+	AudioRetryAt  *time.Time `gorm:"index"`
 }
 
 type User struct {
@@ -71,4 +99,366 @@ type User struct {
 	Level     int
 	NextLevel string
 	Points    int
+
+	TimeZone      string
+	DailyGoal     int
+	CurrentStreak int
+	LongestStreak int
+	StreakFreezes int
+	// LastActiveDay is the last day in the user time zone on which the daily goal was met
+	LastActiveDay string
+
+	LeagueTier int
+
+	// Privacy settings, everything is shared by default
+	HideResults  bool
+	HideLevelUps bool
+	HideBadges   bool
+	HideCourses  bool
+	HideFollows  bool
+
+	ChallengeWins   int
+	ChallengeLosses int
+	ChallengeDraws  int
+}
+
+type Mistake struct {
+	ID        uint `gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	UserID    uint
+	TaskID    uint
+	CourseID  uint
+	Direction string
+
+	Count         int
+	CorrectStreak int
+	LastSeen      time.Time
+}
+
+// MistakesCourseID identifies the virtual course built from user mistakes
+const MistakesCourseID string = "mistakes"
+
+type Exam struct {
+	ID        uint `gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	UserID   uint `gorm:"unique_index:idx_open_exam"`
+	CourseID uint `gorm:"unique_index:idx_open_exam"`
+	TaskIDs  string
+
+	Deadline   time.Time
+	FinishedAt *time.Time
+	Points     uint
+	MaxPoints  int
+	Passed     bool
+	// Open is true while the exam runs and NULL once it is finished or abandoned,
+	// NULLs do not collide in the unique index so a user has one open exam per course
+	Open *bool `gorm:"column:is_open;unique_index:idx_open_exam"`
+}
+
+type Certificate struct {
+	ID        uint `gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	Code     string `gorm:"unique_index"`
+	UserID   uint
+	CourseID uint
+	ExamID   uint
+
+	Points    uint
+	MaxPoints int
+}
+
+type Answer struct {
+	ID        uint `gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	UserID    uint
+	TaskID    uint
+	CourseID  uint
+	Direction string
+
+	Correct bool
+	// Duration of answering in milliseconds
+	Duration int
+}
+
+// TaskStat aggregates graded answers of a task
+type TaskStat struct {
+	TaskID      uint
+	Attempts    int
+	Correct     int
+	AvgDuration float64
+}
+
+// ActivityDay sums points earned by the user on a day in the user time zone
+type ActivityDay struct {
+	ID        uint `gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	UserID uint   `gorm:"unique_index:idx_activity_day"`
+	Day    string `gorm:"unique_index:idx_activity_day"`
+	XP     int
+}
+
+// DayLayout formats activity days
+const DayLayout = "2006-01-02"
+
+type UserAchievement struct {
+	ID        uint `gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	UserID        uint   `gorm:"unique_index:idx_user_achievement"`
+	AchievementID string `gorm:"unique_index:idx_user_achievement"`
+	UnlockedAt    time.Time
+}
+
+// AchievementRule defines when a badge is unlocked, rules are loaded from YAML
+type AchievementRule struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Event       string  `json:"event"`
+	Metric      string  `json:"metric"`
+	Threshold   float32 `json:"threshold"`
+	MinRatings  int     `json:"minRatings"`
+	XP          int     `json:"xp"`
+}
+
+// League is a weekly cohort of users of the same tier
+type League struct {
+	ID        uint `gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	Tier int `gorm:"unique_index:idx_league_cohort"`
+	// Week is the moment the league week started
+	Week time.Time `gorm:"unique_index:idx_league_cohort"`
+	// Cohort numbers the leagues of the tier in the week
+	Cohort int `gorm:"unique_index:idx_league_cohort"`
+	Closed bool
+}
+
+type LeagueMember struct {
+	ID        uint `gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	LeagueID uint `gorm:"unique_index:idx_league_member"`
+	UserID   uint `gorm:"unique_index:idx_league_member"`
+	XP       int
+
+	// Rank and Result are set when the league week is closed
+	Rank   int
+	Result string
+}
+
+const (
+	PromotedResult  string = "promoted"
+	RelegatedResult string = "relegated"
+	StayedResult    string = "stayed"
+)
+
+// ScoreAggregate sums points earned by the user in a leaderboard window and scope
+type ScoreAggregate struct {
+	ID        uint `gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	// Window is day, week, month or all and Period identifies e.g. the day
+	Window string `gorm:"column:time_window;unique_index:idx_score_board"`
+	Period string `gorm:"unique_index:idx_score_board"`
+	// Scope is global, course or language and ScopeID identifies e.g. the course
+	Scope   string `gorm:"unique_index:idx_score_board"`
+	ScopeID string `gorm:"unique_index:idx_score_board"`
+	UserID  uint   `gorm:"unique_index:idx_score_board"`
+	XP      int
+}
+
+const (
+	DayWindow   string = "day"
+	WeekWindow  string = "week"
+	MonthWindow string = "month"
+	AllWindow   string = "all"
+)
+
+const (
+	GlobalScope    string = "global"
+	CourseScope    string = "course"
+	LanguageScope  string = "language"
+	FriendsScope   string = "friends"
+	ClassroomScope string = "classroom"
+)
+
+type Follow struct {
+	ID        uint `gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	FollowerID uint `gorm:"unique_index:idx_follow"`
+	FolloweeID uint `gorm:"unique_index:idx_follow;index"`
+}
+
+// FeedItem is an activity of the user shown to the followers
+type FeedItem struct {
+	ID        uint `gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	UserID   uint `gorm:"index"`
+	Type     string
+	CourseID uint
+	// Value is the reached level or the result points and Name the badge id
+	Value int
+	Name  string
+}
+
+const (
+	CourseFinishedFeed  string = "course_finished"
+	LevelUpFeed         string = "level_up"
+	BadgeEarnedFeed     string = "badge_earned"
+	CoursePublishedFeed string = "course_published"
+)
+
+type Challenge struct {
+	ID        uint `gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	CourseID     uint
+	ChallengerID uint
+	OpponentID   uint
+	TaskIDs      string
+	MaxPoints    int
+	ExpiresAt    time.Time
+	Status       string
+
+	ChallengerPoints     uint
+	ChallengerFinishedAt *time.Time
+	OpponentPoints       uint
+	OpponentFinishedAt   *time.Time
+	// WinnerID is zero for a draw
+	WinnerID uint
+}
+
+const (
+	PendingChallenge  string = "pending"
+	FinishedChallenge string = "finished"
+	ExpiredChallenge  string = "expired"
+)
+
+type Classroom struct {
+	ID        uint `gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	TeacherID uint
+	Name      string
+	JoinCode  string `gorm:"unique_index"`
+}
+
+type ClassroomMember struct {
+	ID        uint `gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	ClassroomID uint `gorm:"unique_index:idx_classroom_member"`
+	UserID      uint `gorm:"unique_index:idx_classroom_member;index"`
+	Role        string
+}
+
+const (
+	TeacherRole string = "teacher"
+	StudentRole string = "student"
+)
+
+type Assignment struct {
+	ID        uint `gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	ClassroomID uint `gorm:"index"`
+	CourseID    uint
+	DueAt       time.Time
+}
+
+// CourseAttempt counts finished attempts of the course by the user
+type CourseAttempt struct {
+	ID        uint `gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	UserID   uint `gorm:"unique_index:idx_course_attempt"`
+	CourseID uint `gorm:"unique_index:idx_course_attempt"`
+	Attempts int
+}
+
+// CourseTime sums time the user spent answering tasks of the course
+type CourseTime struct {
+	UserID   uint
+	CourseID uint
+	// Duration in milliseconds
+	Duration int
+}
+
+// Notification tells the user about something other users or the service did
+type Notification struct {
+	ID        uint `gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	UserID   uint `gorm:"index"`
+	Type     string
+	ActorID  uint
+	CourseID uint
+	// RefID is the classroom or the challenge, Value the given rate and Name the badge id
+	RefID uint
+	Value float32
+	Name  string
+	// Read is stored in is_read, READ is reserved by MySQL
+	Read bool `gorm:"column:is_read"`
+}
+
+const (
+	RatingNotification     string = "rating"
+	AssignmentNotification string = "assignment"
+	BadgeNotification      string = "badge"
+	ChallengeNotification  string = "challenge"
+)
+
+// NotificationTypes lists the types users can switch off
+var NotificationTypes = []string{RatingNotification, AssignmentNotification, BadgeNotification, ChallengeNotification}
+
+// NotificationPreference is stored when the user changes the default, every type is enabled by default
+type NotificationPreference struct {
+	ID        uint `gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	UserID  uint   `gorm:"unique_index:idx_notification_preference"`
+	Type    string `gorm:"unique_index:idx_notification_preference"`
+	Enabled bool
+}
+
+// Image is an upload kept in the blob store under the hash of its content, audio clips of tasks included.
+// RefCount is the number of courses, tasks and users using it
+type Image struct {
+	ID        uint `gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	// Key is stored in blob_key, KEY is reserved by MySQL
+	Key         string `gorm:"column:blob_key;unique_index"`
+	Size        int64
+	ContentType string
+	RefCount    int
+	// UnreferencedAt is when the image was found unused, it is removed once the grace period passes
+	UnreferencedAt *time.Time
 }
@@ -0,0 +1,94 @@
+// Package pdf writes single page PDF documents with Helvetica text
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// A4 page size in points
+const (
+	PageWidth  = 595
+	PageHeight = 842
+)
+
+type Document struct {
+	content bytes.Buffer
+}
+
+func NewDocument() *Document {
+	return &Document{}
+}
+
+// Text writes a line of text with its baseline at x, y measured from the bottom left corner
+func (d *Document) Text(x, y, size int, text string) {
+	fmt.Fprintf(&d.content, "BT /F1 %d Tf %d %d Td (%s) Tj ET\n", size, x, y, escape(text))
+}
+
+// CenteredText writes a line of text centered horizontally on the page
+func (d *Document) CenteredText(y, size int, text string) {
+	// Helvetica glyphs are about half of the font size wide on average
+	width := len([]rune(text)) * size / 2
+	d.Text((PageWidth-width)/2, y, size, text)
+}
+
+// Line draws a straight line
+func (d *Document) Line(x1, y1, x2, y2 int) {
+	fmt.Fprintf(&d.content, "%d %d m %d %d l S\n", x1, y1, x2, y2)
+}
+
+// Bytes renders the document
+func (d *Document) Bytes() []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Contents 5 0 R /Resources << /Font << /F1 4 0 R >> >> >>", PageWidth, PageHeight),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", d.content.Len(), d.content.String()),
+	}
+
+	out := &bytes.Buffer{}
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return out.Bytes()
+}
+
+// transliteration covers letters missing from WinAnsiEncoding used by the courses
+var transliteration = strings.NewReplacer(
+	"ą", "a", "ć", "c", "ę", "e", "ł", "l", "ń", "n", "ś", "s", "ź", "z", "ż", "z",
+	"Ą", "A", "Ć", "C", "Ę", "E", "Ł", "L", "Ń", "N", "Ś", "S", "Ź", "Z", "Ż", "Z",
+)
+
+func escape(text string) string {
+	text = transliteration.Replace(text)
+	b := &strings.Builder{}
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r < 32:
+			b.WriteRune(' ')
+		case r < 128:
+			b.WriteRune(r)
+		case r < 256:
+			fmt.Fprintf(b, "\\%03o", r)
+		default:
+			b.WriteRune('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,22 @@
+package pdf
+
+import (
+	"bytes"
+	"gopkg.in/go-playground/assert.v1"
+	"strconv"
+	"testing"
+)
+
+func TestDocumentBytes(t *testing.T) {
+	doc := NewDocument()
+	doc.CenteredText(700, 24, "Certyfikat (Zwierzęta)")
+
+	out := doc.Bytes()
+
+	assert.Equal(t, bytes.HasPrefix(out, []byte("%PDF-1.4\n")), true)
+	assert.Equal(t, bytes.HasSuffix(out, []byte("%%EOF\n")), true)
+	assert.Equal(t, bytes.Contains(out, []byte(`(Certyfikat \(Zwierzeta\)) Tj`)), true)
+
+	xref := bytes.Index(out, []byte("xref\n"))
+	assert.Equal(t, bytes.Contains(out, []byte("startxref\n"+strconv.Itoa(xref)+"\n")), true)
+}
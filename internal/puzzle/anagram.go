@@ -0,0 +1,55 @@
+package puzzle
+
+import (
+	"github.com/best-project/api/internal"
+	"math/rand"
+)
+
+type Anagram struct {
+	TaskID  uint   `json:"taskId"`
+	Letters string `json:"letters"`
+	Clue    string `json:"clue"`
+
+	answer string
+}
+
+// shuffleAttempts limits reshuffling when the scrambled word equals the original one
+const shuffleAttempts = 10
+
+func NewAnagrams(tasks []internal.Task, rnd *rand.Rand) []Anagram {
+	result := make([]Anagram, 0)
+	for _, task := range tasks {
+		word := letters(firstAlternative(task.Word))
+		if len(word) < 2 {
+			continue
+		}
+		scrambled := make([]rune, len(word))
+		copy(scrambled, word)
+		for i := 0; i < shuffleAttempts; i++ {
+			rnd.Shuffle(len(scrambled), func(i, j int) {
+				scrambled[i], scrambled[j] = scrambled[j], scrambled[i]
+			})
+			if string(scrambled) != string(word) {
+				break
+			}
+		}
+		result = append(result, Anagram{
+			TaskID:  task.ID,
+			Letters: string(scrambled),
+			Clue:    task.Translate,
+			answer:  string(word),
+		})
+	}
+
+	return result
+}
+
+// CheckAnagram validates the answer for the anagram of the task
+func (p *Puzzle) CheckAnagram(taskID uint, answer string) bool {
+	for _, anagram := range p.Anagrams {
+		if anagram.TaskID == taskID {
+			return anagram.answer == string(letters(answer))
+		}
+	}
+	return false
+}
@@ -0,0 +1,233 @@
+package puzzle
+
+import (
+	"github.com/best-project/api/internal"
+	"math/rand"
+	"sort"
+)
+
+type Crossword struct {
+	Width   int              `json:"width"`
+	Height  int              `json:"height"`
+	Grid    []string         `json:"grid"`
+	Entries []CrosswordEntry `json:"entries"`
+}
+
+type CrosswordEntry struct {
+	Number int    `json:"number"`
+	TaskID uint   `json:"taskId"`
+	Row    int    `json:"row"`
+	Col    int    `json:"col"`
+	Across bool   `json:"across"`
+	Length int    `json:"length"`
+	Clue   string `json:"clue"`
+
+	answer []rune
+}
+
+const (
+	crosswordWords = 12
+	blockCell      = '#'
+	openCell       = '.'
+)
+
+type cell struct {
+	row, col int
+}
+
+type crosswordBuilder struct {
+	letters map[cell]rune
+	across  map[cell]bool
+	down    map[cell]bool
+	entries []CrosswordEntry
+}
+
+// NewCrossword places the words of the tasks on a grid crossing each other,
+// translations are used as clues. Words which cannot cross any placed word are skipped.
+func NewCrossword(tasks []internal.Task, rnd *rand.Rand) *Crossword {
+	candidates := make([]CrosswordEntry, 0)
+	for _, task := range tasks {
+		word := letters(firstAlternative(task.Word))
+		if len(word) < 2 {
+			continue
+		}
+		candidates = append(candidates, CrosswordEntry{TaskID: task.ID, Clue: task.Translate, Length: len(word), answer: word})
+	}
+	rnd.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	if len(candidates) > crosswordWords {
+		candidates = candidates[:crosswordWords]
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Length > candidates[j].Length
+	})
+
+	b := &crosswordBuilder{
+		letters: make(map[cell]rune),
+		across:  make(map[cell]bool),
+		down:    make(map[cell]bool),
+	}
+	for i, entry := range candidates {
+		if i == 0 {
+			entry.Across = true
+			b.place(entry)
+			continue
+		}
+		options := b.options(entry)
+		if len(options) == 0 {
+			continue
+		}
+		b.place(options[rnd.Intn(len(options))])
+	}
+
+	return b.build()
+}
+
+func (b *crosswordBuilder) options(entry CrosswordEntry) []CrosswordEntry {
+	result := make([]CrosswordEntry, 0)
+	for _, placed := range b.entries {
+		for i, pr := range placed.answer {
+			for j, r := range entry.answer {
+				if pr != r {
+					continue
+				}
+				option := entry
+				option.Across = !placed.Across
+				if placed.Across {
+					option.Row, option.Col = placed.Row-j, placed.Col+i
+				} else {
+					option.Row, option.Col = placed.Row+i, placed.Col-j
+				}
+				if b.fits(option) {
+					result = append(result, option)
+				}
+			}
+		}
+	}
+	return result
+}
+
+func (b *crosswordBuilder) fits(entry CrosswordEntry) bool {
+	dRow, dCol := step(entry.Across)
+	before := cell{entry.Row - dRow, entry.Col - dCol}
+	after := cell{entry.Row + dRow*entry.Length, entry.Col + dCol*entry.Length}
+	if _, ok := b.letters[before]; ok {
+		return false
+	}
+	if _, ok := b.letters[after]; ok {
+		return false
+	}
+
+	crossings := 0
+	for i, r := range entry.answer {
+		c := cell{entry.Row + dRow*i, entry.Col + dCol*i}
+		if existing, ok := b.letters[c]; ok {
+			if existing != r || (entry.Across && b.across[c]) || (!entry.Across && b.down[c]) {
+				return false
+			}
+			crossings++
+			continue
+		}
+		// an empty cell must not touch letters of parallel words
+		if _, ok := b.letters[cell{c.row + dCol, c.col + dRow}]; ok {
+			return false
+		}
+		if _, ok := b.letters[cell{c.row - dCol, c.col - dRow}]; ok {
+			return false
+		}
+	}
+	return crossings > 0
+}
+
+func (b *crosswordBuilder) place(entry CrosswordEntry) {
+	dRow, dCol := step(entry.Across)
+	for i, r := range entry.answer {
+		c := cell{entry.Row + dRow*i, entry.Col + dCol*i}
+		b.letters[c] = r
+		if entry.Across {
+			b.across[c] = true
+		} else {
+			b.down[c] = true
+		}
+	}
+	b.entries = append(b.entries, entry)
+}
+
+func (b *crosswordBuilder) build() *Crossword {
+	cw := &Crossword{Grid: make([]string, 0), Entries: make([]CrosswordEntry, 0)}
+	if len(b.entries) == 0 {
+		return cw
+	}
+
+	minRow, minCol, maxRow, maxCol := b.entries[0].Row, b.entries[0].Col, b.entries[0].Row, b.entries[0].Col
+	for c := range b.letters {
+		minRow, minCol = min(minRow, c.row), min(minCol, c.col)
+		maxRow, maxCol = max(maxRow, c.row), max(maxCol, c.col)
+	}
+	cw.Height, cw.Width = maxRow-minRow+1, maxCol-minCol+1
+
+	for row := minRow; row <= maxRow; row++ {
+		line := make([]rune, 0, cw.Width)
+		for col := minCol; col <= maxCol; col++ {
+			if _, ok := b.letters[cell{row, col}]; ok {
+				line = append(line, openCell)
+			} else {
+				line = append(line, blockCell)
+			}
+		}
+		cw.Grid = append(cw.Grid, string(line))
+	}
+
+	for _, entry := range b.entries {
+		entry.Row -= minRow
+		entry.Col -= minCol
+		cw.Entries = append(cw.Entries, entry)
+	}
+	sort.SliceStable(cw.Entries, func(i, j int) bool {
+		if cw.Entries[i].Row != cw.Entries[j].Row {
+			return cw.Entries[i].Row < cw.Entries[j].Row
+		}
+		return cw.Entries[i].Col < cw.Entries[j].Col
+	})
+	number := 0
+	for i := range cw.Entries {
+		if i == 0 || cw.Entries[i].Row != cw.Entries[i-1].Row || cw.Entries[i].Col != cw.Entries[i-1].Col {
+			number++
+		}
+		cw.Entries[i].Number = number
+	}
+
+	return cw
+}
+
+// CheckCrossword validates the answer for the crossword entry of the task
+func (p *Puzzle) CheckCrossword(taskID uint, answer string) bool {
+	for _, entry := range p.Crossword.Entries {
+		if entry.TaskID == taskID {
+			return string(entry.answer) == string(letters(answer))
+		}
+	}
+	return false
+}
+
+func step(across bool) (int, int) {
+	if across {
+		return 0, 1
+	}
+	return 1, 0
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
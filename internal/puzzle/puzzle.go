@@ -0,0 +1,52 @@
+package puzzle
+
+import (
+	"github.com/best-project/api/internal"
+	"math/rand"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Puzzle holds every exercise generated for a puzzle course. The same tasks
+// and seed always give the same puzzle, so answers can be validated by
+// generating it again.
+type Puzzle struct {
+	Seed       int64       `json:"seed"`
+	Anagrams   []Anagram   `json:"anagrams"`
+	WordSearch *WordSearch `json:"wordSearch"`
+	Crossword  *Crossword  `json:"crossword"`
+}
+
+// Generate builds all puzzle exercises from the tasks
+func Generate(tasks []internal.Task, seed int64) *Puzzle {
+	sorted := make([]internal.Task, len(tasks))
+	copy(sorted, tasks)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	rnd := rand.New(rand.NewSource(seed))
+	return &Puzzle{
+		Seed:       seed,
+		Anagrams:   NewAnagrams(sorted, rnd),
+		WordSearch: NewWordSearch(sorted, rnd),
+		Crossword:  NewCrossword(sorted, rnd),
+	}
+}
+
+// letters returns the upper cased letters of the word without spaces and punctuation
+func letters(word string) []rune {
+	result := make([]rune, 0, len(word))
+	for _, r := range strings.ToUpper(word) {
+		if unicode.IsLetter(r) {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// firstAlternative returns the first of comma separated alternatives
+func firstAlternative(s string) string {
+	return strings.TrimSpace(strings.Split(s, ",")[0])
+}
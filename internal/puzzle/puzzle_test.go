@@ -0,0 +1,71 @@
+package puzzle
+
+import (
+	"github.com/best-project/api/internal"
+	"gopkg.in/go-playground/assert.v1"
+	"reflect"
+	"testing"
+)
+
+var tasks = []internal.Task{
+	{ID: 1, Word: "art", Translate: "sztuka"},
+	{ID: 2, Word: "artist", Translate: "artysta"},
+	{ID: 3, Word: "band", Translate: "zespół, kapela"},
+	{ID: 4, Word: "brush", Translate: "szczotka, pędzel"},
+	{ID: 5, Word: "camera", Translate: "kamera, aparat fotograficzny"},
+	{ID: 6, Word: "canvas", Translate: "płótno"},
+	{ID: 7, Word: "cinema", Translate: "kino"},
+	{ID: 8, Word: "culture", Translate: "kultura"},
+}
+
+func TestGenerateIsDeterministic(t *testing.T) {
+	reversed := make([]internal.Task, 0)
+	for i := len(tasks) - 1; i >= 0; i-- {
+		reversed = append(reversed, tasks[i])
+	}
+
+	first := Generate(tasks, 42)
+	second := Generate(reversed, 42)
+
+	assert.Equal(t, reflect.DeepEqual(first, second), true)
+	assert.Equal(t, reflect.DeepEqual(first, Generate(tasks, 43)), false)
+}
+
+func TestCheckAnagram(t *testing.T) {
+	p := Generate(tasks, 7)
+
+	assert.Equal(t, len(p.Anagrams), len(tasks))
+	assert.Equal(t, p.CheckAnagram(2, "Artist"), true)
+	assert.Equal(t, p.CheckAnagram(2, "artists"), false)
+}
+
+func TestCheckWordSearch(t *testing.T) {
+	p := Generate(tasks, 7)
+
+	spans := make([]internal.PuzzleSpan, 0)
+	for _, placement := range p.WordSearch.placements {
+		length := len([]rune(placement.word)) - 1
+		spans = append(spans, internal.PuzzleSpan{
+			Row:    placement.row + placement.dRow*length,
+			Col:    placement.col + placement.dCol*length,
+			EndRow: placement.row,
+			EndCol: placement.col,
+		})
+	}
+
+	assert.Equal(t, len(p.CheckWordSearch(spans)), len(p.WordSearch.Words))
+	assert.Equal(t, len(p.CheckWordSearch([]internal.PuzzleSpan{{Row: -1, Col: -1}})), 0)
+}
+
+func TestCrosswordCells(t *testing.T) {
+	p := Generate(tasks, 7)
+
+	assert.NotEqual(t, len(p.Crossword.Entries), 0)
+	for _, entry := range p.Crossword.Entries {
+		dRow, dCol := step(entry.Across)
+		for i := 0; i < entry.Length; i++ {
+			assert.Equal(t, rune(p.Crossword.Grid[entry.Row+dRow*i][entry.Col+dCol*i]), openCell)
+		}
+		assert.Equal(t, p.CheckCrossword(entry.TaskID, string(entry.answer)), true)
+	}
+}
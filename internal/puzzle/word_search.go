@@ -0,0 +1,121 @@
+package puzzle
+
+import (
+	"github.com/best-project/api/internal"
+	"math/rand"
+)
+
+type WordSearch struct {
+	Size  int      `json:"size"`
+	Grid  []string `json:"grid"`
+	Words []string `json:"words"`
+
+	placements []placement
+}
+
+type placement struct {
+	word     string
+	row, col int
+	dRow     int
+	dCol     int
+}
+
+const (
+	wordSearchWords   = 8
+	wordSearchMinSize = 10
+	wordSearchMaxSize = 15
+	placeAttempts     = 100
+)
+
+var searchDirections = [][2]int{{0, 1}, {1, 0}, {1, 1}, {-1, 1}, {0, -1}, {-1, 0}, {-1, -1}, {1, -1}}
+
+func NewWordSearch(tasks []internal.Task, rnd *rand.Rand) *WordSearch {
+	words := make([][]rune, 0)
+	size := wordSearchMinSize
+	for _, task := range tasks {
+		word := letters(firstAlternative(task.Word))
+		if len(word) < 2 || len(word) > wordSearchMaxSize {
+			continue
+		}
+		words = append(words, word)
+		if len(word) > size {
+			size = len(word)
+		}
+		if len(words) == wordSearchWords {
+			break
+		}
+	}
+
+	grid := make([][]rune, size)
+	for i := range grid {
+		grid[i] = make([]rune, size)
+	}
+
+	ws := &WordSearch{Size: size, Words: make([]string, 0)}
+	for _, word := range words {
+		if p, ok := placeInGrid(grid, word, rnd); ok {
+			ws.placements = append(ws.placements, p)
+			ws.Words = append(ws.Words, string(word))
+		}
+	}
+
+	alphabet := []rune("ABCDEFGHIJKLMNOPRSTUWYZ")
+	for _, row := range grid {
+		for i := range row {
+			if row[i] == 0 {
+				row[i] = alphabet[rnd.Intn(len(alphabet))]
+			}
+		}
+		ws.Grid = append(ws.Grid, string(row))
+	}
+
+	return ws
+}
+
+func placeInGrid(grid [][]rune, word []rune, rnd *rand.Rand) (placement, bool) {
+	size := len(grid)
+	for attempt := 0; attempt < placeAttempts; attempt++ {
+		dir := searchDirections[rnd.Intn(len(searchDirections))]
+		row, col := rnd.Intn(size), rnd.Intn(size)
+		endRow, endCol := row+dir[0]*(len(word)-1), col+dir[1]*(len(word)-1)
+		if endRow < 0 || endRow >= size || endCol < 0 || endCol >= size {
+			continue
+		}
+
+		fits := true
+		for i, r := range word {
+			cell := grid[row+dir[0]*i][col+dir[1]*i]
+			if cell != 0 && cell != r {
+				fits = false
+				break
+			}
+		}
+		if !fits {
+			continue
+		}
+		for i, r := range word {
+			grid[row+dir[0]*i][col+dir[1]*i] = r
+		}
+		return placement{word: string(word), row: row, col: col, dRow: dir[0], dCol: dir[1]}, true
+	}
+	return placement{}, false
+}
+
+// CheckWordSearch returns the words hidden in the grid at the given spans,
+// a span marks a found word by the positions of its first and last letter
+func (p *Puzzle) CheckWordSearch(spans []internal.PuzzleSpan) []string {
+	found := make([]string, 0)
+	for _, placement := range p.WordSearch.placements {
+		length := len([]rune(placement.word)) - 1
+		endRow, endCol := placement.row+placement.dRow*length, placement.col+placement.dCol*length
+		for _, span := range spans {
+			forward := span.Row == placement.row && span.Col == placement.col && span.EndRow == endRow && span.EndCol == endCol
+			backward := span.Row == endRow && span.Col == endCol && span.EndRow == placement.row && span.EndCol == placement.col
+			if forward || backward {
+				found = append(found, placement.word)
+				break
+			}
+		}
+	}
+	return found
+}
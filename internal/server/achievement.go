@@ -0,0 +1,20 @@
+package server
+
+import (
+	"github.com/best-project/api/internal"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+func (srv *Server) listAchievements(w http.ResponseWriter, r *http.Request) {
+	writeResponseJson(w, http.StatusOK, srv.converter.AchievementConverter.ManyRulesToDTO(srv.achievement.Rules()))
+}
+
+func (srv *Server) userAchievements(userID uint) ([]internal.AchievementDTO, error) {
+	unlocked, err := srv.achievement.ListForUser(userID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "while listing achievements of user %d", userID)
+	}
+
+	return srv.converter.AchievementConverter.ManyToDTO(unlocked, srv.achievement.Rules()), nil
+}
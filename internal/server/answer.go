@@ -0,0 +1,145 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/server/pretty"
+	"github.com/choria-io/go-validator/enum"
+	"github.com/go-playground/validator"
+	"github.com/pkg/errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var answerDirections = []string{internal.ForwardDirection, internal.ReverseDirection}
+
+func (srv *Server) checkAnswer(w http.ResponseWriter, r *http.Request) {
+	user, err := ParseJWT(r.Header.Get("Authorization"))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while parsing jwt token"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
+		return
+	}
+	answerDTO := &internal.AnswerDTO{}
+	if err := json.NewDecoder(r.Body).Decode(answerDTO); err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while decoding json body"))
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewDecodeError(pretty.Answer))
+		return
+	}
+	if err := srv.validator.Struct(answerDTO); err != nil {
+		e := err.(validator.ValidationErrors)
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewErrorValidate(pretty.Answer, e))
+		return
+	}
+	if answerDTO.Direction == "" {
+		answerDTO.Direction = internal.ForwardDirection
+	}
+	if _, err := enum.ValidateString(answerDTO.Direction, answerDirections); err != nil {
+		srv.logger.Errorln(errors.Wrap(err, "while validate field: direction"))
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewBadRequest())
+		return
+	}
+	taskID, err := strconv.Atoi(answerDTO.TaskID)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while parsing task ID: %s", answerDTO.TaskID))
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewBadRequest())
+		return
+	}
+	task, err := srv.db.Task.GetByID(uint(taskID))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while getting task"))
+		writeMessageResponse(w, http.StatusNotFound, pretty.NewNotFoundError(pretty.Task))
+		return
+	}
+
+	duration := time.Duration(answerDTO.TimeMs) * time.Millisecond
+	correct, err := srv.answerLogic.CheckAnswer(user.ID, task, answerDTO.Direction, answerDTO.Answer, duration)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while checking answer"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
+		return
+	}
+
+	dto := struct {
+		Correct   bool   `json:"correct"`
+		TaskID    string `json:"taskId"`
+		Direction string `json:"direction"`
+		Word      string `json:"word"`
+		Translate string `json:"translate"`
+	}{}
+	dto.Correct = correct
+	dto.TaskID = answerDTO.TaskID
+	dto.Direction = answerDTO.Direction
+	dto.Word = task.Word
+	dto.Translate = task.Translate
+
+	writeResponseJson(w, http.StatusOK, dto)
+}
+
+func (srv *Server) listMistakeTasks(userID uint) ([]internal.Mistake, []internal.Task, error) {
+	mistakes, err := srv.db.Mistake.ListForUser(userID)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "while listing mistakes")
+	}
+	if len(mistakes) == 0 {
+		return mistakes, []internal.Task{}, nil
+	}
+	tasks, err := srv.db.Task.GetManyByID(srv.converter.MistakeConverter.FetchTaskIDs(mistakes))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "while getting mistake tasks")
+	}
+
+	return mistakes, tasks, nil
+}
+
+func (srv *Server) getMistakes(w http.ResponseWriter, r *http.Request) {
+	user, err := ParseJWT(r.Header.Get("Authorization"))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while parsing jwt token"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
+		return
+	}
+	mistakes, tasks, err := srv.listMistakeTasks(user.ID)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while listing mistakes for user %d", user.ID))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorList(pretty.Mistakes))
+		return
+	}
+
+	writeResponseJson(w, http.StatusOK, srv.converter.MistakeConverter.ManyToDTO(mistakes, tasks))
+}
+
+// getMistakesCourse serves user mistakes as a virtual course which can be practised like any other course
+func (srv *Server) getMistakesCourse(w http.ResponseWriter, r *http.Request) {
+	user, err := ParseJWT(r.Header.Get("Authorization"))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while parsing jwt token"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
+		return
+	}
+	mistakes, tasks, err := srv.listMistakeTasks(user.ID)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while listing mistakes for user %d", user.ID))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorList(pretty.Mistakes))
+		return
+	}
+
+	course := &internal.Course{
+		UserID:      user.ID,
+		Name:        "My mistakes",
+		Description: "Words you answered wrong recently.",
+		Type:        internal.NormalType,
+	}
+	dto, err := srv.converter.CourseConverter.ToDTO(course)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while converting course to dto"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorConvert(pretty.Course))
+		return
+	}
+	dto.CourseID = internal.MistakesCourseID
+	dto.Data = srv.converter.MistakeConverter.ManyToTaskDTO(mistakes, tasks)
+	dto.MaxPoints = len(dto.Data) * srv.xp.ForTask(internal.NormalType)
+
+	writeResponseJson(w, http.StatusOK, []internal.CourseDTO{*dto})
+}
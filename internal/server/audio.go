@@ -0,0 +1,39 @@
+package server
+
+import (
+	"github.com/best-project/api/internal/media"
+	"github.com/best-project/api/internal/server/pretty"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+// saveAudio stores the audio form file and returns its url, empty when no file is sent
+func (srv *Server) saveAudio(r *http.Request) (string, error) {
+	file, header, err := r.FormFile("audio")
+	if err == http.ErrMissingFile {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "while getting audio from form")
+	}
+	defer file.Close()
+
+	if header.Size > srv.audioMaxBytes {
+		return "", media.ErrTooLarge
+	}
+	return srv.audio.Store(file)
+}
+
+func (srv *Server) writeAudioError(w http.ResponseWriter, err error) {
+	switch errors.Cause(err) {
+	case media.ErrTooLarge:
+		writeMessageResponse(w, http.StatusRequestEntityTooLarge, pretty.NewTooLargeError(pretty.Audio, formatBytes(srv.audioMaxBytes)))
+	case media.ErrUnsupportedFormat:
+		writeMessageResponse(w, http.StatusUnsupportedMediaType, pretty.NewUnsupportedFormatError(pretty.Audio, media.AudioTypes))
+	case media.ErrMalformed:
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewMalformedError(pretty.Audio))
+	default:
+		srv.logger.Errorln(errors.Wrap(err, "while saving audio"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorSave(pretty.Audio))
+	}
+}
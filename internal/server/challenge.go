@@ -0,0 +1,202 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/server/pretty"
+	"github.com/best-project/api/internal/service"
+	"github.com/go-playground/validator"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func (srv *Server) createChallenge(w http.ResponseWriter, r *http.Request) {
+	user, err := ParseJWT(r.Header.Get("Authorization"))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while parsing jwt token"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
+		return
+	}
+	createDTO := &internal.ChallengeCreateDTO{}
+	if err := json.NewDecoder(r.Body).Decode(createDTO); err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while decoding json body"))
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewDecodeError(pretty.Challenge))
+		return
+	}
+	if err := srv.validator.Struct(createDTO); err != nil {
+		e := err.(validator.ValidationErrors)
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewErrorValidate(pretty.Challenge, e))
+		return
+	}
+	course, err := srv.db.Course.GetByID(createDTO.CourseID)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while getting course %s", createDTO.CourseID))
+		writeMessageResponse(w, http.StatusNotFound, pretty.NewNotFoundError(pretty.Course))
+		return
+	}
+	if !srv.courseAccessible(w, user.ID, course) {
+		return
+	}
+	if _, err := srv.db.User.GetByID(createDTO.OpponentID); err != nil {
+		writeMessageResponse(w, http.StatusNotFound, pretty.NewNotFoundError(pretty.User))
+		return
+	}
+	// the opponent gets the tasks and their media too
+	if !srv.courseAccessible(w, createDTO.OpponentID, course) {
+		return
+	}
+
+	challenge, tasks, err := srv.challenges.Create(user.ID, createDTO.OpponentID, course, time.Duration(createDTO.ExpiresIn)*time.Second)
+	switch errors.Cause(err) {
+	case nil:
+	case service.ErrSelfChallenge, service.ErrChallengeExpiry, service.ErrChallengeNoTasks:
+		writeErrorResponse(w, http.StatusBadRequest, err)
+		return
+	default:
+		srv.logger.Errorln(errors.Wrap(err, "while creating challenge"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorSave(pretty.Challenge))
+		return
+	}
+
+	writeResponseJson(w, http.StatusCreated, srv.challengeToDTO(challenge, user.ID, tasks))
+}
+
+func (srv *Server) getChallenge(w http.ResponseWriter, r *http.Request) {
+	user, err := ParseJWT(r.Header.Get("Authorization"))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while parsing jwt token"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
+		return
+	}
+	challengeID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeMessageResponse(w, http.StatusBadRequest, "provide Challenge id")
+		return
+	}
+
+	challenge, tasks, err := srv.challenges.Get(user.ID, uint(challengeID))
+	switch errors.Cause(err) {
+	case nil:
+	case service.ErrNotParticipant:
+		writeMessageResponse(w, http.StatusForbidden, pretty.NewForbiddenError(pretty.Challenge))
+		return
+	default:
+		srv.logger.Errorln(errors.Wrapf(err, "while getting challenge %d", challengeID))
+		writeMessageResponse(w, http.StatusNotFound, pretty.NewNotFoundError(pretty.Challenge))
+		return
+	}
+	course, err := srv.db.Course.GetByID(strconv.Itoa(int(challenge.CourseID)))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while getting course %d", challenge.CourseID))
+		writeMessageResponse(w, http.StatusNotFound, pretty.NewNotFoundError(pretty.Course))
+		return
+	}
+	// a player who lost access to a private course does not get its tasks anymore
+	if !srv.courseAccessible(w, user.ID, course) {
+		return
+	}
+
+	writeResponseJson(w, http.StatusOK, srv.challengeToDTO(challenge, user.ID, tasks))
+}
+
+func (srv *Server) finishChallenge(w http.ResponseWriter, r *http.Request) {
+	user, err := ParseJWT(r.Header.Get("Authorization"))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while parsing jwt token"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
+		return
+	}
+	challengeID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeMessageResponse(w, http.StatusBadRequest, "provide Challenge id")
+		return
+	}
+	answersDTO := &internal.ChallengeAnswersDTO{}
+	if err := json.NewDecoder(r.Body).Decode(answersDTO); err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while decoding json body"))
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewDecodeError(pretty.Challenge))
+		return
+	}
+
+	challenge, err := srv.challenges.Finish(user.ID, uint(challengeID), answersDTO.Answers)
+	switch errors.Cause(err) {
+	case nil:
+	case service.ErrNotParticipant:
+		writeMessageResponse(w, http.StatusForbidden, pretty.NewForbiddenError(pretty.Challenge))
+		return
+	case service.ErrChallengeFinished, service.ErrChallengeExpired, service.ErrChallengeClosed:
+		writeErrorResponse(w, http.StatusConflict, err)
+		return
+	default:
+		srv.logger.Errorln(errors.Wrapf(err, "while finishing challenge %d", challengeID))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorSave(pretty.Challenge))
+		return
+	}
+
+	writeResponseJson(w, http.StatusOK, srv.challengeToDTO(challenge, user.ID, nil))
+}
+
+func (srv *Server) listChallenges(w http.ResponseWriter, r *http.Request) {
+	user, err := ParseJWT(r.Header.Get("Authorization"))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while parsing jwt token"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
+		return
+	}
+
+	challenges, err := srv.challenges.ListForUser(user.ID)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while listing challenges of user %d", user.ID))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorList(pretty.Challenge))
+		return
+	}
+	result := make([]internal.ChallengeDTO, 0)
+	for i := range challenges {
+		result = append(result, srv.challengeToDTO(&challenges[i], user.ID, nil))
+	}
+
+	writeResponseJson(w, http.StatusOK, result)
+}
+
+// challengeToDTO describes the challenge to the player, the opponent score stays
+// hidden until the challenge is decided so it cannot be used to aim for a win
+func (srv *Server) challengeToDTO(challenge *internal.Challenge, userID uint, tasks []internal.Task) internal.ChallengeDTO {
+	dto := internal.ChallengeDTO{
+		ChallengeID:  strconv.Itoa(int(challenge.ID)),
+		CourseID:     strconv.Itoa(int(challenge.CourseID)),
+		ChallengerID: challenge.ChallengerID,
+		OpponentID:   challenge.OpponentID,
+		ExpiresAt:    challenge.ExpiresAt,
+		Status:       challenge.Status,
+		MaxPoints:    challenge.MaxPoints,
+		WinnerID:     challenge.WinnerID,
+	}
+	decided := challenge.Status != internal.PendingChallenge
+	if challenge.ChallengerFinishedAt != nil && (decided || userID == challenge.ChallengerID) {
+		points := challenge.ChallengerPoints
+		dto.ChallengerPoints = &points
+	}
+	if challenge.OpponentFinishedAt != nil && (decided || userID == challenge.OpponentID) {
+		points := challenge.OpponentPoints
+		dto.OpponentPoints = &points
+	}
+	if userID == challenge.ChallengerID {
+		dto.FinishedAt = challenge.ChallengerFinishedAt
+	} else {
+		dto.FinishedAt = challenge.OpponentFinishedAt
+	}
+	if tasks != nil && !decided && dto.FinishedAt == nil {
+		dto.Tasks = srv.converter.CourseConverter.TaskConverter.ManyToDTO(tasks)
+		for i := range dto.Tasks {
+			// answers are graded on the server
+			dto.Tasks[i].Translate = ""
+		}
+		// the handlers passing tasks have checked the player can view the course
+		srv.signTaskMedia(dto.Tasks, time.Now())
+	}
+
+	return dto
+}
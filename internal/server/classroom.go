@@ -0,0 +1,253 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/server/pretty"
+	"github.com/best-project/api/internal/service"
+	"github.com/go-playground/validator"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"net/http"
+	"strconv"
+)
+
+func (srv *Server) createClassroom(w http.ResponseWriter, r *http.Request) {
+	user, err := ParseJWT(r.Header.Get("Authorization"))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while parsing jwt token"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
+		return
+	}
+	classroomDTO := &internal.ClassroomDTO{}
+	if err := json.NewDecoder(r.Body).Decode(classroomDTO); err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while decoding json body"))
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewDecodeError(pretty.Classroom))
+		return
+	}
+	if err := srv.validator.Struct(classroomDTO); err != nil {
+		e := err.(validator.ValidationErrors)
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewErrorValidate(pretty.Classroom, e))
+		return
+	}
+
+	classroom, err := srv.classrooms.Create(user.ID, classroomDTO.Name)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrap(err, "while creating classroom"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorSave(pretty.Classroom))
+		return
+	}
+
+	writeResponseJson(w, http.StatusCreated, srv.converter.ClassroomConverter.ToDTO(*classroom, internal.TeacherRole))
+}
+
+func (srv *Server) joinClassroom(w http.ResponseWriter, r *http.Request) {
+	user, err := ParseJWT(r.Header.Get("Authorization"))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while parsing jwt token"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
+		return
+	}
+	joinDTO := struct {
+		JoinCode string `json:"joinCode" validate:"required"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&joinDTO); err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while decoding json body"))
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewDecodeError(pretty.Classroom))
+		return
+	}
+	if err := srv.validator.Struct(joinDTO); err != nil {
+		e := err.(validator.ValidationErrors)
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewErrorValidate(pretty.Classroom, e))
+		return
+	}
+
+	classroom, err := srv.classrooms.Join(user.ID, joinDTO.JoinCode)
+	switch errors.Cause(err) {
+	case nil:
+	case service.ErrUnknownJoinCode:
+		writeMessageResponse(w, http.StatusNotFound, pretty.NewNotFoundError(pretty.Classroom))
+		return
+	default:
+		srv.logger.Errorln(errors.Wrap(err, "while joining classroom"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorSave(pretty.Classroom))
+		return
+	}
+
+	srv.writeClassroom(w, user.ID, classroom.ID)
+}
+
+func (srv *Server) listClassrooms(w http.ResponseWriter, r *http.Request) {
+	user, err := ParseJWT(r.Header.Get("Authorization"))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while parsing jwt token"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
+		return
+	}
+
+	classrooms, err := srv.classrooms.ListForUser(user.ID)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while listing classrooms of user %d", user.ID))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorList(pretty.Classroom))
+		return
+	}
+	result := make([]internal.ClassroomDTO, 0)
+	for _, classroom := range classrooms {
+		role := internal.StudentRole
+		if classroom.TeacherID == user.ID {
+			role = internal.TeacherRole
+		}
+		result = append(result, srv.converter.ClassroomConverter.ToDTO(classroom, role))
+	}
+
+	writeResponseJson(w, http.StatusOK, result)
+}
+
+func (srv *Server) getClassroom(w http.ResponseWriter, r *http.Request) {
+	user, err := ParseJWT(r.Header.Get("Authorization"))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while parsing jwt token"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
+		return
+	}
+	classroomID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeMessageResponse(w, http.StatusBadRequest, "provide Classroom id")
+		return
+	}
+
+	srv.writeClassroom(w, user.ID, uint(classroomID))
+}
+
+func (srv *Server) writeClassroom(w http.ResponseWriter, userID, classroomID uint) {
+	classroom, member, assignments, err := srv.classrooms.Get(userID, classroomID)
+	switch errors.Cause(err) {
+	case nil:
+	case service.ErrNotMember:
+		writeMessageResponse(w, http.StatusForbidden, pretty.NewForbiddenError(pretty.Classroom))
+		return
+	default:
+		srv.logger.Errorln(errors.Wrapf(err, "while getting classroom %d", classroomID))
+		writeMessageResponse(w, http.StatusNotFound, pretty.NewNotFoundError(pretty.Classroom))
+		return
+	}
+	names, err := srv.courseNames(srv.converter.ClassroomConverter.FetchCourseIDs(assignments))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrap(err, "while getting assigned courses"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorGet(pretty.Courses))
+		return
+	}
+
+	dto := srv.converter.ClassroomConverter.ToDTO(*classroom, member.Role)
+	dto.Assignments = srv.converter.ClassroomConverter.ManyAssignmentsToDTO(assignments, names)
+
+	writeResponseJson(w, http.StatusOK, dto)
+}
+
+func (srv *Server) createAssignment(w http.ResponseWriter, r *http.Request) {
+	user, err := ParseJWT(r.Header.Get("Authorization"))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while parsing jwt token"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
+		return
+	}
+	classroomID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeMessageResponse(w, http.StatusBadRequest, "provide Classroom id")
+		return
+	}
+	assignmentDTO := &internal.AssignmentDTO{}
+	if err := json.NewDecoder(r.Body).Decode(assignmentDTO); err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while decoding json body"))
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewDecodeError(pretty.Assignment))
+		return
+	}
+	if err := srv.validator.Struct(assignmentDTO); err != nil {
+		e := err.(validator.ValidationErrors)
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewErrorValidate(pretty.Assignment, e))
+		return
+	}
+	course, err := srv.db.Course.GetByID(assignmentDTO.CourseID)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while getting course %s", assignmentDTO.CourseID))
+		writeMessageResponse(w, http.StatusNotFound, pretty.NewNotFoundError(pretty.Course))
+		return
+	}
+	// students of a classroom see its private courses, only the author shares them
+	if course.Private && course.UserID != user.ID {
+		writeMessageResponse(w, http.StatusForbidden, pretty.NewForbiddenError(pretty.Course))
+		return
+	}
+
+	assignment, err := srv.classrooms.Assign(user.ID, uint(classroomID), course.ID, assignmentDTO.DueAt)
+	switch errors.Cause(err) {
+	case nil:
+	case service.ErrNotTeacher:
+		writeMessageResponse(w, http.StatusForbidden, pretty.NewForbiddenError(pretty.Classroom))
+		return
+	default:
+		srv.logger.Errorln(errors.Wrapf(err, "while assigning course %d", course.ID))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorSave(pretty.Assignment))
+		return
+	}
+
+	writeResponseJson(w, http.StatusCreated, srv.converter.ClassroomConverter.AssignmentToDTO(*assignment, map[uint]string{course.ID: course.Name}))
+}
+
+func (srv *Server) getClassroomDashboard(w http.ResponseWriter, r *http.Request) {
+	user, err := ParseJWT(r.Header.Get("Authorization"))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while parsing jwt token"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
+		return
+	}
+	classroomID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeMessageResponse(w, http.StatusBadRequest, "provide Classroom id")
+		return
+	}
+
+	dashboard, err := srv.classrooms.Dashboard(user.ID, uint(classroomID))
+	switch errors.Cause(err) {
+	case nil:
+	case service.ErrNotTeacher:
+		writeMessageResponse(w, http.StatusForbidden, pretty.NewForbiddenError(pretty.Classroom))
+		return
+	default:
+		srv.logger.Errorln(errors.Wrapf(err, "while building dashboard of classroom %d", classroomID))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorGet(pretty.Classroom))
+		return
+	}
+
+	students := make([]uint, 0, len(dashboard.Students))
+	for _, student := range dashboard.Students {
+		students = append(students, student.UserID)
+	}
+	users, err := srv.db.User.GetManyByID(students)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrap(err, "while getting students"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorGet(pretty.Users))
+		return
+	}
+	names, err := srv.courseNames(srv.converter.ClassroomConverter.FetchCourseIDs(dashboard.Assignments))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrap(err, "while getting assigned courses"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorGet(pretty.Courses))
+		return
+	}
+
+	writeResponseJson(w, http.StatusOK, srv.converter.ClassroomConverter.DashboardToDTO(dashboard, users, names))
+}
+
+func (srv *Server) courseNames(ids []string) (map[uint]string, error) {
+	courses, err := srv.existingCourses(ids)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[uint]string, len(courses))
+	for _, course := range courses {
+		names[course.ID] = course.Name
+	}
+
+	return names, nil
+}
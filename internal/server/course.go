@@ -2,23 +2,26 @@ package server
 
 import (
 	"encoding/json"
-	"fmt"
 	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/event"
+	"github.com/best-project/api/internal/media"
 	"github.com/best-project/api/internal/server/pretty"
+	"github.com/best-project/api/internal/service"
 	"github.com/choria-io/go-validator/enum"
 	"github.com/go-playground/validator"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
-	"github.com/rs/xid"
 	"io"
+	"math/rand"
 	"net/http"
-	"os"
 	"strconv"
 	"time"
 )
 
 var courseTypes = []string{internal.NormalType, internal.PuzzleType}
 
+var sessionDirections = []string{internal.ForwardDirection, internal.ReverseDirection, internal.MixedDirection}
+
 const (
 	MB = 1 << 20
 )
@@ -29,7 +32,10 @@ func (srv *Server) getCourseData(r *http.Request) (*internal.CourseDTO, error) {
 		return nil, err
 	}
 
-	imgPath, _ := srv.saveImage(r)
+	imgPath, err := srv.saveImage(r)
+	if media.Rejected(err) {
+		return nil, err
+	}
 	courseDTO.Image = imgPath
 	courseDTO.Name = r.FormValue("name")
 	courseDTO.CourseID = r.FormValue("courseId")
@@ -42,9 +48,48 @@ func (srv *Server) getCourseData(r *http.Request) (*internal.CourseDTO, error) {
 		return nil, errors.Wrapf(err, "invalid course type %s", courseDTO.Type)
 	}
 
+	if courseDTO.ExamTimeLimit, err = formInt(r, "examTimeLimit"); err != nil {
+		return nil, err
+	}
+	if courseDTO.ExamTaskCount, err = formInt(r, "examTaskCount"); err != nil {
+		return nil, err
+	}
+	if courseDTO.ExamCooldown, err = formInt(r, "examCooldown"); err != nil {
+		return nil, err
+	}
+	if value := r.FormValue("passPercent"); value != "" {
+		passPercent, err := strconv.ParseFloat(value, 32)
+		if err != nil || passPercent <= 0 || passPercent > 1 {
+			return nil, errors.Errorf("invalid pass percent %s", value)
+		}
+		courseDTO.PassPercent = float32(passPercent)
+	}
+	if value := r.FormValue("private"); value != "" {
+		if courseDTO.Private, err = strconv.ParseBool(value); err != nil {
+			return nil, errors.Errorf("invalid private value %s", value)
+		}
+	}
+
 	return courseDTO, nil
 }
 
+func formInt(r *http.Request, key string) (int, error) {
+	value := r.FormValue(key)
+	if value == "" {
+		return 0, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 {
+		return 0, errors.Errorf("invalid %s value %s", key, value)
+	}
+	return parsed, nil
+}
+
+func formHas(r *http.Request, key string) bool {
+	_, ok := r.Form[key]
+	return ok
+}
+
 func (srv *Server) getTasksData(body io.ReadCloser) ([]internal.TaskDTO, error) {
 	tasksDTO := make([]internal.TaskDTO, 0)
 	err := json.NewDecoder(body).Decode(&tasksDTO)
@@ -63,6 +108,10 @@ func (srv *Server) createCourse(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	course, err := srv.getCourseData(r)
+	if media.Rejected(err) {
+		srv.writeImageError(w, err)
+		return
+	}
 	if err != nil {
 		srv.logger.Errorln(errors.Wrapf(err, "while decoding json body"))
 		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewDecodeError(pretty.Course))
@@ -76,13 +125,15 @@ func (srv *Server) createCourse(w http.ResponseWriter, r *http.Request) {
 
 	course.Rate = 3
 	course.UserID = user.ID
-	course.MaxPoints = len(course.Data) * srv.xpForTask
+	course.MaxPoints = len(course.Data) * srv.xp.ForTask(course.Type)
 
-	if err := srv.db.Course.SaveCourse(srv.converter.CourseConverter.ToModel(course), srv.xpForTask); err != nil {
+	courseModel := srv.converter.CourseConverter.ToModel(course)
+	if err := srv.db.Course.SaveCourse(courseModel, srv.xp.ForTask(courseModel.Type)); err != nil {
 		srv.logger.Errorln(errors.Wrapf(err, "while saving course"))
 		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorSave(pretty.Course))
 		return
 	}
+	srv.events.Publish(event.Event{Type: event.CoursePublished, UserID: user.ID, CourseID: courseModel.ID})
 
 	writeMessageResponse(w, http.StatusCreated, pretty.NewCreateMessage(pretty.Course))
 }
@@ -95,6 +146,10 @@ func (srv *Server) updateCourse(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	course, err := srv.getCourseData(r)
+	if media.Rejected(err) {
+		srv.writeImageError(w, err)
+		return
+	}
 	if err != nil {
 		srv.logger.Errorln(errors.Wrapf(err, "while decoding json body"))
 		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewDecodeError(pretty.Course))
@@ -133,9 +188,24 @@ func (srv *Server) updateCourse(w http.ResponseWriter, r *http.Request) {
 	if course.Name != "" {
 		courseModel.Name = course.Name
 	}
+	if course.PassPercent != 0 {
+		courseModel.PassPercent = course.PassPercent
+	}
+	if formHas(r, "examTimeLimit") {
+		courseModel.ExamTimeLimit = course.ExamTimeLimit
+	}
+	if formHas(r, "examTaskCount") {
+		courseModel.ExamTaskCount = course.ExamTaskCount
+	}
+	if formHas(r, "examCooldown") {
+		courseModel.ExamCooldown = course.ExamCooldown
+	}
+	if formHas(r, "private") {
+		courseModel.Private = course.Private
+	}
 	courseModel.UpdatedAt = time.Now()
 
-	if err := srv.db.Course.SaveCourse(courseModel, srv.xpForTask); err != nil {
+	if err := srv.db.Course.SaveCourse(courseModel, srv.xp.ForTask(courseModel.Type)); err != nil {
 		srv.logger.Errorln(errors.Wrapf(err, "while saving course"))
 		writeMessageResponse(w, http.StatusInternalServerError, "")
 		return
@@ -145,11 +215,17 @@ func (srv *Server) updateCourse(w http.ResponseWriter, r *http.Request) {
 }
 
 func (srv *Server) rateCourse(w http.ResponseWriter, r *http.Request) {
+	user, err := ParseJWT(r.Header.Get("Authorization"))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while parsing jwt token"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
+		return
+	}
 	rateDTO := struct {
 		Rate     int    `json:"rate"`
 		CourseID string `json:"courseId"`
 	}{}
-	err := json.NewDecoder(r.Body).Decode(&rateDTO)
+	err = json.NewDecoder(r.Body).Decode(&rateDTO)
 	if err != nil {
 		srv.logger.Errorln(errors.Wrapf(err, "while parsing jwt token"))
 		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
@@ -168,38 +244,16 @@ func (srv *Server) rateCourse(w http.ResponseWriter, r *http.Request) {
 	course.Rate = rate / float32(course.RateCounter+1)
 	course.RateCounter++
 
-	if err := srv.db.Course.SaveCourse(course, srv.xpForTask); err != nil {
+	if err := srv.db.Course.SaveCourse(course, srv.xp.ForTask(course.Type)); err != nil {
 		srv.logger.Errorln(errors.Wrapf(err, "while parsing jwt token"))
 		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
 		return
 	}
+	srv.events.Publish(event.Event{Type: event.RatingReceived, UserID: course.UserID, ActorID: user.ID, CourseID: course.ID, Value: float32(rateDTO.Rate)})
 
 	writeMessageResponse(w, http.StatusOK, pretty.NewCreateMessage(pretty.Course))
 }
 
-func (srv *Server) saveImage(r *http.Request) (string, error) {
-	//Content-Type
-	file, _, err := r.FormFile("image")
-	if err != nil {
-		return "", errors.Wrap(err, "while getting image from form")
-	}
-	defer file.Close()
-
-	imgPath := fmt.Sprintf("/images/%s.png", xid.New().String())
-	saveFile, err := os.Create(imgPath)
-	if err != nil {
-		return "", err
-	}
-	defer saveFile.Close()
-
-	// Use io.Copy to just dump the response body to the file. This supports huge files
-	_, err = io.Copy(saveFile, file)
-	if err != nil {
-		return "", err
-	}
-	return imgPath, nil
-}
-
 func (srv *Server) addTasksToCourse(w http.ResponseWriter, r *http.Request) {
 	user, err := ParseJWT(r.Header.Get("Authorization"))
 	if err != nil {
@@ -214,11 +268,20 @@ func (srv *Server) addTasksToCourse(w http.ResponseWriter, r *http.Request) {
 	}
 
 	taskDTO := internal.TaskDTO{}
-	imgPath, err := srv.saveImage(r)
+	imgPath, err := srv.taskImage(r)
+	if media.Rejected(err) {
+		srv.writeImageError(w, err)
+		return
+	}
 	if err != nil {
 		srv.logger.Errorln(errors.Wrapf(err, "while parsing multipart file"))
 	}
 	taskDTO.Image = imgPath
+	taskDTO.Audio, err = srv.saveAudio(r)
+	if err != nil {
+		srv.writeAudioError(w, err)
+		return
+	}
 	taskDTO.CourseID = r.FormValue("courseId")
 	taskDTO.Word = r.FormValue("word")
 	taskDTO.Translate = r.FormValue("translate")
@@ -239,9 +302,9 @@ func (srv *Server) addTasksToCourse(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	course.Task = append(course.Task, srv.converter.CourseConverter.TaskConverter.ConvertToModel(taskDTO))
-	course.MaxPoints = len(course.Task) * srv.xpForTask
+	course.MaxPoints = len(course.Task) * srv.xp.ForTask(course.Type)
 
-	if err := srv.db.Course.SaveCourse(course, srv.xpForTask); err != nil {
+	if err := srv.db.Course.SaveCourse(course, srv.xp.ForTask(course.Type)); err != nil {
 		srv.logger.Errorln(errors.Wrapf(err, "while saving course"))
 		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorSave(pretty.Course))
 		return
@@ -269,7 +332,11 @@ func (srv *Server) editTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	taskDTO := internal.TaskDTO{}
-	imgPath, err := srv.saveImage(r)
+	imgPath, err := srv.taskImage(r)
+	if media.Rejected(err) {
+		srv.writeImageError(w, err)
+		return
+	}
 	if err != nil {
 		srv.logger.Errorln(errors.Wrapf(err, "while parsing multipart file"))
 		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorSave(pretty.Task))
@@ -277,6 +344,11 @@ func (srv *Server) editTask(w http.ResponseWriter, r *http.Request) {
 	}
 	taskDTO.ID = id
 	taskDTO.Image = imgPath
+	taskDTO.Audio, err = srv.saveAudio(r)
+	if err != nil {
+		srv.writeAudioError(w, err)
+		return
+	}
 	taskDTO.CourseID = r.FormValue("courseId")
 	taskDTO.Word = r.FormValue("word")
 	taskDTO.Translate = r.FormValue("translate")
@@ -343,7 +415,7 @@ func (srv *Server) removeTasksFromCourse(w http.ResponseWriter, r *http.Request)
 		writeMessageResponse(w, http.StatusBadRequest, pretty.NewErrorRemove(pretty.Task))
 		return
 	}
-	course.MaxPoints -= srv.xpForTask
+	course.MaxPoints -= srv.xp.ForTask(course.Type)
 
 	tasks := make([]internal.Task, 0)
 	for _, t :=  range course.Task {
@@ -354,7 +426,7 @@ func (srv *Server) removeTasksFromCourse(w http.ResponseWriter, r *http.Request)
 	}
 	course.Task = tasks
 
-	if err := srv.db.Course.SaveCourse(course, srv.xpForTask); err != nil {
+	if err := srv.db.Course.SaveCourse(course, srv.xp.ForTask(course.Type)); err != nil {
 		srv.logger.Errorln(errors.Wrapf(err, "while saving course"))
 		writeMessageResponse(w, http.StatusInternalServerError, "")
 		return
@@ -371,6 +443,13 @@ func (srv *Server) applyBestPointsToCourses(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	courses, err = srv.visibleCourses(token.ID, courses)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while checking access to courses"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorList(pretty.Courses))
+		return
+	}
+
 	results, err := srv.db.CourseResult.ListBestResultsForUser(token.ID)
 	if err != nil {
 		srv.logger.Errorln(errors.Wrapf(err, "while listing finished results"))
@@ -512,13 +591,53 @@ func (srv *Server) getCourse(w http.ResponseWriter, r *http.Request) {
 		writeMessageResponse(w, http.StatusBadRequest, "provide Course id")
 		return
 	}
+	if id == internal.MistakesCourseID {
+		srv.getMistakesCourse(w, r)
+		return
+	}
+	direction := r.URL.Query().Get("direction")
+	if direction == "" {
+		direction = internal.ForwardDirection
+	}
+	if _, err := enum.ValidateString(direction, sessionDirections); err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "invalid direction %s", direction))
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewBadRequest())
+		return
+	}
 	course, err := srv.db.Course.GetByID(id)
 	if err != nil {
 		srv.logger.Errorln(errors.Wrapf(err, "while getting from storage course ID: %s", id))
 		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorGet(pretty.Course))
 		return
 	}
+	user, err := ParseJWT(r.Header.Get("Authorization"))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while parsing jwt token"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
+		return
+	}
+	if !srv.courseAccessible(w, user.ID, course) {
+		return
+	}
 	course.Task = srv.db.Task.GetTasksForCourse(course)
+	if r.URL.Query().Get("adaptive") == "true" {
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		course.Task, err = srv.difficulty.SelectAdaptive(user.ID, course, limit, rand.New(rand.NewSource(time.Now().UnixNano())))
+		if err != nil {
+			srv.logger.Errorln(errors.Wrapf(err, "while selecting adaptive tasks"))
+			writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorList(pretty.Tasks))
+			return
+		}
+		// the result of the session is checked against the points of the selected tasks
+		course.MaxPoints = len(course.Task) * srv.xp.ForTask(course.Type)
+		started := &internal.CourseResult{UserID: user.ID, CourseID: course.ID, Phase: internal.StartedPhase,
+			Direction: direction, MaxPoints: course.MaxPoints}
+		if err := srv.saveStartedResult(started); err != nil {
+			srv.logger.Errorln(errors.Wrapf(err, "while starting adaptive session"))
+			writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorSave(pretty.CourseResult))
+			return
+		}
+	}
 
 	dto, err := srv.converter.CourseConverter.ToDTO(course)
 	if err != nil {
@@ -526,6 +645,45 @@ func (srv *Server) getCourse(w http.ResponseWriter, r *http.Request) {
 		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorConvert(pretty.Course))
 		return
 	}
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := range dto.Data {
+		dto.Data[i].Direction = service.ResolveDirection(direction, rnd)
+	}
 
 	srv.applyBestPointsToCourses(w, r, []internal.CourseDTO{*dto})
 }
+
+// getCourseDifficulty shows the course author which words learners fail the most
+func (srv *Server) getCourseDifficulty(w http.ResponseWriter, r *http.Request) {
+	user, err := ParseJWT(r.Header.Get("Authorization"))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while parsing jwt token"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
+		return
+	}
+	id := mux.Vars(r)["id"]
+	course, err := srv.db.Course.GetByID(id)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while getting from storage course ID: %s", id))
+		writeMessageResponse(w, http.StatusNotFound, pretty.NewNotFoundError(pretty.Course))
+		return
+	}
+	if course.UserID != user.ID {
+		writeMessageResponse(w, http.StatusForbidden, pretty.NewForbiddenError(pretty.Course))
+		return
+	}
+
+	stats, err := srv.difficulty.HardestTasks(course)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while getting hardest tasks"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorList(pretty.Tasks))
+		return
+	}
+	dto := internal.CourseDifficultyDTO{
+		CourseID:        id,
+		DifficultyScore: course.DifficultyScore,
+		Tasks:           srv.converter.CourseConverter.TaskConverter.ManyStatsToDTO(stats, course.Task),
+	}
+
+	writeResponseJson(w, http.StatusOK, dto)
+}
@@ -24,12 +24,36 @@ func (srv *Server) getCourseResultData(body io.ReadCloser) (*internal.CourseResu
 	if _, err := enum.ValidateString(courseDTO.Phase, coursePhases); err != nil {
 		return nil, errors.Wrap(err, "while validate field: phase")
 	}
+	if courseDTO.Direction == "" {
+		courseDTO.Direction = internal.ForwardDirection
+	}
+	if _, err := enum.ValidateString(courseDTO.Direction, sessionDirections); err != nil {
+		return nil, errors.Wrap(err, "while validate field: direction")
+	}
 
 	return courseDTO, nil
 }
 
 var coursePhases = []string{internal.StartedPhase, internal.FinishedPhase}
 
+// saveStartedResult keeps one started result per user. Starting the course again keeps the max points
+// stored when the tasks of an adaptive session were selected
+func (srv *Server) saveStartedResult(result *internal.CourseResult) error {
+	results, err := srv.db.CourseResult.ListStartedForUser(result.UserID)
+	if err != nil {
+		return errors.Wrapf(err, "while listing for user %d", result.UserID)
+	}
+	if len(results) == 1 {
+		result.ID = results[0].ID
+		result.UpdatedAt = time.Now()
+		if result.MaxPoints == 0 && results[0].CourseID == result.CourseID {
+			result.MaxPoints = results[0].MaxPoints
+		}
+	}
+
+	return srv.db.CourseResult.SaveResult(result)
+}
+
 func (srv *Server) saveResult(w http.ResponseWriter, r *http.Request) {
 	user, err := ParseJWT(r.Header.Get("Authorization"))
 	if err != nil {
@@ -48,6 +72,11 @@ func (srv *Server) saveResult(w http.ResponseWriter, r *http.Request) {
 		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorValidate(pretty.CourseResult, e))
 		return
 	}
+	if courseDTO.CourseID == internal.MistakesCourseID {
+		// mistakes are tracked per answer, the virtual course does not store results nor award points
+		writeMessageResponse(w, http.StatusCreated, pretty.NewCreateMessage(pretty.CourseResult))
+		return
+	}
 	if !srv.db.Course.Exist(courseDTO.CourseID) {
 		srv.logger.Errorln(errors.New("course not exist"))
 		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewNotFoundError(pretty.Course))
@@ -57,17 +86,7 @@ func (srv *Server) saveResult(w http.ResponseWriter, r *http.Request) {
 
 	result := srv.converter.CourseResultConverter.ToModel(courseDTO)
 	if courseDTO.Phase == internal.StartedPhase {
-		results, err := srv.db.CourseResult.ListStartedForUser(courseDTO.UserID)
-		if err != nil {
-			srv.logger.Errorln(errors.Wrapf(err, "while listing for user %d", courseDTO.UserID))
-			writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorList(pretty.CourseResults))
-			return
-		}
-		if len(results) == 1 {
-			result.ID = results[0].ID
-			result.UpdatedAt = time.Now()
-		}
-		if err := srv.db.CourseResult.SaveResult(result); err != nil {
+		if err := srv.saveStartedResult(result); err != nil {
 			srv.logger.Errorln(errors.Wrapf(err, "while saving course"))
 			writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorSave(pretty.CourseResult))
 			return
@@ -76,6 +95,12 @@ func (srv *Server) saveResult(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	started, err := srv.db.CourseResult.GetStartedForUserForCourse(user.ID, result.CourseID)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while getting started course"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorList(pretty.CourseResult))
+		return
+	}
 	courseResult, bestPoints, err := srv.db.CourseResult.ReplaceIfExist(result)
 	if err != nil {
 		srv.logger.Errorln(errors.Wrapf(err, "while replacing started course"))
@@ -84,13 +109,28 @@ func (srv *Server) saveResult(w http.ResponseWriter, r *http.Request) {
 	}
 
 	courseResult.UserID = user.ID
+	// the result is graded against the points stored when the session started, never against the request
+	courseResult.MaxPoints = 0
+	if started != nil {
+		courseResult.MaxPoints = started.MaxPoints
+	}
 	passed, err := srv.courseLogic.CheckResult(courseResult)
 	if err != nil {
 		srv.logger.Errorln(errors.Wrapf(err, "while checking result"))
 		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
 		return
 	}
+	if started != nil && started.MaxPoints > 0 {
+		// the next session of the course is graded against the whole course unless it is adaptive again
+		started.MaxPoints = 0
+		if err := srv.db.CourseResult.SaveResult(started); err != nil {
+			srv.logger.Errorln(errors.Wrapf(err, "while ending started course"))
+		}
+	}
 	srv.logger.Info("trying to save result")
+	if _, err := srv.difficulty.UpdateCourseDifficulty(courseResult.CourseID); err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while updating course difficulty"))
+	}
 
 	dto := struct {
 		Passed     bool   `json:"passed"`
@@ -133,6 +173,12 @@ func (srv *Server) getFinishedCourses(w http.ResponseWriter, r *http.Request) {
 		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorConvert(pretty.Courses))
 		return
 	}
+	dto, err = srv.visibleCourses(user.ID, dto)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while checking access to courses"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorList(pretty.Courses))
+		return
+	}
 
 	writeResponseJson(w, http.StatusOK, dto)
 }
@@ -163,6 +209,12 @@ func (srv *Server) getStartedCourses(w http.ResponseWriter, r *http.Request) {
 		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorConvert(pretty.Courses))
 		return
 	}
+	dto, err = srv.visibleCourses(user.ID, dto)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while checking access to courses"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorList(pretty.Courses))
+		return
+	}
 
 	writeResponseJson(w, http.StatusOK, dto)
 }
@@ -189,7 +241,7 @@ func (srv *Server) courseRanking(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	sort.Slice(results, func(i, j int) bool {
-		return results[i].Points < results[j].Points
+		return results[i].Points > results[j].Points
 	})
 
 	writeResponseJson(w, http.StatusOK, results)
@@ -203,7 +255,7 @@ func (srv *Server) usersRanking(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	sort.Slice(users, func(i, j int) bool {
-		return users[i].Points < users[j].Points
+		return users[i].Points > users[j].Points
 	})
 
 	writeResponseJson(w, http.StatusOK, srv.converter.ManyToUserStat(users))
@@ -224,7 +276,7 @@ func (srv *Server) userRanking(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	sort.Slice(results, func(i, j int) bool {
-		return results[i].Points < results[j].Points
+		return results[i].Points > results[j].Points
 	})
 
 	dto, err := srv.converter.CourseResultConverter.ManyToDTO(results)
@@ -0,0 +1,196 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/pdf"
+	"github.com/best-project/api/internal/server/pretty"
+	"github.com/best-project/api/internal/service"
+	"github.com/go-playground/validator"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func (srv *Server) startExam(w http.ResponseWriter, r *http.Request) {
+	user, err := ParseJWT(r.Header.Get("Authorization"))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while parsing jwt token"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
+		return
+	}
+	startDTO := struct {
+		CourseID string `json:"courseId" validate:"required"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&startDTO); err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while decoding json body"))
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewDecodeError(pretty.Exam))
+		return
+	}
+	if err := srv.validator.Struct(startDTO); err != nil {
+		e := err.(validator.ValidationErrors)
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewErrorValidate(pretty.Exam, e))
+		return
+	}
+	course, err := srv.db.Course.GetByID(startDTO.CourseID)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while getting course %s", startDTO.CourseID))
+		writeMessageResponse(w, http.StatusNotFound, pretty.NewNotFoundError(pretty.Course))
+		return
+	}
+	if !srv.courseAccessible(w, user.ID, course) {
+		return
+	}
+
+	exam, tasks, err := srv.examLogic.Start(user.ID, course)
+	switch errors.Cause(err) {
+	case nil:
+	case service.ErrExamDisabled:
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewNotSupportedError(pretty.Course, "exams"))
+		return
+	case service.ErrExamCooldown:
+		writeErrorResponse(w, http.StatusTooManyRequests, err)
+		return
+	default:
+		srv.logger.Errorln(errors.Wrapf(err, "while starting exam"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorSave(pretty.Exam))
+		return
+	}
+
+	dto := internal.ExamDTO{
+		ExamID:    strconv.Itoa(int(exam.ID)),
+		CourseID:  strconv.Itoa(int(exam.CourseID)),
+		Deadline:  exam.Deadline,
+		TimeLimit: course.ExamTimeLimit,
+		Tasks:     srv.converter.CourseConverter.TaskConverter.ManyToDTO(tasks),
+	}
+	for i := range dto.Tasks {
+		// answers are graded on the server
+		dto.Tasks[i].Translate = ""
+	}
+	if course.Private {
+		srv.signTaskMedia(dto.Tasks, time.Now())
+	}
+
+	writeResponseJson(w, http.StatusCreated, dto)
+}
+
+func (srv *Server) finishExam(w http.ResponseWriter, r *http.Request) {
+	user, err := ParseJWT(r.Header.Get("Authorization"))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while parsing jwt token"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
+		return
+	}
+	answersDTO := &internal.ExamAnswersDTO{}
+	if err := json.NewDecoder(r.Body).Decode(answersDTO); err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while decoding json body"))
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewDecodeError(pretty.Exam))
+		return
+	}
+	if err := srv.validator.Struct(answersDTO); err != nil {
+		e := err.(validator.ValidationErrors)
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewErrorValidate(pretty.Exam, e))
+		return
+	}
+	examID, err := strconv.Atoi(answersDTO.ExamID)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while parsing exam ID: %s", answersDTO.ExamID))
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewBadRequest())
+		return
+	}
+
+	exam, certificate, err := srv.examLogic.Finish(user.ID, uint(examID), answersDTO.Answers)
+	switch errors.Cause(err) {
+	case nil:
+	case service.ErrExamNotOwned:
+		writeMessageResponse(w, http.StatusForbidden, pretty.NewForbiddenError(pretty.Exam))
+		return
+	case service.ErrExamFinished, service.ErrExamExpired:
+		writeErrorResponse(w, http.StatusConflict, err)
+		return
+	default:
+		srv.logger.Errorln(errors.Wrapf(err, "while finishing exam %d", examID))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorSave(pretty.Exam))
+		return
+	}
+
+	dto := internal.ExamResultDTO{
+		ExamID:    strconv.Itoa(int(exam.ID)),
+		CourseID:  strconv.Itoa(int(exam.CourseID)),
+		Points:    exam.Points,
+		MaxPoints: exam.MaxPoints,
+		Passed:    exam.Passed,
+	}
+	if certificate != nil {
+		dto.CertificateCode = certificate.Code
+	}
+
+	writeResponseJson(w, http.StatusOK, dto)
+}
+
+func (srv *Server) getCertificateData(code string) (*internal.CertificateDTO, error) {
+	certificate, err := srv.db.Certificate.GetByCode(code)
+	if err != nil {
+		return nil, errors.Wrapf(err, "while getting certificate %s", code)
+	}
+	user, err := srv.db.User.GetByID(certificate.UserID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "while getting user %d", certificate.UserID)
+	}
+	course, err := srv.db.Course.GetByID(strconv.Itoa(int(certificate.CourseID)))
+	if err != nil {
+		return nil, errors.Wrapf(err, "while getting course %d", certificate.CourseID)
+	}
+
+	return &internal.CertificateDTO{
+		Code:       certificate.Code,
+		UserID:     user.ID,
+		UserName:   fmt.Sprintf("%s %s", user.FirstName, user.LastName),
+		CourseID:   strconv.Itoa(int(course.ID)),
+		CourseName: course.Name,
+		Points:     certificate.Points,
+		MaxPoints:  certificate.MaxPoints,
+		IssuedAt:   certificate.CreatedAt,
+	}, nil
+}
+
+func (srv *Server) verifyCertificate(w http.ResponseWriter, r *http.Request) {
+	code := mux.Vars(r)["code"]
+	dto, err := srv.getCertificateData(code)
+	if err != nil {
+		srv.logger.Errorln(err)
+		writeMessageResponse(w, http.StatusNotFound, pretty.NewNotFoundError(pretty.Certificate))
+		return
+	}
+
+	writeResponseJson(w, http.StatusOK, dto)
+}
+
+func (srv *Server) getCertificatePDF(w http.ResponseWriter, r *http.Request) {
+	code := mux.Vars(r)["code"]
+	dto, err := srv.getCertificateData(code)
+	if err != nil {
+		srv.logger.Errorln(err)
+		writeMessageResponse(w, http.StatusNotFound, pretty.NewNotFoundError(pretty.Certificate))
+		return
+	}
+
+	doc := pdf.NewDocument()
+	doc.CenteredText(680, 36, "Certificate")
+	doc.CenteredText(630, 14, "This certifies that")
+	doc.CenteredText(590, 24, dto.UserName)
+	doc.CenteredText(550, 14, "has passed the exam of the course")
+	doc.CenteredText(510, 22, dto.CourseName)
+	doc.CenteredText(470, 14, fmt.Sprintf("with %d of %d points on %s", dto.Points, dto.MaxPoints, dto.IssuedAt.Format("2 January 2006")))
+	doc.Line(100, 420, pdf.PageWidth-100, 420)
+	doc.CenteredText(390, 10, fmt.Sprintf("Certificate code: %s", dto.Code))
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=certificate-%s.pdf", dto.Code))
+	w.Header().Set("Content-Type", "application/pdf")
+	w.WriteHeader(http.StatusOK)
+	w.Write(doc.Bytes())
+}
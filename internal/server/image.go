@@ -0,0 +1,250 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/best-project/api/internal/media"
+	"github.com/best-project/api/internal/server/pretty"
+	"github.com/best-project/api/internal/storage"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"image"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultImageType = "image/png"
+	blobCacheControl = "public, max-age=31536000, immutable"
+)
+
+// saveImage validates the image form file, stores it without metadata and returns its url,
+// an image uploaded before is not stored again.
+// Files rejected by the validation are reported with errors recognized by media.Rejected
+func (srv *Server) saveImage(r *http.Request) (string, error) {
+	file, header, err := r.FormFile("image")
+	if err != nil {
+		return "", errors.Wrap(err, "while getting image from form")
+	}
+	defer file.Close()
+
+	if header.Size > srv.imageLimits.MaxBytes {
+		return "", media.ErrTooLarge
+	}
+	img, err := media.ProcessImage(file, srv.imageLimits)
+	if err != nil {
+		return "", errors.Wrapf(err, "while processing image %s", header.Filename)
+	}
+
+	key, created, err := srv.images.Store(img)
+	if err != nil {
+		return "", err
+	}
+	if created {
+		if decoded, err := media.Decode(img.Data); err == nil {
+			srv.saveVariants(key, decoded)
+		}
+	}
+	return srv.blobs.URL(key), nil
+}
+
+// taskImage imports the image linked in the imageUrl field when no file is uploaded
+func (srv *Server) taskImage(r *http.Request) (string, error) {
+	imageURL := r.FormValue("imageUrl")
+	if imageURL == "" {
+		return srv.saveImage(r)
+	}
+	if _, _, err := r.FormFile("image"); err != http.ErrMissingFile {
+		return srv.saveImage(r)
+	}
+	return srv.imports.Import(r.Context(), imageURL)
+}
+
+// writeImageError responds with the reason the uploaded image was rejected
+func (srv *Server) writeImageError(w http.ResponseWriter, err error) {
+	switch errors.Cause(err) {
+	case media.ErrTooLarge:
+		writeMessageResponse(w, http.StatusRequestEntityTooLarge, pretty.NewTooLargeError(pretty.Image, formatBytes(srv.imageLimits.MaxBytes)))
+	case media.ErrUnsupportedFormat:
+		writeMessageResponse(w, http.StatusUnsupportedMediaType, pretty.NewUnsupportedFormatError(pretty.Image, media.ImageTypes))
+	case media.ErrMalformed:
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewMalformedError(pretty.Image))
+	case media.ErrDimensions:
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewTooLargeError(pretty.Image,
+			fmt.Sprintf("%dx%d pixels", srv.imageLimits.MaxWidth, srv.imageLimits.MaxHeight)))
+	case media.ErrBlockedAddress:
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewBlockedURLError(pretty.Image))
+	case media.ErrFetchFailed:
+		writeMessageResponse(w, http.StatusUnprocessableEntity, pretty.NewFetchError(pretty.Image))
+	default:
+		srv.logger.Errorln(errors.Wrap(err, "while saving image"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorSave(pretty.Image))
+	}
+}
+
+func formatBytes(n int64) string {
+	if n >= MB && n%MB == 0 {
+		return fmt.Sprintf("%d MB", n/MB)
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
+
+// getImage serves the image or audio clip or, with the size or w parameter, the closest resized variant of the image.
+// Blobs are never overwritten so responses are cached for good, media of private courses until the signed url expires
+func (srv *Server) getImage(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	variant, resized, err := requestedVariant(r)
+	if err != nil {
+		writeMessageResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	cacheControl, ok := srv.mediaCacheControl(w, r, name)
+	if !ok {
+		return
+	}
+	key := name
+	if resized {
+		key, err = srv.imageVariant(name, variant)
+	}
+	etag := blobETag(key)
+	if err == nil && etagMatches(r.Header.Get("If-None-Match"), etag) {
+		setBlobCacheHeaders(w, etag, cacheControl)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	var body storage.ReadSeekCloser
+	var info *storage.BlobInfo
+	if err == nil {
+		body, info, err = storage.OpenBlob(srv.blobs, key)
+	}
+	switch errors.Cause(err) {
+	case nil:
+	case storage.ErrBlobNotFound, storage.ErrInvalidBlobKey:
+		writeMessageResponse(w, http.StatusNotFound, pretty.NewNotFoundError(pretty.Image))
+		return
+	case media.ErrMalformed, media.ErrUnsupportedFormat:
+		writeMessageResponse(w, http.StatusUnprocessableEntity, pretty.NewMalformedError(pretty.Image))
+		return
+	case media.ErrDimensions:
+		writeMessageResponse(w, http.StatusUnprocessableEntity, pretty.NewTooLargeError(pretty.Image,
+			fmt.Sprintf("%dx%d pixels", srv.imageLimits.MaxWidth, srv.imageLimits.MaxHeight)))
+		return
+	default:
+		srv.logger.Errorln(errors.Wrapf(err, "while getting image %s", name))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorGet(pretty.Image))
+		return
+	}
+	defer body.Close()
+
+	contentType := info.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(key))
+	}
+	if contentType == "" {
+		contentType = defaultImageType
+	}
+	setBlobCacheHeaders(w, etag, cacheControl)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%s", path.Base(key)))
+	w.Header().Set("Content-Type", contentType)
+	// Range requests let audio players seek without downloading the whole clip
+	http.ServeContent(w, r, path.Base(key), info.ModTime, body)
+}
+
+// requestedVariant reads the size name or the width in pixels, false means the original image
+func requestedVariant(r *http.Request) (media.Variant, bool, error) {
+	if size := r.URL.Query().Get("size"); size != "" {
+		variant, ok := media.VariantByName(size)
+		if !ok {
+			return media.Variant{}, false, errors.Errorf("unknown image size %s", size)
+		}
+		return variant, true, nil
+	}
+	if value := r.URL.Query().Get("w"); value != "" {
+		width, err := strconv.Atoi(value)
+		if err != nil || width <= 0 {
+			return media.Variant{}, false, errors.Errorf("invalid image width %s", value)
+		}
+		return media.ClosestVariant(width), true, nil
+	}
+	return media.Variant{}, false, nil
+}
+
+// saveVariants stores the resized copies of a new image, failures are only logged
+// since missing variants are created on the first request
+func (srv *Server) saveVariants(key string, img image.Image) {
+	for _, variant := range media.Variants {
+		if err := srv.saveVariant(key, img, variant); err != nil {
+			srv.logger.Errorln(errors.Wrapf(err, "while creating %s variant of image %s", variant.Name, key))
+		}
+	}
+}
+
+func (srv *Server) saveVariant(key string, img image.Image, variant media.Variant) error {
+	data, err := media.Resize(img, variant)
+	if err != nil {
+		return err
+	}
+	return srv.blobs.Put(media.VariantKey(key, variant), bytes.NewReader(data), int64(len(data)), media.JPEGType)
+}
+
+// imageVariant returns the key of the variant, creating it for images uploaded before variants existed.
+// Those may predate the upload limits so their size is checked before decoding
+func (srv *Server) imageVariant(key string, variant media.Variant) (string, error) {
+	variantKey := media.VariantKey(key, variant)
+	_, err := srv.blobs.Stat(variantKey)
+	if err != storage.ErrBlobNotFound {
+		return variantKey, err
+	}
+
+	body, _, err := srv.blobs.Get(key)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return "", errors.Wrapf(err, "while reading image %s", key)
+	}
+	if err := media.CheckDimensions(data, srv.imageLimits); err != nil {
+		return "", err
+	}
+	img, err := media.Decode(data)
+	if err != nil {
+		return "", err
+	}
+	if err := srv.saveVariant(key, img, variant); err != nil {
+		return "", errors.Wrapf(err, "while creating %s variant of image %s", variant.Name, key)
+	}
+	return variantKey, nil
+}
+
+// blobETag is strong as a key always points to the same content
+func blobETag(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+func setBlobCacheHeaders(w http.ResponseWriter, etag, cacheControl string) {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", cacheControl)
+}
+
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/server/pretty"
+	"github.com/best-project/api/internal/service"
+	"github.com/choria-io/go-validator/enum"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+const (
+	defaultLeaderboardLimit = 20
+	maxLeaderboardLimit     = 100
+)
+
+var (
+	leaderboardWindows = []string{internal.DayWindow, internal.WeekWindow, internal.MonthWindow, internal.AllWindow}
+	leaderboardScopes  = []string{internal.GlobalScope, internal.CourseScope, internal.LanguageScope, internal.FriendsScope, internal.ClassroomScope}
+)
+
+func (srv *Server) getLeaderboard(w http.ResponseWriter, r *http.Request) {
+	token, err := ParseJWT(r.Header.Get("Authorization"))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while parsing jwt token"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
+		return
+	}
+
+	query := service.LeaderboardQuery{
+		Window:   r.FormValue("window"),
+		Scope:    r.FormValue("scope"),
+		ScopeID:  r.FormValue("id"),
+		UserID:   token.ID,
+		AroundMe: r.FormValue("around") == "me",
+	}
+	if query.Window == "" {
+		query.Window = internal.AllWindow
+	}
+	if query.Scope == "" {
+		query.Scope = internal.GlobalScope
+	}
+	if _, err := enum.ValidateString(query.Window, leaderboardWindows); err != nil {
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewBadRequest())
+		return
+	}
+	if _, err := enum.ValidateString(query.Scope, leaderboardScopes); err != nil {
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewBadRequest())
+		return
+	}
+	if query.ScopeID == "" && (query.Scope == internal.CourseScope || query.Scope == internal.LanguageScope || query.Scope == internal.ClassroomScope) {
+		writeMessageResponse(w, http.StatusBadRequest, "provide "+query.Scope+" id")
+		return
+	}
+	if query.Page, err = formInt(r, "page"); err != nil {
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewBadRequest())
+		return
+	}
+	if query.Limit, err = formInt(r, "limit"); err != nil || query.Limit > maxLeaderboardLimit {
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewBadRequest())
+		return
+	}
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultLeaderboardLimit
+	}
+
+	board, err := srv.leaderboard.Board(query)
+	switch errors.Cause(err) {
+	case nil:
+	case service.ErrScopeNotSupported:
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewNotSupportedError(pretty.Leaderboard, query.Scope+" scope"))
+		return
+	default:
+		srv.logger.Errorln(errors.Wrap(err, "while getting leaderboard"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorGet(pretty.Leaderboard))
+		return
+	}
+
+	users, err := srv.db.User.GetManyByID(srv.converter.LeaderboardConverter.FetchUserIDs(board))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrap(err, "while getting leaderboard users"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorGet(pretty.Users))
+		return
+	}
+
+	writeResponseJson(w, http.StatusOK, srv.converter.LeaderboardConverter.ToDTO(board, users))
+}
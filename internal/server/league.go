@@ -0,0 +1,65 @@
+package server
+
+import (
+	"github.com/best-project/api/internal/server/pretty"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+func (srv *Server) getLeague(w http.ResponseWriter, r *http.Request) {
+	token, err := ParseJWT(r.Header.Get("Authorization"))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while parsing jwt token"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
+		return
+	}
+	user, err := srv.db.User.GetByID(token.ID)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while getting user %d", token.ID))
+		writeMessageResponse(w, http.StatusNotFound, pretty.NewNotFoundError(pretty.User))
+		return
+	}
+
+	league, members, err := srv.leagues.Current(user)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while getting league of user %d", user.ID))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorGet(pretty.League))
+		return
+	}
+	users, err := srv.db.User.GetManyByID(srv.converter.LeagueConverter.FetchUserIDs(members))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrap(err, "while getting league members"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorGet(pretty.Users))
+		return
+	}
+
+	dto := srv.converter.LeagueConverter.ToDTO(*league, members, users)
+	settings := srv.leagues.Settings()
+	dto.Promote, dto.Relegate = settings.Promote, settings.Relegate
+	if league.Tier == settings.Tiers-1 {
+		dto.Promote = 0
+	}
+	if league.Tier == 0 {
+		dto.Relegate = 0
+	}
+
+	writeResponseJson(w, http.StatusOK, dto)
+}
+
+func (srv *Server) getLeagueHistory(w http.ResponseWriter, r *http.Request) {
+	token, err := ParseJWT(r.Header.Get("Authorization"))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while parsing jwt token"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
+		return
+	}
+
+	members, leagues, err := srv.leagues.History(token.ID)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while listing league history of user %d", token.ID))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorList(pretty.League))
+		return
+	}
+
+	writeResponseJson(w, http.StatusOK, srv.converter.LeagueConverter.ManyHistoryToDTO(members, leagues))
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/live"
+	"github.com/best-project/api/internal/server/pretty"
+	"github.com/go-playground/validator"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxLiveCommandSize = 4096
+
+// the API is open to every origin, players are authenticated by the token instead
+var liveUpgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+func (srv *Server) createLiveRoom(w http.ResponseWriter, r *http.Request) {
+	user, err := ParseJWT(r.Header.Get("Authorization"))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while parsing jwt token"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
+		return
+	}
+	createDTO := &internal.LiveRoomCreateDTO{}
+	if err := json.NewDecoder(r.Body).Decode(createDTO); err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while decoding json body"))
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewDecodeError(pretty.LiveRoom))
+		return
+	}
+	if err := srv.validator.Struct(createDTO); err != nil {
+		e := err.(validator.ValidationErrors)
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewErrorValidate(pretty.LiveRoom, e))
+		return
+	}
+	course, err := srv.db.Course.GetByID(createDTO.CourseID)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while getting course %s", createDTO.CourseID))
+		writeMessageResponse(w, http.StatusNotFound, pretty.NewNotFoundError(pretty.Course))
+		return
+	}
+	if !srv.courseAccessible(w, user.ID, course) {
+		return
+	}
+	if course.Private {
+		// players join without an account so they only get signed images
+		now := time.Now()
+		for i := range course.Task {
+			course.Task[i].Image = srv.signMediaURL(course.Task[i].Image, now)
+		}
+	}
+
+	questions := live.NewQuestions(course.Task, createDTO.QuestionCount, rand.New(rand.NewSource(time.Now().UnixNano())))
+	room, err := srv.live.Open(user.ID, course.ID, questions)
+	switch errors.Cause(err) {
+	case nil:
+	case live.ErrNoQuestions:
+		writeErrorResponse(w, http.StatusBadRequest, err)
+		return
+	case live.ErrHubClosed:
+		writeErrorResponse(w, http.StatusServiceUnavailable, err)
+		return
+	default:
+		srv.logger.Errorln(errors.Wrap(err, "while opening live room"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorSave(pretty.LiveRoom))
+		return
+	}
+
+	writeResponseJson(w, http.StatusCreated, internal.LiveRoomDTO{
+		PIN:       room.PIN,
+		CourseID:  strconv.Itoa(int(course.ID)),
+		Questions: len(questions),
+	})
+}
+
+// joinLiveRoom upgrades to a WebSocket, browsers cannot set headers
+// on the handshake so the token can be passed in the query as well
+func (srv *Server) joinLiveRoom(w http.ResponseWriter, r *http.Request) {
+	token := r.Header.Get("Authorization")
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+	claim, err := ParseJWT(token)
+	if err != nil {
+		writeErrorResponse(w, http.StatusForbidden, errors.Wrap(err, "while verifying auth token"))
+		return
+	}
+	room, err := srv.live.Get(mux.Vars(r)["pin"])
+	if err != nil {
+		writeMessageResponse(w, http.StatusNotFound, pretty.NewNotFoundError(pretty.LiveRoom))
+		return
+	}
+	user, err := srv.db.User.GetByID(claim.ID)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while getting user %d", claim.ID))
+		writeMessageResponse(w, http.StatusNotFound, pretty.NewNotFoundError(pretty.User))
+		return
+	}
+
+	conn, err := liveUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrap(err, "while upgrading to websocket"))
+		return
+	}
+	conn.SetReadLimit(maxLiveCommandSize)
+
+	if err := room.Serve(conn, user.ID, playerName(user)); err != nil {
+		conn.WriteJSON(live.Message{Type: live.ErrorMessage, Error: err.Error()})
+		conn.Close()
+	}
+}
+
+func playerName(user *internal.User) string {
+	name := strings.TrimSpace(fmt.Sprintf("%s %s", user.FirstName, user.LastName))
+	if name == "" {
+		return fmt.Sprintf("Player %d", user.ID)
+	}
+	return name
+}
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/server/pretty"
+	"github.com/best-project/api/internal/service"
+	"github.com/pkg/errors"
+	"net/http"
+	"strconv"
+)
+
+const defaultNotificationLimit = 20
+
+func (srv *Server) getNotifications(w http.ResponseWriter, r *http.Request) {
+	user, err := ParseJWT(r.Header.Get("Authorization"))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while parsing jwt token"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
+		return
+	}
+	page, err := formInt(r, "page")
+	if err != nil {
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewBadRequest())
+		return
+	}
+	limit, err := formInt(r, "limit")
+	if err != nil || limit > maxLeaderboardLimit {
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewBadRequest())
+		return
+	}
+	unreadOnly := false
+	if value := r.FormValue("unread"); value != "" {
+		if unreadOnly, err = strconv.ParseBool(value); err != nil {
+			writeMessageResponse(w, http.StatusBadRequest, pretty.NewBadRequest())
+			return
+		}
+	}
+	if page == 0 {
+		page = 1
+	}
+	if limit == 0 {
+		limit = defaultNotificationLimit
+	}
+
+	notifications, unread, err := srv.notifications.List(user.ID, unreadOnly, page, limit)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while listing notifications of user %d", user.ID))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorList(pretty.Notifications))
+		return
+	}
+	actors, err := srv.db.User.GetManyByID(srv.converter.NotificationConverter.FetchActorIDs(notifications))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrap(err, "while getting notification actors"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorGet(pretty.Notifications))
+		return
+	}
+	courses, err := srv.existingCourses(srv.converter.NotificationConverter.FetchCourseIDs(notifications))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrap(err, "while getting notification courses"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorGet(pretty.Notifications))
+		return
+	}
+
+	writeResponseJson(w, http.StatusOK, internal.NotificationListDTO{
+		Unread:        unread,
+		Page:          page,
+		Notifications: srv.converter.NotificationConverter.ManyToDTO(notifications, actors, courses),
+	})
+}
+
+func (srv *Server) markNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	user, err := ParseJWT(r.Header.Get("Authorization"))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while parsing jwt token"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
+		return
+	}
+	readDTO := &internal.NotificationReadDTO{}
+	if err := json.NewDecoder(r.Body).Decode(readDTO); err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while decoding json body"))
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewDecodeError(pretty.Notifications))
+		return
+	}
+	if !readDTO.All && len(readDTO.IDs) == 0 {
+		writeMessageResponse(w, http.StatusBadRequest, "provide Notification ids or all")
+		return
+	}
+
+	if readDTO.All {
+		err = srv.notifications.MarkAllRead(user.ID)
+	} else {
+		err = srv.notifications.MarkRead(user.ID, readDTO.IDs)
+	}
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while marking notifications of user %d as read", user.ID))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorUpdate(pretty.Notifications))
+		return
+	}
+
+	writeMessageResponse(w, http.StatusOK, pretty.NewUpdateMessage(pretty.Notifications))
+}
+
+func (srv *Server) getNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	user, err := ParseJWT(r.Header.Get("Authorization"))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while parsing jwt token"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
+		return
+	}
+
+	preferences, err := srv.notifications.Preferences(user.ID)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while getting notification preferences of user %d", user.ID))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorGet(pretty.NotificationPreferences))
+		return
+	}
+
+	writeResponseJson(w, http.StatusOK, preferences)
+}
+
+func (srv *Server) updateNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	user, err := ParseJWT(r.Header.Get("Authorization"))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while parsing jwt token"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
+		return
+	}
+	changes := make(map[string]bool)
+	if err := json.NewDecoder(r.Body).Decode(&changes); err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while decoding json body"))
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewDecodeError(pretty.NotificationPreferences))
+		return
+	}
+
+	preferences, err := srv.notifications.SetPreferences(user.ID, changes)
+	switch errors.Cause(err) {
+	case nil:
+	case service.ErrUnknownNotificationType:
+		writeErrorResponse(w, http.StatusBadRequest, err)
+		return
+	default:
+		srv.logger.Errorln(errors.Wrapf(err, "while saving notification preferences of user %d", user.ID))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorSave(pretty.NotificationPreferences))
+		return
+	}
+
+	writeResponseJson(w, http.StatusOK, preferences)
+}
@@ -3,6 +3,7 @@ package pretty
 import (
 	"fmt"
 	"github.com/go-playground/validator"
+	"strings"
 )
 
 type Kind int
@@ -16,6 +17,22 @@ const (
 	Users
 	Task
 	Tasks
+	Mistakes
+	Answer
+	Puzzle
+	Exam
+	Certificate
+	League
+	Leaderboard
+	Feed
+	Challenge
+	Classroom
+	Assignment
+	LiveRoom
+	Notifications
+	NotificationPreferences
+	Image
+	Audio
 )
 
 func (k Kind) String() string {
@@ -36,6 +53,38 @@ func (k Kind) String() string {
 		return "Task"
 	case Tasks:
 		return "Tasks"
+	case Mistakes:
+		return "Mistakes"
+	case Answer:
+		return "Answer"
+	case Puzzle:
+		return "Puzzle"
+	case Exam:
+		return "Exam"
+	case Certificate:
+		return "Certificate"
+	case League:
+		return "League"
+	case Leaderboard:
+		return "Leaderboard"
+	case Feed:
+		return "Feed"
+	case Challenge:
+		return "Challenge"
+	case Classroom:
+		return "Classroom"
+	case Assignment:
+		return "Assignment"
+	case LiveRoom:
+		return "Live room"
+	case Notifications:
+		return "Notifications"
+	case NotificationPreferences:
+		return "Notification preferences"
+	case Image:
+		return "Image"
+	case Audio:
+		return "Audio"
 	default:
 		return ""
 	}
@@ -67,6 +116,30 @@ func NewForbiddenError(k Kind) string {
 	return fmt.Sprintf("Operation on %s forbidden", k)
 }
 
+func NewNotSupportedError(k Kind, feature string) string {
+	return fmt.Sprintf("%s does not support %s", k, feature)
+}
+
+func NewTooLargeError(k Kind, limit string) string {
+	return fmt.Sprintf("%s exceeds the limit of %s", k, limit)
+}
+
+func NewUnsupportedFormatError(k Kind, allowed []string) string {
+	return fmt.Sprintf("%s format is not supported, allowed formats: %s", k, strings.Join(allowed, ", "))
+}
+
+func NewMalformedError(k Kind) string {
+	return fmt.Sprintf("%s is malformed and cannot be read", k)
+}
+
+func NewBlockedURLError(k Kind) string {
+	return fmt.Sprintf("%s url is not allowed", k)
+}
+
+func NewFetchError(k Kind) string {
+	return fmt.Sprintf("%s cannot be fetched from the given url", k)
+}
+
 func NewBadRequest() string {
 	return "Bad request"
 }
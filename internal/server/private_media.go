@@ -0,0 +1,106 @@
+package server
+
+import (
+	"fmt"
+	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/media"
+	"github.com/best-project/api/internal/server/pretty"
+	"github.com/pkg/errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// canViewCourse lets everyone see public courses, private ones only the author
+// and members of classrooms the author assigned the course in
+func (srv *Server) canViewCourse(userID uint, course *internal.Course) (bool, error) {
+	if !course.Private || course.UserID == userID {
+		return true, nil
+	}
+	return srv.classrooms.HasCourse(userID, course)
+}
+
+// courseAccessible writes the error response when the user cannot see the course
+func (srv *Server) courseAccessible(w http.ResponseWriter, userID uint, course *internal.Course) bool {
+	ok, err := srv.canViewCourse(userID, course)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while checking access to course %d", course.ID))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorGet(pretty.Course))
+		return false
+	}
+	if !ok {
+		writeMessageResponse(w, http.StatusForbidden, pretty.NewForbiddenError(pretty.Course))
+		return false
+	}
+	return true
+}
+
+// visibleCourses drops the private courses the user cannot see and signs the media of the rest
+func (srv *Server) visibleCourses(userID uint, courses []internal.CourseDTO) ([]internal.CourseDTO, error) {
+	now := time.Now()
+	visible := make([]internal.CourseDTO, 0, len(courses))
+	for _, course := range courses {
+		if !course.Private {
+			visible = append(visible, course)
+			continue
+		}
+		courseID, err := strconv.Atoi(course.CourseID)
+		if err != nil {
+			return nil, err
+		}
+		ok, err := srv.canViewCourse(userID, &internal.Course{ID: uint(courseID), UserID: course.UserID, Private: true})
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		course.Image = srv.signMediaURL(course.Image, now)
+		srv.signTaskMedia(course.Data, now)
+		visible = append(visible, course)
+	}
+	return visible, nil
+}
+
+func (srv *Server) signTaskMedia(tasks []internal.TaskDTO, now time.Time) {
+	for i := range tasks {
+		tasks[i].Image = srv.signMediaURL(tasks[i].Image, now)
+		tasks[i].Audio = srv.signMediaURL(tasks[i].Audio, now)
+	}
+}
+
+// signMediaURL signs urls served by getImage, links to other hosts are left as they are
+func (srv *Server) signMediaURL(raw string, now time.Time) string {
+	if raw == "" || !strings.HasPrefix(raw, srv.blobs.URL("")) {
+		return raw
+	}
+	return srv.signer.Sign(raw, now)
+}
+
+// mediaCacheControl lets through public media and private media with a valid signature,
+// it writes the error response and returns false otherwise
+func (srv *Server) mediaCacheControl(w http.ResponseWriter, r *http.Request, name string) (string, bool) {
+	query := r.URL.Query()
+	if signature := query.Get(media.SignatureParam); signature != "" {
+		expires, err := srv.signer.Verify(name, query.Get(media.ExpiresParam), signature, time.Now())
+		if err != nil {
+			writeMessageResponse(w, http.StatusForbidden, pretty.NewForbiddenError(pretty.Image))
+			return "", false
+		}
+		return fmt.Sprintf("private, max-age=%d", int(time.Until(expires).Seconds())), true
+	}
+
+	private, public, err := srv.db.Image.CountVisibility(srv.blobs.URL(name))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while checking visibility of image %s", name))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorGet(pretty.Image))
+		return "", false
+	}
+	// the same content may be uploaded to a public course too, it is public then
+	if private > 0 && public == 0 {
+		writeMessageResponse(w, http.StatusForbidden, pretty.NewForbiddenError(pretty.Image))
+		return "", false
+	}
+	return blobCacheControl, true
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/puzzle"
+	"github.com/best-project/api/internal/server/pretty"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func (srv *Server) getPuzzleCourse(w http.ResponseWriter, r *http.Request) (*internal.Course, bool) {
+	user, err := ParseJWT(r.Header.Get("Authorization"))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while parsing jwt token"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
+		return nil, false
+	}
+	id := mux.Vars(r)["id"]
+	course, err := srv.db.Course.GetByID(id)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while getting from storage course ID: %s", id))
+		writeMessageResponse(w, http.StatusNotFound, pretty.NewNotFoundError(pretty.Course))
+		return nil, false
+	}
+	if course.Type != internal.PuzzleType {
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewNotSupportedError(pretty.Course, "puzzles"))
+		return nil, false
+	}
+	if !srv.courseAccessible(w, user.ID, course) {
+		return nil, false
+	}
+
+	return course, true
+}
+
+func (srv *Server) getPuzzle(w http.ResponseWriter, r *http.Request) {
+	course, ok := srv.getPuzzleCourse(w, r)
+	if !ok {
+		return
+	}
+
+	seed := time.Now().UnixNano()
+	if value := r.URL.Query().Get("seed"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			srv.logger.Errorln(errors.Wrapf(err, "while parsing seed %s", value))
+			writeMessageResponse(w, http.StatusBadRequest, pretty.NewBadRequest())
+			return
+		}
+		seed = parsed
+	}
+
+	writeResponseJson(w, http.StatusOK, puzzle.Generate(course.Task, seed))
+}
+
+// checkPuzzle validates answers by generating the puzzle again from the seed
+func (srv *Server) checkPuzzle(w http.ResponseWriter, r *http.Request) {
+	course, ok := srv.getPuzzleCourse(w, r)
+	if !ok {
+		return
+	}
+	solution := &internal.PuzzleSolutionDTO{}
+	if err := json.NewDecoder(r.Body).Decode(solution); err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while decoding json body"))
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewDecodeError(pretty.Puzzle))
+		return
+	}
+
+	p := puzzle.Generate(course.Task, solution.Seed)
+	result := internal.PuzzleResultDTO{
+		CourseID:   strconv.Itoa(int(course.ID)),
+		Seed:       solution.Seed,
+		Anagrams:   solvedTasks(solution.Anagrams, p.CheckAnagram),
+		Crossword:  solvedTasks(solution.Crossword, p.CheckCrossword),
+		WordSearch: p.CheckWordSearch(solution.WordSearch),
+		MaxPoints:  (len(p.Anagrams) + len(p.Crossword.Entries) + len(p.WordSearch.Words)) * srv.xp.ForTask(internal.PuzzleType),
+	}
+	result.Points = uint((len(result.Anagrams) + len(result.Crossword) + len(result.WordSearch)) * srv.xp.ForTask(internal.PuzzleType))
+
+	writeResponseJson(w, http.StatusOK, result)
+}
+
+// solvedTasks returns IDs of correctly answered tasks, a task answered more than once counts once
+func solvedTasks(answers []internal.AnswerDTO, check func(taskID uint, answer string) bool) []string {
+	solved := make([]string, 0)
+	seen := make(map[uint]bool)
+	for _, answer := range answers {
+		taskID, err := strconv.Atoi(answer.TaskID)
+		if err != nil || seen[uint(taskID)] {
+			continue
+		}
+		if check(uint(taskID), answer.Answer) {
+			seen[uint(taskID)] = true
+			solved = append(solved, answer.TaskID)
+		}
+	}
+	return solved
+}
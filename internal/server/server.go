@@ -2,8 +2,10 @@ package server
 
 import (
 	"encoding/json"
-	"fmt"
 	"github.com/best-project/api/internal/converter"
+	"github.com/best-project/api/internal/event"
+	"github.com/best-project/api/internal/live"
+	"github.com/best-project/api/internal/media"
 	"github.com/best-project/api/internal/service"
 	"github.com/best-project/api/internal/storage"
 	"github.com/go-playground/validator"
@@ -21,28 +23,98 @@ type Server struct {
 	fb     facebook.Interface
 	db     *storage.Database
 
-	courseLogic service.CourseResultHandler
-	validator   *validator.Validate
+	courseLogic   service.CourseResultHandler
+	answerLogic   service.AnswerHandler
+	examLogic     service.ExamHandler
+	difficulty    service.DifficultyHandler
+	streaks       service.StreakHandler
+	achievement   service.AchievementHandler
+	leagues       service.LeagueHandler
+	leaderboard   service.LeaderboardHandler
+	social        service.SocialHandler
+	challenges    service.ChallengeHandler
+	classrooms    service.ClassroomHandler
+	live          *live.Hub
+	blobs         storage.BlobStore
+	imageLimits   media.ImageLimits
+	images        *service.ImageLogic
+	imports       *service.ImportLogic
+	audio         *service.AudioLogic
+	audioMaxBytes int64
+	signer        *media.URLSigner
+	notifications service.NotificationHandler
+	events        event.Publisher
+	validator     *validator.Validate
 
-	host      string
-	xpForTask int
+	host string
+	xp   service.XPRules
 
 	converter *converter.Converter
 }
 
-func NewServer(db *storage.Database, fb facebook.Interface, courseLogic *service.CourseLogic, logger *logrus.Logger) *Server {
+// Deps are the dependencies of the server, built once in main
+type Deps struct {
+	DB            *storage.Database
+	Facebook      facebook.Interface
+	Courses       *service.CourseLogic
+	Answers       *service.AnswerLogic
+	Exams         *service.ExamLogic
+	Difficulty    *service.DifficultyLogic
+	Streaks       *service.StreakLogic
+	Achievements  *service.AchievementLogic
+	Leagues       *service.LeagueLogic
+	Leaderboard   *service.LeaderboardLogic
+	Social        *service.SocialLogic
+	Challenges    *service.ChallengeLogic
+	Classrooms    *service.ClassroomLogic
+	Live          *live.Hub
+	Blobs         storage.BlobStore
+	ImageLimits   media.ImageLimits
+	Images        *service.ImageLogic
+	Imports       *service.ImportLogic
+	Audio         *service.AudioLogic
+	AudioMaxBytes int64
+	Signer        *media.URLSigner
+	Notifications *service.NotificationLogic
+	XP            service.XPRules
+	Events        event.Publisher
+	Logger        *logrus.Logger
+}
+
+func NewServer(deps Deps) *Server {
 	return &Server{
-		logger: logger,
-		fb:     fb,
-		db:     db,
+		logger: deps.Logger,
+		fb:     deps.Facebook,
+		db:     deps.DB,
 
-		converter:   converter.NewConverter(),
-		courseLogic: courseLogic,
-		validator:   validator.New(),
+		converter:     converter.NewConverter(),
+		courseLogic:   deps.Courses,
+		answerLogic:   deps.Answers,
+		examLogic:     deps.Exams,
+		difficulty:    deps.Difficulty,
+		streaks:       deps.Streaks,
+		achievement:   deps.Achievements,
+		leagues:       deps.Leagues,
+		leaderboard:   deps.Leaderboard,
+		social:        deps.Social,
+		challenges:    deps.Challenges,
+		classrooms:    deps.Classrooms,
+		live:          deps.Live,
+		blobs:         deps.Blobs,
+		imageLimits:   deps.ImageLimits,
+		images:        deps.Images,
+		imports:       deps.Imports,
+		audio:         deps.Audio,
+		audioMaxBytes: deps.AudioMaxBytes,
+		signer:        deps.Signer,
+		notifications: deps.Notifications,
+		events:        deps.Events,
+		validator:     validator.New(),
 
-		xpForTask: 10,
+		xp: deps.XP,
 	}
 }
+
 func enableCors(w *http.ResponseWriter) {
 	(*w).Header().Set("Access-Control-Allow-Origin", "*")
 	(*w).Header().Set("Access-Control-Allow-Methods", "*")
@@ -58,7 +130,12 @@ func (srv *Server) Handle() http.Handler {
 
 	rtr.Path("/user").Methods(http.MethodGet).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.getUserByToken)))
 	rtr.Path("/user/update").Methods(http.MethodPut).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.updateUser)))
+	rtr.Path("/user/mistakes").Methods(http.MethodGet).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.getMistakes)))
 	rtr.Path("/user/refresh/{token}").Methods(http.MethodGet).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.refreshToken)))
+	rtr.Path("/user/{id}/follow").Methods(http.MethodPost).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.followUser)))
+	rtr.Path("/user/{id}/follow").Methods(http.MethodDelete).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.unfollowUser)))
+	rtr.Path("/user/{id}/followers").Methods(http.MethodGet).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.getFollowers)))
+	rtr.Path("/user/{id}/following").Methods(http.MethodGet).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.getFollowing)))
 	rtr.Path("/user/{id}").Methods(http.MethodGet).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.getUserByID)))
 	rtr.Path("/user/courses/{id}").Methods(http.MethodGet).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.getCoursesByUserID)))
 	rtr.Path("/users/ranking").Methods(http.MethodGet).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.userRanking)))
@@ -70,6 +147,11 @@ func (srv *Server) Handle() http.Handler {
 	rtr.Path("/course/task/edit").Methods(http.MethodPost).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.editTask)))
 	rtr.Path("/course/task/remove/{id}").Methods(http.MethodDelete).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.removeTasksFromCourse)))
 
+	rtr.Path("/course/task/answer").Methods(http.MethodPost).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.checkAnswer)))
+
+	rtr.Path("/course/{id}/difficulty").Methods(http.MethodGet).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.getCourseDifficulty)))
+	rtr.Path("/course/{id}/puzzle").Methods(http.MethodGet).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.getPuzzle)))
+	rtr.Path("/course/{id}/puzzle/check").Methods(http.MethodPost).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.checkPuzzle)))
 	rtr.Path("/course/{id}").Methods(http.MethodGet).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.getCourse)))
 	rtr.Path("/courses").Methods(http.MethodGet).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.getUserCourses)))
 	rtr.Path("/courses/meta").Methods(http.MethodGet).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.getUserCoursesMetadata)))
@@ -83,23 +165,39 @@ func (srv *Server) Handle() http.Handler {
 	rtr.Path("/ranking").Methods(http.MethodGet).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.usersRanking)))
 	rtr.Path("/ranking/{id}").Methods(http.MethodGet).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.courseRanking)))
 
+	rtr.Path("/exam/start").Methods(http.MethodPost).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.startExam)))
+	rtr.Path("/exam/finish").Methods(http.MethodPost).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.finishExam)))
+	rtr.Path("/certificates/{code}").Methods(http.MethodGet).Handler(negroni.New(negroni.WrapFunc(srv.verifyCertificate)))
+	rtr.Path("/certificates/{code}/pdf").Methods(http.MethodGet).Handler(negroni.New(negroni.WrapFunc(srv.getCertificatePDF)))
+
+	rtr.Path("/challenge").Methods(http.MethodPost).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.createChallenge)))
+	rtr.Path("/challenge/{id}").Methods(http.MethodGet).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.getChallenge)))
+	rtr.Path("/challenge/{id}/finish").Methods(http.MethodPost).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.finishChallenge)))
+	rtr.Path("/challenges").Methods(http.MethodGet).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.listChallenges)))
+	rtr.Path("/classroom").Methods(http.MethodPost).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.createClassroom)))
+	rtr.Path("/classroom/join").Methods(http.MethodPost).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.joinClassroom)))
+	rtr.Path("/classroom/{id}").Methods(http.MethodGet).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.getClassroom)))
+	rtr.Path("/classroom/{id}/assignment").Methods(http.MethodPost).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.createAssignment)))
+	rtr.Path("/classroom/{id}/dashboard").Methods(http.MethodGet).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.getClassroomDashboard)))
+	rtr.Path("/classrooms").Methods(http.MethodGet).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.listClassrooms)))
+	rtr.Path("/live").Methods(http.MethodPost).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.createLiveRoom)))
+	rtr.Path("/live/{pin}").Methods(http.MethodGet).HandlerFunc(srv.joinLiveRoom)
+	rtr.Path("/feed").Methods(http.MethodGet).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.getFeed)))
+	rtr.Path("/leaderboard").Methods(http.MethodGet).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.getLeaderboard)))
+	rtr.Path("/league").Methods(http.MethodGet).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.getLeague)))
+	rtr.Path("/league/history").Methods(http.MethodGet).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.getLeagueHistory)))
+	rtr.Path("/notifications").Methods(http.MethodGet).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.getNotifications)))
+	rtr.Path("/notifications/read").Methods(http.MethodPost).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.markNotificationsRead)))
+	rtr.Path("/notifications/preferences").Methods(http.MethodGet).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.getNotificationPreferences)))
+	rtr.Path("/notifications/preferences").Methods(http.MethodPut).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.updateNotificationPreferences)))
+	rtr.Path("/achievements").Methods(http.MethodGet).Handler(negroni.New(tokenCheckMiddleware, negroni.WrapFunc(srv.listAchievements)))
+
 	rtr.Path("/images/{name}").Methods(http.MethodGet).Handler(negroni.New(negroni.WrapFunc(srv.getImage)))
 	rtr.Path("/status").Methods(http.MethodGet).Handler(negroni.New(negroni.WrapFunc(srv.statusHandler)))
 
 	return rtr
 }
 
-func (srv *Server) getImage(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	filename := vars["name"]
-
-	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%s", filename))
-	w.Header().Set("Content-Type", "image/png")
-	w.Header().Set("Content-Length", r.Header.Get("Content-Length"))
-
-	http.ServeFile(w, r, fmt.Sprintf("images/%s", filename))
-}
-
 func (srv *Server) statusHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/server/pretty"
+	"github.com/best-project/api/internal/service"
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	"net/http"
+	"strconv"
+)
+
+const defaultFeedLimit = 20
+
+func (srv *Server) followUser(w http.ResponseWriter, r *http.Request) {
+	srv.changeFollow(w, r, srv.social.Follow)
+}
+
+func (srv *Server) unfollowUser(w http.ResponseWriter, r *http.Request) {
+	srv.changeFollow(w, r, srv.social.Unfollow)
+}
+
+func (srv *Server) changeFollow(w http.ResponseWriter, r *http.Request, change func(followerID, followeeID uint) error) {
+	token, err := ParseJWT(r.Header.Get("Authorization"))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while parsing jwt token"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
+		return
+	}
+	user, ok := srv.userFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	err = change(token.ID, user.ID)
+	switch errors.Cause(err) {
+	case nil:
+	case service.ErrSelfFollow:
+		writeErrorResponse(w, http.StatusBadRequest, err)
+		return
+	default:
+		srv.logger.Errorln(errors.Wrapf(err, "while changing follow of user %d", user.ID))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorUpdate(pretty.User))
+		return
+	}
+
+	writeMessageResponse(w, http.StatusOK, pretty.NewUpdateMessage(pretty.User))
+}
+
+func (srv *Server) getFollowers(w http.ResponseWriter, r *http.Request) {
+	srv.listFollows(w, r, srv.social.Followers)
+}
+
+func (srv *Server) getFollowing(w http.ResponseWriter, r *http.Request) {
+	srv.listFollows(w, r, srv.social.Following)
+}
+
+func (srv *Server) listFollows(w http.ResponseWriter, r *http.Request, list func(userID uint) ([]uint, error)) {
+	token, err := ParseJWT(r.Header.Get("Authorization"))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while parsing jwt token"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
+		return
+	}
+	user, ok := srv.userFromPath(w, r)
+	if !ok {
+		return
+	}
+	if user.HideFollows && user.ID != token.ID {
+		writeMessageResponse(w, http.StatusForbidden, pretty.NewForbiddenError(pretty.Users))
+		return
+	}
+
+	ids, err := list(user.ID)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while listing follows of user %d", user.ID))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorList(pretty.Users))
+		return
+	}
+	users, err := srv.db.User.GetManyByID(ids)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrap(err, "while getting users"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorList(pretty.Users))
+		return
+	}
+
+	writeResponseJson(w, http.StatusOK, srv.converter.ManyToUserStat(users))
+}
+
+func (srv *Server) getFeed(w http.ResponseWriter, r *http.Request) {
+	token, err := ParseJWT(r.Header.Get("Authorization"))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while parsing jwt token"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewInternalError())
+		return
+	}
+	page, err := formInt(r, "page")
+	if err != nil {
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewBadRequest())
+		return
+	}
+	limit, err := formInt(r, "limit")
+	if err != nil || limit > maxLeaderboardLimit {
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewBadRequest())
+		return
+	}
+	if page == 0 {
+		page = 1
+	}
+	if limit == 0 {
+		limit = defaultFeedLimit
+	}
+
+	items, err := srv.social.Feed(token.ID, page, limit)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while getting feed of user %d", token.ID))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorGet(pretty.Feed))
+		return
+	}
+	users, err := srv.db.User.GetManyByID(srv.converter.FeedConverter.FetchUserIDs(items))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrap(err, "while getting feed users"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorGet(pretty.Feed))
+		return
+	}
+	courses, err := srv.existingCourses(srv.converter.FeedConverter.FetchCourseIDs(items))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrap(err, "while getting feed courses"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorGet(pretty.Feed))
+		return
+	}
+
+	writeResponseJson(w, http.StatusOK, srv.converter.FeedConverter.ManyToDTO(items, users, courses))
+}
+
+// userFromPath gets the user identified by the id path variable, writing the error response when it fails
+func (srv *Server) userFromPath(w http.ResponseWriter, r *http.Request) (*internal.User, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeMessageResponse(w, http.StatusBadRequest, "provide User id")
+		return nil, false
+	}
+	user, err := srv.db.User.GetByID(uint(id))
+	if err != nil {
+		srv.logger.Errorln(errors.Wrapf(err, "while getting user %d", id))
+		writeMessageResponse(w, http.StatusNotFound, pretty.NewNotFoundError(pretty.User))
+		return nil, false
+	}
+
+	return user, true
+}
+
+// existingCourses gets courses by id skipping removed ones
+func (srv *Server) existingCourses(ids []string) ([]*internal.Course, error) {
+	courses := make([]*internal.Course, 0)
+	for _, id := range ids {
+		course, err := srv.db.Course.GetByID(id)
+		if gorm.IsRecordNotFoundError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		courses = append(courses, course)
+	}
+
+	return courses, nil
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"encoding/json"
 	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/media"
 	"github.com/best-project/api/internal/server/pretty"
 	"github.com/go-playground/validator"
 	"github.com/gorilla/mux"
@@ -45,6 +46,19 @@ func (srv *Server) getUserByToken(w http.ResponseWriter, r *http.Request) {
 		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorConvert(pretty.User))
 		return
 	}
+	result.DailyXP, result.CurrentStreak, err = srv.streaks.Status(user)
+	if err != nil {
+		srv.logger.Errorln(errors.Wrap(err, "while getting streak status"))
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorGet(pretty.User))
+		return
+	}
+	result.DailyGoal = srv.streaks.Goal(user)
+	result.Achievements, err = srv.userAchievements(user.ID)
+	if err != nil {
+		srv.logger.Errorln(err)
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorGet(pretty.User))
+		return
+	}
 
 	writeResponseJson(w, http.StatusOK, result)
 }
@@ -73,6 +87,12 @@ func (srv *Server) getUserByID(w http.ResponseWriter, r *http.Request) {
 		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorConvert(pretty.User))
 		return
 	}
+	result.Achievements, err = srv.userAchievements(user.ID)
+	if err != nil {
+		srv.logger.Errorln(err)
+		writeMessageResponse(w, http.StatusInternalServerError, pretty.NewErrorGet(pretty.User))
+		return
+	}
 
 	writeResponseJson(w, http.StatusOK, result)
 }
@@ -216,7 +236,10 @@ func (srv *Server) createUser(w http.ResponseWriter, r *http.Request) {
 }
 
 func (srv *Server) getUserDataFromForm(r *http.Request) (*internal.UserDTO, error) {
-	imgPath, _ := srv.saveImage(r)
+	imgPath, err := srv.saveImage(r)
+	if media.Rejected(err) {
+		return nil, err
+	}
 
 	userDTO := &internal.UserDTO{
 		Email:     r.FormValue("email"),
@@ -230,6 +253,10 @@ func (srv *Server) getUserDataFromForm(r *http.Request) (*internal.UserDTO, erro
 
 func (srv *Server) updateUser(w http.ResponseWriter, r *http.Request) {
 	userData, err := srv.getUserDataFromForm(r)
+	if media.Rejected(err) {
+		srv.writeImageError(w, err)
+		return
+	}
 	if err != nil {
 		writeMessageResponse(w, http.StatusBadRequest, pretty.NewDecodeError(pretty.User))
 		return
@@ -265,6 +292,36 @@ func (srv *Server) updateUser(w http.ResponseWriter, r *http.Request) {
 	if user.Avatar != "" {
 		user.Avatar = userData.Avatar
 	}
+	if timeZone := r.FormValue("timeZone"); timeZone != "" {
+		if _, err := time.LoadLocation(timeZone); err != nil {
+			writeMessageResponse(w, http.StatusBadRequest, pretty.NewBadRequest())
+			return
+		}
+		user.TimeZone = timeZone
+	}
+	if dailyGoal, err := formInt(r, "dailyGoal"); err != nil {
+		writeMessageResponse(w, http.StatusBadRequest, pretty.NewBadRequest())
+		return
+	} else if dailyGoal > 0 {
+		user.DailyGoal = dailyGoal
+	}
+	for key, setting := range map[string]*bool{
+		"hideResults":  &user.HideResults,
+		"hideLevelUps": &user.HideLevelUps,
+		"hideBadges":   &user.HideBadges,
+		"hideCourses":  &user.HideCourses,
+		"hideFollows":  &user.HideFollows,
+	} {
+		if !formHas(r, key) {
+			continue
+		}
+		hide, err := strconv.ParseBool(r.FormValue(key))
+		if err != nil {
+			writeMessageResponse(w, http.StatusBadRequest, pretty.NewBadRequest())
+			return
+		}
+		*setting = hide
+	}
 
 	if err := srv.db.User.SaveUser(user); err != nil {
 		srv.logger.Errorln(errors.Wrap(err, "while saving user"))
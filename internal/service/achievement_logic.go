@@ -0,0 +1,228 @@
+package service
+
+import (
+	"fmt"
+	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/event"
+	"github.com/best-project/api/internal/storage"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"strconv"
+	"time"
+)
+
+const (
+	FinishedCoursesMetric = "finished_courses"
+	PerfectCoursesMetric  = "perfect_courses"
+	StreakMetric          = "streak"
+	LevelMetric           = "level"
+	CreatedCoursesMetric  = "created_courses"
+	CourseRatingMetric    = "course_rating"
+)
+
+var achievementEvents = map[event.Type]bool{
+	event.ResultSaved:     true,
+	event.CoursePublished: true,
+	event.RatingReceived:  true,
+}
+
+var achievementMetrics = map[string]bool{
+	FinishedCoursesMetric: true,
+	PerfectCoursesMetric:  true,
+	StreakMetric:          true,
+	LevelMetric:           true,
+	CreatedCoursesMetric:  true,
+	CourseRatingMetric:    true,
+}
+
+type AchievementLogic struct {
+	db     *storage.Database
+	rules  []internal.AchievementRule
+	xp     XPAwarder
+	events event.Publisher
+}
+
+type AchievementHandler interface {
+	Rules() []internal.AchievementRule
+	ListForUser(userID uint) ([]internal.UserAchievement, error)
+}
+
+func NewAchievementLogic(db *storage.Database, rules []internal.AchievementRule, xp XPAwarder, events event.Publisher) *AchievementLogic {
+	return &AchievementLogic{
+		db:     db,
+		rules:  rules,
+		xp:     xp,
+		events: events,
+	}
+}
+
+// LoadAchievementRules reads and validates achievement rules from a YAML file
+func LoadAchievementRules(path string) ([]internal.AchievementRule, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "while reading achievements file %s", path)
+	}
+	rules := make([]internal.AchievementRule, 0)
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, errors.Wrapf(err, "while decoding achievements file %s", path)
+	}
+
+	ids := make(map[string]bool)
+	for _, rule := range rules {
+		if rule.ID == "" || ids[rule.ID] {
+			return nil, fmt.Errorf("achievement id %q is empty or not unique", rule.ID)
+		}
+		ids[rule.ID] = true
+		if !achievementEvents[event.Type(rule.Event)] {
+			return nil, fmt.Errorf("achievement %s: unknown event %s", rule.ID, rule.Event)
+		}
+		if !achievementMetrics[rule.Metric] {
+			return nil, fmt.Errorf("achievement %s: unknown metric %s", rule.ID, rule.Metric)
+		}
+	}
+
+	return rules, nil
+}
+
+// Subscribe evaluates the rules on the events they are defined for
+func (a *AchievementLogic) Subscribe(bus *event.Bus) {
+	subscribed := make(map[event.Type]bool)
+	for _, rule := range a.rules {
+		t := event.Type(rule.Event)
+		if subscribed[t] {
+			continue
+		}
+		subscribed[t] = true
+		bus.Subscribe(t, a.handle)
+	}
+}
+
+func (a *AchievementLogic) Rules() []internal.AchievementRule {
+	return a.rules
+}
+
+func (a *AchievementLogic) ListForUser(userID uint) ([]internal.UserAchievement, error) {
+	return a.db.Achievement.ListForUser(userID)
+}
+
+func (a *AchievementLogic) handle(e event.Event) error {
+	unlocked, err := a.db.Achievement.ListForUser(e.UserID)
+	if err != nil {
+		return errors.Wrap(err, "while listing achievements")
+	}
+	has := make(map[string]bool)
+	for _, achievement := range unlocked {
+		has[achievement.AchievementID] = true
+	}
+
+	var user *internal.User
+	for _, rule := range a.rules {
+		if rule.Event != string(e.Type) || has[rule.ID] {
+			continue
+		}
+		if user == nil {
+			if user, err = a.db.User.GetByID(e.UserID); err != nil {
+				return errors.Wrapf(err, "while getting user %d", e.UserID)
+			}
+		}
+		value, err := a.metric(rule, e, user)
+		if err != nil {
+			return errors.Wrapf(err, "while computing %s of user %d", rule.Metric, e.UserID)
+		}
+		if !reached(rule, value) {
+			continue
+		}
+		if err := a.unlock(rule, user); err != nil {
+			return errors.Wrapf(err, "while unlocking achievement %s", rule.ID)
+		}
+	}
+
+	return nil
+}
+
+// reached tells if the metric value unlocks the badge, counts reach the threshold while
+// a course has to be rated above it
+func reached(rule internal.AchievementRule, value float32) bool {
+	if rule.Metric == CourseRatingMetric {
+		return value > rule.Threshold
+	}
+	return value >= rule.Threshold
+}
+
+// unlock awards the bonus points only to the event which unlocked the badge first
+func (a *AchievementLogic) unlock(rule internal.AchievementRule, user *internal.User) error {
+	unlocked, err := a.db.Achievement.Unlock(&internal.UserAchievement{
+		UserID:        user.ID,
+		AchievementID: rule.ID,
+		UnlockedAt:    time.Now(),
+	})
+	if err != nil || !unlocked {
+		return err
+	}
+	if rule.XP > 0 {
+		if err := a.xp.AwardXP(user, rule.XP); err != nil {
+			return errors.Wrap(err, "while awarding bonus points")
+		}
+	}
+	a.events.Publish(event.Event{Type: event.BadgeEarned, UserID: user.ID, Name: rule.ID, Points: uint(rule.XP)})
+
+	return nil
+}
+
+func (a *AchievementLogic) metric(rule internal.AchievementRule, e event.Event, user *internal.User) (float32, error) {
+	switch rule.Metric {
+	case StreakMetric:
+		return float32(user.CurrentStreak), nil
+	case LevelMetric:
+		return float32(user.Level), nil
+	case CreatedCoursesMetric:
+		courses, err := a.db.Course.GetByUserID(user.ID)
+		return float32(len(courses)), err
+	case CourseRatingMetric:
+		course, err := a.db.Course.GetByID(strconv.Itoa(int(e.CourseID)))
+		if err != nil {
+			return 0, err
+		}
+		if course.UserID != user.ID || course.RateCounter < rule.MinRatings {
+			return 0, nil
+		}
+		return course.Rate, nil
+	case FinishedCoursesMetric, PerfectCoursesMetric:
+		results, err := a.db.CourseResult.ListBestResultsForUser(user.ID)
+		if err != nil {
+			return 0, err
+		}
+		best := make(map[uint]uint)
+		for _, result := range results {
+			if result.Passed && result.Points >= best[result.CourseID] {
+				best[result.CourseID] = result.Points
+			}
+		}
+		if rule.Metric == FinishedCoursesMetric {
+			return float32(len(best)), nil
+		}
+		return a.perfectCourses(best)
+	default:
+		return 0, fmt.Errorf("unknown metric %s", rule.Metric)
+	}
+}
+
+func (a *AchievementLogic) perfectCourses(best map[uint]uint) (float32, error) {
+	ids := make([]string, 0, len(best))
+	for id := range best {
+		ids = append(ids, strconv.Itoa(int(id)))
+	}
+	courses, err := a.db.Course.GetManyByID(ids)
+	if err != nil {
+		return 0, err
+	}
+
+	perfect := 0
+	for _, course := range courses {
+		if course.MaxPoints > 0 && best[course.ID] >= uint(course.MaxPoints) {
+			perfect++
+		}
+	}
+	return float32(perfect), nil
+}
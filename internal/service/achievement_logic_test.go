@@ -0,0 +1,38 @@
+package service
+
+import (
+	"github.com/best-project/api/internal"
+	"gopkg.in/go-playground/assert.v1"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestLoadAchievementRules(t *testing.T) {
+	rules, err := LoadAchievementRules("../../achievements.yaml")
+
+	assert.Equal(t, err, nil)
+	assert.NotEqual(t, len(rules), 0)
+}
+
+func TestLoadAchievementRulesRejectsUnknownMetric(t *testing.T) {
+	file, err := ioutil.TempFile("", "achievements")
+	assert.Equal(t, err, nil)
+	defer os.Remove(file.Name())
+	file.WriteString("- id: broken\n  event: result_saved\n  metric: unknown\n  threshold: 1\n")
+	file.Close()
+
+	_, err = LoadAchievementRules(file.Name())
+
+	assert.NotEqual(t, err, nil)
+}
+
+func TestReachedThreshold(t *testing.T) {
+	finished := internal.AchievementRule{Metric: FinishedCoursesMetric, Threshold: 10}
+	rated := internal.AchievementRule{Metric: CourseRatingMetric, Threshold: 4.5}
+
+	assert.Equal(t, reached(finished, 10), true)
+	assert.Equal(t, reached(finished, 9), false)
+	assert.Equal(t, reached(rated, 4.5), false)
+	assert.Equal(t, reached(rated, 4.6), true)
+}
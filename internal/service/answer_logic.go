@@ -0,0 +1,116 @@
+package service
+
+import (
+	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/storage"
+	"github.com/pkg/errors"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+type AnswerLogic struct {
+	db            *storage.Database
+	mistakeStreak int
+}
+
+type AnswerHandler interface {
+	CheckAnswer(userID uint, task *internal.Task, direction, answer string, duration time.Duration) (bool, error)
+}
+
+// NewAnswerLogic creates answer logic which removes a word from the user mistakes
+// after mistakeStreak correct answers in a row
+func NewAnswerLogic(db *storage.Database, mistakeStreak int) *AnswerLogic {
+	return &AnswerLogic{
+		db:            db,
+		mistakeStreak: mistakeStreak,
+	}
+}
+
+// CheckAnswer grades an answer given in the direction, records it for the task statistics
+// and updates the user mistakes, which are tracked separately for every direction
+func (a *AnswerLogic) CheckAnswer(userID uint, task *internal.Task, direction, answer string, duration time.Duration) (bool, error) {
+	expected := task.Translate
+	if direction == internal.ReverseDirection {
+		expected = task.Word
+	}
+	correct := IsCorrectAnswer(expected, answer)
+
+	graded := &internal.Answer{
+		UserID:    userID,
+		TaskID:    task.ID,
+		CourseID:  task.CourseID,
+		Direction: direction,
+		Correct:   correct,
+		Duration:  int(duration / time.Millisecond),
+	}
+	if err := a.db.Answer.SaveAnswer(graded); err != nil {
+		return correct, errors.Wrap(err, "while saving answer")
+	}
+
+	mistake, err := a.db.Mistake.FindForTask(userID, task.ID, direction)
+	if err != nil {
+		return correct, errors.Wrap(err, "while getting mistake")
+	}
+	if correct {
+		if mistake == nil {
+			return true, nil
+		}
+		mistake.CorrectStreak++
+		if mistake.CorrectStreak >= a.mistakeStreak {
+			return true, a.db.Mistake.RemoveMistake(mistake)
+		}
+		return true, a.db.Mistake.SaveMistake(mistake)
+	}
+
+	if mistake == nil {
+		mistake = &internal.Mistake{UserID: userID, TaskID: task.ID, CourseID: task.CourseID, Direction: direction}
+	}
+	mistake.Count++
+	mistake.CorrectStreak = 0
+	mistake.LastSeen = time.Now()
+
+	return false, a.db.Mistake.SaveMistake(mistake)
+}
+
+// IsCorrectAnswer checks that every comma separated alternative given in answer
+// is one of the comma separated alternatives of expected
+func IsCorrectAnswer(expected, answer string) bool {
+	alternatives := make(map[string]bool)
+	for _, alternative := range strings.Split(expected, ",") {
+		alternatives[normalizeAnswer(alternative)] = true
+	}
+
+	given := 0
+	for _, part := range strings.Split(answer, ",") {
+		part = normalizeAnswer(part)
+		if part == "" {
+			continue
+		}
+		if !alternatives[part] {
+			return false
+		}
+		given++
+	}
+	return given > 0
+}
+
+// ResolveDirection returns the direction in which a single task is asked
+// during a session with the given direction
+func ResolveDirection(sessionDirection string, rnd *rand.Rand) string {
+	switch sessionDirection {
+	case internal.ReverseDirection:
+		return internal.ReverseDirection
+	case internal.MixedDirection:
+		if rnd.Intn(2) == 0 {
+			return internal.ReverseDirection
+		}
+		return internal.ForwardDirection
+	default:
+		return internal.ForwardDirection
+	}
+}
+
+func normalizeAnswer(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"github.com/best-project/api/internal"
+	"gopkg.in/go-playground/assert.v1"
+	"math/rand"
+	"testing"
+)
+
+func TestIsCorrectAnswer(t *testing.T) {
+	assert.Equal(t, IsCorrectAnswer("zespół, kapela", "kapela"), true)
+	assert.Equal(t, IsCorrectAnswer("zespół, kapela", "  Zespół "), true)
+	assert.Equal(t, IsCorrectAnswer("kamera, aparat  fotograficzny", "aparat fotograficzny"), true)
+	assert.Equal(t, IsCorrectAnswer("zespół, kapela", "kapelan"), false)
+	assert.Equal(t, IsCorrectAnswer("zespół, kapela", ""), false)
+	assert.Equal(t, IsCorrectAnswer("zespół, kapela", "kapela, zespół"), true)
+	assert.Equal(t, IsCorrectAnswer("zespół, kapela", "kapela, kot"), false)
+}
+
+func TestResolveDirection(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	assert.Equal(t, ResolveDirection("", rnd), internal.ForwardDirection)
+	assert.Equal(t, ResolveDirection(internal.ReverseDirection, rnd), internal.ReverseDirection)
+
+	seen := make(map[string]bool)
+	for i := 0; i < 20; i++ {
+		seen[ResolveDirection(internal.MixedDirection, rnd)] = true
+	}
+	assert.Equal(t, len(seen), 2)
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/media"
+	"github.com/best-project/api/internal/storage"
+	"github.com/pkg/errors"
+	"io"
+	"strconv"
+	"time"
+)
+
+var ErrNoSpeechProvider = errors.New("speech provider is not configured")
+
+const (
+	// speechRetryDelay is the wait after the first failed synthesis of a task, it doubles with every next one
+	speechRetryDelay    = time.Hour
+	maxSpeechRetryDelay = 7 * 24 * time.Hour
+)
+
+// AudioLogic keeps the pronunciation clips of tasks, uploaded ones or synthesized from the word
+type AudioLogic struct {
+	db       *storage.Database
+	images   *ImageLogic
+	speech   SpeechProvider
+	maxBytes int64
+}
+
+// SpeechFailure is a task left without audio, it is tried again at RetryAt
+type SpeechFailure struct {
+	TaskID  uint
+	Reason  string
+	RetryAt time.Time
+}
+
+type SpeechReport struct {
+	Synthesized int
+	Failed      []SpeechFailure
+}
+
+// NewAudioLogic accepts a nil speech provider, missing audio is not filled in then
+func NewAudioLogic(db *storage.Database, images *ImageLogic, speech SpeechProvider, maxBytes int64) *AudioLogic {
+	return &AudioLogic{
+		db:       db,
+		images:   images,
+		speech:   speech,
+		maxBytes: maxBytes,
+	}
+}
+
+// Store validates the uploaded clip and returns its url, invalid files are reported with media errors
+func (l *AudioLogic) Store(r io.Reader) (string, error) {
+	audio, err := media.ProcessAudio(r, l.maxBytes)
+	if err != nil {
+		return "", err
+	}
+	key, err := l.images.StoreAudio(audio)
+	if err != nil {
+		return "", err
+	}
+	return l.images.URL(key), nil
+}
+
+// FillMissing synthesizes the words of up to limit tasks without audio in the language of their course.
+// Failed tasks are retried later with a growing delay so they do not hold back the others
+func (l *AudioLogic) FillMissing(ctx context.Context, limit int, now time.Time) (*SpeechReport, error) {
+	if l.speech == nil {
+		return nil, ErrNoSpeechProvider
+	}
+	tasks, err := l.db.Task.ListWithoutAudio(limit, now)
+	if err != nil {
+		return nil, err
+	}
+	languages, err := l.courseLanguages(tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SpeechReport{}
+	for i := range tasks {
+		task := &tasks[i]
+		clip, err := l.speech.Synthesize(ctx, task.Word, languages[task.CourseID])
+		if err != nil {
+			if err := l.deferTask(report, task, err.Error(), now); err != nil {
+				return report, err
+			}
+			continue
+		}
+		audio, err := l.Store(bytes.NewReader(clip))
+		if media.Rejected(err) {
+			if err := l.deferTask(report, task, "invalid clip: "+err.Error(), now); err != nil {
+				return report, err
+			}
+			continue
+		}
+		if err != nil {
+			return report, err
+		}
+		set, err := l.db.Task.SetAudio(task.ID, audio)
+		if err != nil {
+			return report, err
+		}
+		// a clip set in the meantime is kept, the unused one is collected with the unreferenced images
+		if set {
+			report.Synthesized++
+		}
+	}
+	return report, nil
+}
+
+func (l *AudioLogic) deferTask(report *SpeechReport, task *internal.Task, reason string, now time.Time) error {
+	attempts := task.AudioAttempts + 1
+	retryAt := now.Add(speechRetryAfter(attempts))
+	if err := l.db.Task.DeferAudio(task.ID, attempts, retryAt); err != nil {
+		return errors.Wrapf(err, "while deferring audio of task %d", task.ID)
+	}
+	report.Failed = append(report.Failed, SpeechFailure{TaskID: task.ID, Reason: reason, RetryAt: retryAt})
+	return nil
+}
+
+// speechRetryAfter returns the wait after the given number of failed syntheses
+func speechRetryAfter(attempts int) time.Duration {
+	delay := speechRetryDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxSpeechRetryDelay {
+			return maxSpeechRetryDelay
+		}
+	}
+	return delay
+}
+
+func (l *AudioLogic) courseLanguages(tasks []internal.Task) (map[uint]string, error) {
+	ids := make([]string, 0)
+	seen := make(map[uint]bool)
+	for _, task := range tasks {
+		if !seen[task.CourseID] {
+			seen[task.CourseID] = true
+			ids = append(ids, strconv.Itoa(int(task.CourseID)))
+		}
+	}
+	courses, err := l.db.Course.GetManyByID(ids)
+	if err != nil {
+		return nil, errors.Wrap(err, "while getting courses of tasks")
+	}
+
+	languages := make(map[uint]string, len(courses))
+	for _, course := range courses {
+		languages[course.ID] = course.Language
+	}
+	return languages, nil
+}
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/event"
+	"github.com/best-project/api/internal/storage"
+	"github.com/pkg/errors"
+	mathrand "math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrSelfChallenge     = errors.New("users cannot challenge themselves")
+	ErrChallengeExpiry   = errors.New("challenge expiry is too long")
+	ErrChallengeNoTasks  = errors.New("course has no tasks to challenge on")
+	ErrNotParticipant    = errors.New("user does not take part in the challenge")
+	ErrChallengeFinished = errors.New("challenge is already finished by the user")
+	ErrChallengeExpired  = errors.New("challenge has expired")
+	ErrChallengeClosed   = errors.New("challenge is already decided")
+)
+
+// ChallengeSettings configure head-to-head challenges
+type ChallengeSettings struct {
+	TaskCount     int
+	DefaultExpiry time.Duration
+	MaxExpiry     time.Duration
+}
+
+type ChallengeLogic struct {
+	db          *storage.Database
+	courseLogic CourseResultHandler
+	xp          XPRules
+	settings    ChallengeSettings
+	events      event.Publisher
+	now         func() time.Time
+}
+
+type ChallengeHandler interface {
+	Create(challengerID, opponentID uint, course *internal.Course, expiresIn time.Duration) (*internal.Challenge, []internal.Task, error)
+	Get(userID, challengeID uint) (*internal.Challenge, []internal.Task, error)
+	Finish(userID, challengeID uint, answers []internal.AnswerDTO) (*internal.Challenge, error)
+	ListForUser(userID uint) ([]internal.Challenge, error)
+}
+
+func NewChallengeLogic(db *storage.Database, courseLogic CourseResultHandler, xp XPRules, settings ChallengeSettings, events event.Publisher) *ChallengeLogic {
+	return &ChallengeLogic{
+		db:          db,
+		courseLogic: courseLogic,
+		xp:          xp,
+		settings:    settings,
+		events:      events,
+		now:         time.Now,
+	}
+}
+
+// Create picks the tasks both players answer, the default expiry is used when expiresIn is zero
+func (c *ChallengeLogic) Create(challengerID, opponentID uint, course *internal.Course, expiresIn time.Duration) (*internal.Challenge, []internal.Task, error) {
+	if challengerID == opponentID {
+		return nil, nil, ErrSelfChallenge
+	}
+	if expiresIn == 0 {
+		expiresIn = c.settings.DefaultExpiry
+	}
+	if expiresIn > c.settings.MaxExpiry {
+		return nil, nil, ErrChallengeExpiry
+	}
+
+	now := c.now()
+	tasks := pickTasks(course.Task, c.settings.TaskCount, mathrand.New(mathrand.NewSource(now.UnixNano())))
+	if len(tasks) == 0 {
+		return nil, nil, ErrChallengeNoTasks
+	}
+	ids := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, strconv.Itoa(int(task.ID)))
+	}
+	challenge := &internal.Challenge{
+		CourseID:     course.ID,
+		ChallengerID: challengerID,
+		OpponentID:   opponentID,
+		TaskIDs:      strings.Join(ids, ","),
+		MaxPoints:    len(tasks) * c.xp.ForTask(course.Type),
+		ExpiresAt:    now.Add(expiresIn),
+		Status:       internal.PendingChallenge,
+	}
+	if err := c.db.Challenge.SaveChallenge(challenge); err != nil {
+		return nil, nil, errors.Wrap(err, "while saving challenge")
+	}
+	c.events.Publish(event.Event{Type: event.ChallengeReceived, UserID: opponentID, ActorID: challengerID, CourseID: course.ID, RefID: challenge.ID})
+
+	return challenge, tasks, nil
+}
+
+// Get returns the challenge with its tasks in the order they were picked
+func (c *ChallengeLogic) Get(userID, challengeID uint) (*internal.Challenge, []internal.Task, error) {
+	challenge, err := c.db.Challenge.GetByID(challengeID)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "while getting challenge %d", challengeID)
+	}
+	if challenge.ChallengerID != userID && challenge.OpponentID != userID {
+		return nil, nil, ErrNotParticipant
+	}
+	course, err := c.db.Course.GetByID(strconv.Itoa(int(challenge.CourseID)))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "while getting course")
+	}
+
+	taskByID := make(map[string]internal.Task, len(course.Task))
+	for _, task := range course.Task {
+		taskByID[strconv.Itoa(int(task.ID))] = task
+	}
+	tasks := make([]internal.Task, 0)
+	for _, id := range strings.Split(challenge.TaskIDs, ",") {
+		if task, ok := taskByID[id]; ok {
+			tasks = append(tasks, task)
+		}
+	}
+
+	return challenge, tasks, nil
+}
+
+func (c *ChallengeLogic) ListForUser(userID uint) ([]internal.Challenge, error) {
+	return c.db.Challenge.ListForUser(userID)
+}
+
+// Finish grades answers of the player, the challenge is decided when both players finished.
+// Points are passed to CheckResult so a won challenge gives XP like a finished course.
+// Conditional updates keep parallel requests and instances from finishing or deciding it twice
+func (c *ChallengeLogic) Finish(userID, challengeID uint, answers []internal.AnswerDTO) (*internal.Challenge, error) {
+	challenge, err := c.db.Challenge.GetByID(challengeID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "while getting challenge %d", challengeID)
+	}
+	points, finishedAt := challengeSide(challenge, userID)
+	if points == nil {
+		return nil, ErrNotParticipant
+	}
+	if *finishedAt != nil {
+		return challenge, ErrChallengeFinished
+	}
+	if challenge.Status != internal.PendingChallenge {
+		return challenge, ErrChallengeClosed
+	}
+	now := c.now()
+	if now.After(challenge.ExpiresAt) {
+		return challenge, ErrChallengeExpired
+	}
+
+	course, err := c.db.Course.GetByID(strconv.Itoa(int(challenge.CourseID)))
+	if err != nil {
+		return nil, errors.Wrap(err, "while getting course")
+	}
+	earned := uint(gradeAnswers(challenge.TaskIDs, course.Task, answers) * c.xp.ForTask(course.Type))
+	recorded, err := c.db.Challenge.FinishSide(challenge.ID, userID == challenge.ChallengerID, earned, now)
+	if err != nil {
+		return nil, err
+	}
+	challenge, err = c.db.Challenge.GetByID(challengeID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "while getting challenge %d", challengeID)
+	}
+	if !recorded {
+		// a parallel request of the player or the expiry was first
+		if challenge.Status != internal.PendingChallenge {
+			return challenge, ErrChallengeClosed
+		}
+		return challenge, ErrChallengeFinished
+	}
+	if challenge.ChallengerFinishedAt != nil && challenge.OpponentFinishedAt != nil {
+		if err := c.settle(challenge); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &internal.CourseResult{
+		UserID:    userID,
+		CourseID:  challenge.CourseID,
+		Points:    earned,
+		MaxPoints: challenge.MaxPoints,
+		Phase:     internal.FinishedPhase,
+		Direction: internal.ForwardDirection,
+	}
+	if _, err := c.courseLogic.CheckResult(result); err != nil {
+		return challenge, errors.Wrap(err, "while checking challenge result")
+	}
+
+	return challenge, nil
+}
+
+// SettleExpired decides challenges which expired before both players finished, it runs on every instance
+// and a challenge decided by another one is skipped
+func (c *ChallengeLogic) SettleExpired(now time.Time) error {
+	challenges, err := c.db.Challenge.ListExpired(now)
+	if err != nil {
+		return err
+	}
+	for i := range challenges {
+		if err := c.settle(&challenges[i]); err != nil {
+			return errors.Wrapf(err, "while settling challenge %d", challenges[i].ID)
+		}
+	}
+	return nil
+}
+
+// settle decides the challenge and records the result on profiles of both players,
+// profiles are left alone when the challenge was decided meanwhile
+func (c *ChallengeLogic) settle(challenge *internal.Challenge) error {
+	winnerID, loserID, played := decideChallenge(challenge)
+	challenge.WinnerID = winnerID
+	challenge.Status = internal.FinishedChallenge
+	if !played {
+		challenge.Status = internal.ExpiredChallenge
+	}
+	decided, err := c.db.Challenge.Decide(challenge)
+	if err != nil {
+		return err
+	}
+	if !decided || !played {
+		return nil
+	}
+
+	for _, userID := range []uint{challenge.ChallengerID, challenge.OpponentID} {
+		user, err := c.db.User.GetByID(userID)
+		if err != nil {
+			return errors.Wrapf(err, "while getting user %d", userID)
+		}
+		switch userID {
+		case winnerID:
+			user.ChallengeWins++
+		case loserID:
+			user.ChallengeLosses++
+		default:
+			user.ChallengeDraws++
+		}
+		if err := c.db.User.SaveUser(user); err != nil {
+			return errors.Wrapf(err, "while saving user %d", userID)
+		}
+	}
+	return nil
+}
+
+// decideChallenge returns the winner and the loser, both are zero for a draw.
+// A player who did not finish before expiry loses, played is false when nobody finished.
+func decideChallenge(challenge *internal.Challenge) (winnerID, loserID uint, played bool) {
+	challengerDone, opponentDone := challenge.ChallengerFinishedAt != nil, challenge.OpponentFinishedAt != nil
+	switch {
+	case !challengerDone && !opponentDone:
+		return 0, 0, false
+	case !opponentDone:
+		return challenge.ChallengerID, challenge.OpponentID, true
+	case !challengerDone:
+		return challenge.OpponentID, challenge.ChallengerID, true
+	case challenge.ChallengerPoints > challenge.OpponentPoints:
+		return challenge.ChallengerID, challenge.OpponentID, true
+	case challenge.OpponentPoints > challenge.ChallengerPoints:
+		return challenge.OpponentID, challenge.ChallengerID, true
+	default:
+		return 0, 0, true
+	}
+}
+
+// challengeSide returns the points and the finish time of the player, nil when the user does not play
+func challengeSide(challenge *internal.Challenge, userID uint) (*uint, **time.Time) {
+	switch userID {
+	case challenge.ChallengerID:
+		return &challenge.ChallengerPoints, &challenge.ChallengerFinishedAt
+	case challenge.OpponentID:
+		return &challenge.OpponentPoints, &challenge.OpponentFinishedAt
+	default:
+		return nil, nil
+	}
+}
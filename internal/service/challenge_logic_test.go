@@ -0,0 +1,52 @@
+package service
+
+import (
+	"github.com/best-project/api/internal"
+	"gopkg.in/go-playground/assert.v1"
+	"testing"
+	"time"
+)
+
+func TestDecideChallenge(t *testing.T) {
+	finished := time.Now()
+	challenge := func(challengerPoints, opponentPoints uint, challengerDone, opponentDone bool) *internal.Challenge {
+		c := &internal.Challenge{ChallengerID: 1, OpponentID: 2, ChallengerPoints: challengerPoints, OpponentPoints: opponentPoints}
+		if challengerDone {
+			c.ChallengerFinishedAt = &finished
+		}
+		if opponentDone {
+			c.OpponentFinishedAt = &finished
+		}
+		return c
+	}
+
+	for name, tc := range map[string]struct {
+		challenge      *internal.Challenge
+		winner, loser  uint
+		expectedPlayed bool
+	}{
+		"challenger wins":      {challenge(30, 20, true, true), 1, 2, true},
+		"opponent wins":        {challenge(10, 20, true, true), 2, 1, true},
+		"draw":                 {challenge(20, 20, true, true), 0, 0, true},
+		"opponent did not end": {challenge(0, 0, true, false), 1, 2, true},
+		"challenger timed out": {challenge(0, 10, false, true), 2, 1, true},
+		"nobody played":        {challenge(0, 0, false, false), 0, 0, false},
+	} {
+		winner, loser, played := decideChallenge(tc.challenge)
+		if winner != tc.winner || loser != tc.loser || played != tc.expectedPlayed {
+			t.Errorf("%s: got winner %d, loser %d, played %v", name, winner, loser, played)
+		}
+	}
+}
+
+func TestChallengeSide(t *testing.T) {
+	c := &internal.Challenge{ChallengerID: 1, OpponentID: 2}
+
+	points, _ := challengeSide(c, 2)
+	*points = 40
+	assert.Equal(t, c.OpponentPoints, uint(40))
+
+	points, finishedAt := challengeSide(c, 3)
+	assert.Equal(t, points, (*uint)(nil))
+	assert.Equal(t, finishedAt, (**time.Time)(nil))
+}
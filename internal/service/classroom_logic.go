@@ -0,0 +1,339 @@
+package service
+
+import (
+	"crypto/rand"
+	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/event"
+	"github.com/best-project/api/internal/storage"
+	"github.com/pkg/errors"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrNotTeacher      = errors.New("only teachers of the classroom can do it")
+	ErrNotMember       = errors.New("user is not a member of the classroom")
+	ErrUnknownJoinCode = errors.New("no classroom with the join code")
+)
+
+// joinCodeAlphabet skips characters which are easy to confuse
+const (
+	joinCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	joinCodeLength   = 6
+)
+
+type AssignmentProgress struct {
+	internal.Assignment
+	BestPoints uint
+	Passed     bool
+	Attempts   int
+	// TimeSpent in milliseconds
+	TimeSpent int
+	Overdue   bool
+}
+
+type StudentProgress struct {
+	UserID      uint
+	Overdue     int
+	Assignments []AssignmentProgress
+}
+
+type ClassroomDashboard struct {
+	Classroom   *internal.Classroom
+	Assignments []internal.Assignment
+	Students    []StudentProgress
+}
+
+type ClassroomLogic struct {
+	db     *storage.Database
+	events event.Publisher
+	now    func() time.Time
+}
+
+type ClassroomHandler interface {
+	Create(teacherID uint, name string) (*internal.Classroom, error)
+	Join(userID uint, code string) (*internal.Classroom, error)
+	ListForUser(userID uint) ([]internal.Classroom, error)
+	Get(userID, classroomID uint) (*internal.Classroom, *internal.ClassroomMember, []internal.Assignment, error)
+	Assign(userID, classroomID, courseID uint, dueAt time.Time) (*internal.Assignment, error)
+	Dashboard(userID, classroomID uint) (*ClassroomDashboard, error)
+	HasCourse(userID uint, course *internal.Course) (bool, error)
+}
+
+func NewClassroomLogic(db *storage.Database, events event.Publisher) *ClassroomLogic {
+	return &ClassroomLogic{
+		db:     db,
+		events: events,
+		now:    time.Now,
+	}
+}
+
+// Subscribe counts finished attempts of courses
+func (c *ClassroomLogic) Subscribe(bus *event.Bus) {
+	bus.Subscribe(event.ResultSaved, c.countAttempt)
+}
+
+// Create creates the classroom with the teacher as its first member
+func (c *ClassroomLogic) Create(teacherID uint, name string) (*internal.Classroom, error) {
+	code, err := newJoinCode()
+	if err != nil {
+		return nil, errors.Wrap(err, "while generating join code")
+	}
+	classroom := &internal.Classroom{TeacherID: teacherID, Name: name, JoinCode: code}
+	if err := c.db.Classroom.SaveClassroom(classroom); err != nil {
+		return nil, errors.Wrap(err, "while saving classroom")
+	}
+	member := &internal.ClassroomMember{ClassroomID: classroom.ID, UserID: teacherID, Role: internal.TeacherRole}
+	if err := c.db.Classroom.SaveMember(member); err != nil {
+		return nil, errors.Wrap(err, "while saving teacher")
+	}
+
+	return classroom, nil
+}
+
+// Join adds the user as a student, joining twice keeps the current role
+func (c *ClassroomLogic) Join(userID uint, code string) (*internal.Classroom, error) {
+	classroom, err := c.db.Classroom.GetByCode(code)
+	if err != nil {
+		return nil, err
+	}
+	if classroom == nil {
+		return nil, ErrUnknownJoinCode
+	}
+	member, err := c.db.Classroom.FindMember(classroom.ID, userID)
+	if err != nil || member != nil {
+		return classroom, err
+	}
+
+	member = &internal.ClassroomMember{ClassroomID: classroom.ID, UserID: userID, Role: internal.StudentRole}
+	return classroom, c.db.Classroom.SaveMember(member)
+}
+
+func (c *ClassroomLogic) ListForUser(userID uint) ([]internal.Classroom, error) {
+	return c.db.Classroom.ListForUser(userID)
+}
+
+// Get returns the classroom with its assignments to a member of the classroom
+func (c *ClassroomLogic) Get(userID, classroomID uint) (*internal.Classroom, *internal.ClassroomMember, []internal.Assignment, error) {
+	classroom, member, err := c.member(userID, classroomID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	assignments, err := c.db.Classroom.ListAssignments(classroomID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return classroom, member, assignments, nil
+}
+
+func (c *ClassroomLogic) Assign(userID, classroomID, courseID uint, dueAt time.Time) (*internal.Assignment, error) {
+	if _, err := c.teacher(userID, classroomID); err != nil {
+		return nil, err
+	}
+	assignment := &internal.Assignment{ClassroomID: classroomID, CourseID: courseID, DueAt: dueAt}
+	if err := c.db.Classroom.SaveAssignment(assignment); err != nil {
+		return nil, errors.Wrap(err, "while saving assignment")
+	}
+	members, err := c.db.Classroom.ListMembers(classroomID)
+	if err != nil {
+		return nil, errors.Wrap(err, "while listing classroom members")
+	}
+	for _, member := range members {
+		if member.Role != internal.StudentRole {
+			continue
+		}
+		c.events.Publish(event.Event{Type: event.AssignmentCreated, UserID: member.UserID, ActorID: userID, CourseID: courseID, RefID: classroomID})
+	}
+
+	return assignment, nil
+}
+
+// Dashboard shows progress of every student on the classroom assignments to the teacher
+func (c *ClassroomLogic) Dashboard(userID, classroomID uint) (*ClassroomDashboard, error) {
+	classroom, err := c.teacher(userID, classroomID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := c.db.Classroom.ListMembers(classroomID)
+	if err != nil {
+		return nil, err
+	}
+	assignments, err := c.db.Classroom.ListAssignments(classroomID)
+	if err != nil {
+		return nil, err
+	}
+
+	students := make([]uint, 0, len(members))
+	best := make(map[uint][]internal.CourseResult)
+	passed := make(map[uint][]internal.CourseResult)
+	for _, member := range members {
+		if member.Role != internal.StudentRole {
+			continue
+		}
+		students = append(students, member.UserID)
+		if best[member.UserID], err = c.db.CourseResult.ListBestResultsForUser(member.UserID); err != nil {
+			return nil, errors.Wrapf(err, "while listing results of user %d", member.UserID)
+		}
+		if passed[member.UserID], err = c.db.CourseResult.ListPassedForUser(member.UserID); err != nil {
+			return nil, errors.Wrapf(err, "while listing passed results of user %d", member.UserID)
+		}
+	}
+	courses := make([]uint, 0, len(assignments))
+	for _, assignment := range assignments {
+		courses = append(courses, assignment.CourseID)
+	}
+	attempts, err := c.db.Attempt.ListForUsers(students, courses)
+	if err != nil {
+		return nil, err
+	}
+	times, err := c.db.Answer.TimeForUsers(students, courses)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ClassroomDashboard{
+		Classroom:   classroom,
+		Assignments: assignments,
+		Students:    buildProgress(students, assignments, best, passed, attempts, times, c.now()),
+	}, nil
+}
+
+// Members resolves the classroom leaderboard scope for members of the classroom
+func (c *ClassroomLogic) Members(userID uint, groupID string) ([]uint, error) {
+	classroomID, err := strconv.Atoi(groupID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "while parsing classroom id %s", groupID)
+	}
+	if _, _, err := c.member(userID, uint(classroomID)); err != nil {
+		return nil, err
+	}
+	members, err := c.db.Classroom.ListMembers(uint(classroomID))
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(members))
+	for _, member := range members {
+		if member.Role == internal.StudentRole {
+			ids = append(ids, member.UserID)
+		}
+	}
+
+	return ids, nil
+}
+
+// HasCourse tells if the course is assigned in a classroom the user attends, only assignments
+// made by the course author count
+func (c *ClassroomLogic) HasCourse(userID uint, course *internal.Course) (bool, error) {
+	classrooms, err := c.db.Classroom.ListForUser(userID)
+	if err != nil {
+		return false, err
+	}
+	for _, classroom := range classrooms {
+		if classroom.TeacherID != course.UserID {
+			continue
+		}
+		assignments, err := c.db.Classroom.ListAssignments(classroom.ID)
+		if err != nil {
+			return false, err
+		}
+		for _, assignment := range assignments {
+			if assignment.CourseID == course.ID {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (c *ClassroomLogic) member(userID, classroomID uint) (*internal.Classroom, *internal.ClassroomMember, error) {
+	classroom, err := c.db.Classroom.GetByID(classroomID)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "while getting classroom %d", classroomID)
+	}
+	member, err := c.db.Classroom.FindMember(classroomID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if member == nil {
+		return nil, nil, ErrNotMember
+	}
+
+	return classroom, member, nil
+}
+
+func (c *ClassroomLogic) teacher(userID, classroomID uint) (*internal.Classroom, error) {
+	classroom, member, err := c.member(userID, classroomID)
+	if err == ErrNotMember || (err == nil && member.Role != internal.TeacherRole) {
+		return nil, ErrNotTeacher
+	}
+
+	return classroom, err
+}
+
+func (c *ClassroomLogic) countAttempt(e event.Event) error {
+	return c.db.Attempt.CountAttempt(e.UserID, e.CourseID)
+}
+
+// buildProgress combines results, attempts and time spent of students per assignment.
+// An assignment is overdue when it was not passed before its due date, improving the result later does not change it.
+func buildProgress(students []uint, assignments []internal.Assignment, best, passed map[uint][]internal.CourseResult,
+	attempts []internal.CourseAttempt, times []internal.CourseTime, now time.Time) []StudentProgress {
+	type key struct{ user, course uint }
+	attemptsBy := make(map[key]int)
+	for _, attempt := range attempts {
+		attemptsBy[key{attempt.UserID, attempt.CourseID}] = attempt.Attempts
+	}
+	timeBy := make(map[key]int)
+	for _, t := range times {
+		timeBy[key{t.UserID, t.CourseID}] = t.Duration
+	}
+
+	result := make([]StudentProgress, 0, len(students))
+	for _, userID := range students {
+		bestBy := make(map[uint]internal.CourseResult)
+		for _, r := range best[userID] {
+			if current, ok := bestBy[r.CourseID]; !ok || r.Points > current.Points {
+				bestBy[r.CourseID] = r
+			}
+		}
+		firstPassed := make(map[uint]time.Time)
+		for _, r := range passed[userID] {
+			if first, ok := firstPassed[r.CourseID]; !ok || r.CreatedAt.Before(first) {
+				firstPassed[r.CourseID] = r.CreatedAt
+			}
+		}
+
+		student := StudentProgress{UserID: userID, Assignments: make([]AssignmentProgress, 0, len(assignments))}
+		for _, assignment := range assignments {
+			k := key{userID, assignment.CourseID}
+			r, done := bestBy[assignment.CourseID]
+			progress := AssignmentProgress{
+				Assignment: assignment,
+				BestPoints: r.Points,
+				Passed:     done && r.Passed,
+				Attempts:   attemptsBy[k],
+				TimeSpent:  timeBy[k],
+			}
+			first, passedOnce := firstPassed[assignment.CourseID]
+			progress.Overdue = now.After(assignment.DueAt) && (!passedOnce || first.After(assignment.DueAt))
+			if progress.Overdue {
+				student.Overdue++
+			}
+			student.Assignments = append(student.Assignments, progress)
+		}
+		result = append(result, student)
+	}
+	return result
+}
+
+func newJoinCode() (string, error) {
+	b := make([]byte, joinCodeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = joinCodeAlphabet[int(b[i])%len(joinCodeAlphabet)]
+	}
+	return string(b), nil
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"github.com/best-project/api/internal"
+	"gopkg.in/go-playground/assert.v1"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildProgress(t *testing.T) {
+	now := time.Date(2020, 3, 10, 12, 0, 0, 0, time.UTC)
+	assignments := []internal.Assignment{
+		{ID: 1, CourseID: 10, DueAt: now.AddDate(0, 0, -2)},
+		{ID: 2, CourseID: 20, DueAt: now.AddDate(0, 0, 2)},
+		{ID: 3, CourseID: 30, DueAt: now.AddDate(0, 0, -1)},
+	}
+	best := map[uint][]internal.CourseResult{
+		1: {
+			{CourseID: 10, Points: 40, Passed: true, CreatedAt: now.AddDate(0, 0, -3), UpdatedAt: now.AddDate(0, 0, -3)},
+			// improved after the due date
+			{CourseID: 10, Points: 80, Passed: true, Direction: internal.ReverseDirection, CreatedAt: now.AddDate(0, 0, -1), UpdatedAt: now},
+			{CourseID: 30, Points: 90, Passed: true, CreatedAt: now, UpdatedAt: now},
+		},
+	}
+	passed := map[uint][]internal.CourseResult{
+		1: {
+			{CourseID: 10, Points: 80, Passed: true, CreatedAt: now.AddDate(0, 0, -1)},
+			{CourseID: 10, Points: 40, Passed: true, CreatedAt: now.AddDate(0, 0, -3)},
+			{CourseID: 30, Points: 90, Passed: true, CreatedAt: now},
+		},
+	}
+	attempts := []internal.CourseAttempt{{UserID: 1, CourseID: 10, Attempts: 3}}
+	times := []internal.CourseTime{{UserID: 1, CourseID: 10, Duration: 5000}}
+
+	progress := buildProgress([]uint{1, 2}, assignments, best, passed, attempts, times, now)
+
+	assert.Equal(t, len(progress), 2)
+	first := progress[0].Assignments
+	assert.Equal(t, first[0].BestPoints, uint(80))
+	assert.Equal(t, first[0].Passed, true)
+	assert.Equal(t, first[0].Attempts, 3)
+	assert.Equal(t, first[0].TimeSpent, 5000)
+	assert.Equal(t, first[0].Overdue, false)
+	assert.Equal(t, first[1].Overdue, false)
+	// passed only after the due date
+	assert.Equal(t, first[2].Overdue, true)
+	assert.Equal(t, progress[0].Overdue, 1)
+
+	assert.Equal(t, progress[1].Overdue, 2)
+	assert.Equal(t, progress[1].Assignments[1].Overdue, false)
+}
+
+func TestNewJoinCode(t *testing.T) {
+	code, err := newJoinCode()
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(code), joinCodeLength)
+	for _, c := range code {
+		assert.Equal(t, strings.ContainsRune(joinCodeAlphabet, c), true)
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"fmt"
 	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/event"
 	"github.com/best-project/api/internal/storage"
 	"github.com/pkg/errors"
 	"strconv"
@@ -11,18 +12,28 @@ import (
 type CourseLogic struct {
 	db          *storage.Database
 	passPercent float32
+	streaks     StreakHandler
+	events      event.Publisher
+	xp          XPRules
+	curve       LevelCurve
 }
 
-var firstLevel = 100
-
 type CourseResultHandler interface {
 	CheckResult(result *internal.CourseResult) (bool, error)
 }
 
-func NewCourseLogic(db *storage.Database, passPercent float32) *CourseLogic {
+type XPAwarder interface {
+	AwardXP(user *internal.User, xp int) error
+}
+
+func NewCourseLogic(db *storage.Database, passPercent float32, xp XPRules, curve LevelCurve, streaks StreakHandler, events event.Publisher) *CourseLogic {
 	return &CourseLogic{
 		db:          db,
 		passPercent: passPercent,
+		xp:          xp,
+		curve:       curve,
+		streaks:     streaks,
+		events:      events,
 	}
 }
 
@@ -31,11 +42,21 @@ func (c *CourseLogic) CheckResult(result *internal.CourseResult) (bool, error) {
 	if err != nil {
 		return false, errors.Wrap(err, "while getting course")
 	}
-	if !c.isWon(result.Points, uint(course.MaxPoints)) {
+	// a session of a subset of tasks cannot need more points than the whole course
+	maxPoints := course.MaxPoints
+	if result.MaxPoints > 0 && (maxPoints == 0 || result.MaxPoints < maxPoints) {
+		maxPoints = result.MaxPoints
+	}
+	if !c.isWon(result.Points, uint(maxPoints), c.passPercentFor(course)) {
+		c.publishResult(result)
 		return false, nil
 	}
 	result.Passed = true
 
+	alreadyPassed, err := c.db.CourseResult.HasPassed(result.UserID, result.CourseID)
+	if err != nil {
+		return true, errors.Wrap(err, "while checking previous results")
+	}
 	if err := c.db.CourseResult.SaveResult(result); err != nil {
 		return true, err
 	}
@@ -44,26 +65,92 @@ func (c *CourseLogic) CheckResult(result *internal.CourseResult) (bool, error) {
 	if err != nil {
 		return true, err
 	}
-	user.Points += int(result.Points)
-	user.Level, user.NextLevel = c.calculateLevel(user.Points)
-	if err = c.db.User.SaveUser(user); err != nil {
+	if err := c.awardXP(user, course.ID, c.xp.ForResult(result.Points, maxPoints, alreadyPassed)); err != nil {
 		return true, err
 	}
+	c.publishResult(result)
 
 	return true, nil
 }
 
-func (c *CourseLogic) isWon(points, maxPoints uint) bool {
+// AwardXP adds points to the user, recalculates the level and counts the points towards the daily goal
+func (c *CourseLogic) AwardXP(user *internal.User, xp int) error {
+	return c.awardXP(user, 0, xp)
+}
+
+// awardXP awards points earned in the course, courseID is zero for points not related to a course
+func (c *CourseLogic) awardXP(user *internal.User, courseID uint, xp int) error {
+	previousLevel := user.Level
+	user.Points += xp
+	user.Level, user.NextLevel = c.calculateLevel(user.Points)
+	if err := c.streaks.RecordXP(user, xp); err != nil {
+		return errors.Wrap(err, "while recording daily points")
+	}
+	if err := c.db.User.SaveUser(user); err != nil {
+		return err
+	}
+	c.events.Publish(event.Event{Type: event.XPEarned, UserID: user.ID, CourseID: courseID, Points: uint(xp)})
+	if user.Level > previousLevel {
+		c.events.Publish(event.Event{Type: event.LevelUp, UserID: user.ID, Value: float32(user.Level)})
+	}
+
+	return nil
+}
+
+func (c *CourseLogic) publishResult(result *internal.CourseResult) {
+	c.events.Publish(event.Event{
+		Type:     event.ResultSaved,
+		UserID:   result.UserID,
+		CourseID: result.CourseID,
+		Points:   result.Points,
+		Passed:   result.Passed,
+	})
+}
+
+func (c *CourseLogic) isWon(points, maxPoints uint, passPercent float32) bool {
 	result := float32(points) / float32(maxPoints)
-	return result > c.passPercent
+	return result > passPercent
+}
+
+// passPercentFor returns the course pass threshold, falling back to the configured one
+func (c *CourseLogic) passPercentFor(course *internal.Course) float32 {
+	if course.PassPercent > 0 {
+		return course.PassPercent
+	}
+	return c.passPercent
+}
+
+// RecomputeLevels updates level of every user after the level curve has changed
+func (c *CourseLogic) RecomputeLevels() (int, error) {
+	users, err := c.db.User.GetAll()
+	if err != nil {
+		return 0, errors.Wrap(err, "while listing users")
+	}
+
+	updated := 0
+	for i := range users {
+		user := &users[i]
+		level, nextLevel := c.calculateLevel(user.Points)
+		if level == user.Level && nextLevel == user.NextLevel {
+			continue
+		}
+		user.Level, user.NextLevel = level, nextLevel
+		if err := c.db.User.SaveUser(user); err != nil {
+			return updated, errors.Wrapf(err, "while saving user %d", user.ID)
+		}
+		updated++
+	}
+
+	return updated, nil
 }
 
+// calculateLevel returns the level for the points and the points divided by the points needed for the level
 func (c *CourseLogic) calculateLevel(points int) (int, string) {
 	nextLvl := 0
-	for i := 1; true; i++ {
-		xpNeeded := int(float64(firstLevel+10*i) * 1.2)
+	for i := 1; i <= MaxLevel; i++ {
+		xpNeeded := c.curve.XPForLevel(i)
 		nextLvl += xpNeeded
-		if nextLvl > points {
+		if nextLvl > points || i == MaxLevel {
 			return i, fmt.Sprintf("%.2f", float64(points)/float64(xpNeeded))
 		}
 	}
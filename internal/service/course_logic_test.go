@@ -6,10 +6,45 @@ import (
 )
 
 func TestCalculateLevel(t *testing.T) {
-	logic := NewCourseLogic(nil, 0.7)
+	logic := NewCourseLogic(nil, 0.7, DefaultXPRules, DefaultLevelCurve, nil, nil)
 
 	lvl, nextLvl := logic.calculateLevel(1000)
 
 	assert.Equal(t, lvl, 7)
-	assert.Equal(t, nextLvl, 204)
+	assert.Equal(t, nextLvl, "4.90")
+}
+
+func TestCalculateLevelWithTable(t *testing.T) {
+	logic := NewCourseLogic(nil, 0.7, DefaultXPRules, TableCurve{100, 200}, nil, nil)
+
+	lvl, nextLvl := logic.calculateLevel(0)
+	assert.Equal(t, lvl, 1)
+	assert.Equal(t, nextLvl, "0.00")
+
+	lvl, nextLvl = logic.calculateLevel(250)
+	assert.Equal(t, lvl, 2)
+	assert.Equal(t, nextLvl, "1.25")
+
+	lvl, _ = logic.calculateLevel(700)
+	assert.Equal(t, lvl, 5)
+}
+
+func TestXPForResult(t *testing.T) {
+	rules := XPRules{PerfectBonus: 20, FirstCompletionBonus: 10, RepeatPercent: 0.5}
+
+	assert.Equal(t, rules.ForResult(80, 100, false), 90)
+	assert.Equal(t, rules.ForResult(100, 100, false), 130)
+	assert.Equal(t, rules.ForResult(100, 100, true), 50)
+}
+
+func TestNewLevelCurveRejectsNonPositiveLevels(t *testing.T) {
+	_, err := NewLevelCurve(LevelCurveConfig{Name: LinearCurveName, Base: 100, Step: -10, Factor: 1})
+	assert.NotEqual(t, err, nil)
+
+	_, err = NewLevelCurve(LevelCurveConfig{Name: PolynomialCurveName, Base: 100, Exponent: -1})
+	assert.NotEqual(t, err, nil)
+
+	curve, err := NewLevelCurve(LevelCurveConfig{Name: LinearCurveName, Base: 100, Step: 10, Factor: 1.2})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, curve, DefaultLevelCurve)
 }
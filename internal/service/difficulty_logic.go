@@ -0,0 +1,145 @@
+package service
+
+import (
+	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/storage"
+	"github.com/pkg/errors"
+	"math/rand"
+	"sort"
+	"strconv"
+)
+
+type DifficultyLogic struct {
+	db *storage.Database
+}
+
+type DifficultyHandler interface {
+	UpdateCourseDifficulty(courseID uint) (float32, error)
+	HardestTasks(course *internal.Course) ([]internal.TaskStat, error)
+	SelectAdaptive(userID uint, course *internal.Course, count int, rnd *rand.Rand) ([]internal.Task, error)
+}
+
+const (
+	// personalWeight is the number of answers after which the learner's own
+	// results matter as much as the results of all learners
+	personalWeight = 3
+	// minFailWeight keeps well known words in adaptive sessions
+	minFailWeight = 0.05
+)
+
+func NewDifficultyLogic(db *storage.Database) *DifficultyLogic {
+	return &DifficultyLogic{
+		db: db,
+	}
+}
+
+// UpdateCourseDifficulty computes the course difficulty score from answers of all learners
+func (d *DifficultyLogic) UpdateCourseDifficulty(courseID uint) (float32, error) {
+	course, err := d.db.Course.GetByID(strconv.Itoa(int(courseID)))
+	if err != nil {
+		return 0, errors.Wrap(err, "while getting course")
+	}
+	stats, err := d.db.Answer.StatsForCourse(courseID)
+	if err != nil {
+		return 0, errors.Wrap(err, "while getting task stats")
+	}
+
+	score := courseDifficulty(course.Task, mapStats(stats))
+	if err := d.db.Course.UpdateDifficulty(courseID, score); err != nil {
+		return 0, errors.Wrap(err, "while updating course difficulty")
+	}
+
+	return score, nil
+}
+
+// HardestTasks returns statistics of every answered task of the course, hardest first
+func (d *DifficultyLogic) HardestTasks(course *internal.Course) ([]internal.TaskStat, error) {
+	stats, err := d.db.Answer.StatsForCourse(course.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "while getting task stats")
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		return SuccessRate(stats[i]) < SuccessRate(stats[j])
+	})
+
+	return stats, nil
+}
+
+// SelectAdaptive picks count tasks, preferring words the learner most likely fails
+func (d *DifficultyLogic) SelectAdaptive(userID uint, course *internal.Course, count int, rnd *rand.Rand) ([]internal.Task, error) {
+	global, err := d.db.Answer.StatsForCourse(course.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "while getting task stats")
+	}
+	personal, err := d.db.Answer.StatsForUser(userID, course.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "while getting user task stats")
+	}
+
+	return adaptiveSelection(course.Task, mapStats(global), mapStats(personal), count, rnd), nil
+}
+
+// SuccessRate of a task smoothed towards one half, so rarely answered tasks are not extreme
+func SuccessRate(stat internal.TaskStat) float32 {
+	return float32(stat.Correct+1) / float32(stat.Attempts+2)
+}
+
+// failProbability blends the learner's own results with the results of all learners
+func failProbability(global, personal internal.TaskStat) float64 {
+	prior := float64(SuccessRate(global))
+	success := (float64(personal.Correct) + prior*personalWeight) / (float64(personal.Attempts) + personalWeight)
+	return 1 - success
+}
+
+func courseDifficulty(tasks []internal.Task, stats map[uint]internal.TaskStat) float32 {
+	if len(tasks) == 0 {
+		return 0
+	}
+	var sum float32
+	for _, task := range tasks {
+		sum += 1 - SuccessRate(stats[task.ID])
+	}
+	return sum / float32(len(tasks))
+}
+
+// adaptiveSelection draws tasks without replacement weighted by the fail probability
+func adaptiveSelection(tasks []internal.Task, global, personal map[uint]internal.TaskStat, count int, rnd *rand.Rand) []internal.Task {
+	pool := make([]internal.Task, len(tasks))
+	copy(pool, tasks)
+	weights := make([]float64, len(pool))
+	for i, task := range pool {
+		weights[i] = failProbability(global[task.ID], personal[task.ID]) + minFailWeight
+	}
+	if count <= 0 || count > len(pool) {
+		count = len(pool)
+	}
+
+	selected := make([]internal.Task, 0, count)
+	for len(selected) < count {
+		total := 0.0
+		for _, weight := range weights {
+			total += weight
+		}
+		pick := rnd.Float64() * total
+		i := 0
+		for ; i < len(weights)-1; i++ {
+			pick -= weights[i]
+			if pick < 0 {
+				break
+			}
+		}
+		selected = append(selected, pool[i])
+		pool = append(pool[:i], pool[i+1:]...)
+		weights = append(weights[:i], weights[i+1:]...)
+	}
+
+	return selected
+}
+
+func mapStats(stats []internal.TaskStat) map[uint]internal.TaskStat {
+	result := make(map[uint]internal.TaskStat, len(stats))
+	for _, stat := range stats {
+		result[stat.TaskID] = stat
+	}
+	return result
+}
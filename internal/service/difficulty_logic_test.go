@@ -0,0 +1,40 @@
+package service
+
+import (
+	"github.com/best-project/api/internal"
+	"gopkg.in/go-playground/assert.v1"
+	"math/rand"
+	"testing"
+)
+
+func TestCourseDifficulty(t *testing.T) {
+	tasks := []internal.Task{{ID: 1}, {ID: 2}}
+
+	easy := map[uint]internal.TaskStat{1: {TaskID: 1, Attempts: 98, Correct: 98}, 2: {TaskID: 2, Attempts: 98, Correct: 98}}
+	hard := map[uint]internal.TaskStat{1: {TaskID: 1, Attempts: 98, Correct: 0}, 2: {TaskID: 2, Attempts: 98, Correct: 0}}
+
+	assert.Equal(t, courseDifficulty(tasks, easy) < 0.02, true)
+	assert.Equal(t, courseDifficulty(tasks, hard) > 0.98, true)
+	assert.Equal(t, courseDifficulty(tasks, map[uint]internal.TaskStat{}), float32(0.5))
+}
+
+func TestAdaptiveSelectionPrefersFailedWords(t *testing.T) {
+	tasks := []internal.Task{{ID: 1}, {ID: 2}, {ID: 3}}
+	global := map[uint]internal.TaskStat{}
+	personal := map[uint]internal.TaskStat{
+		1: {TaskID: 1, Attempts: 20, Correct: 20},
+		2: {TaskID: 2, Attempts: 20, Correct: 0},
+		3: {TaskID: 3, Attempts: 20, Correct: 20},
+	}
+	rnd := rand.New(rand.NewSource(1))
+
+	picked := 0
+	for i := 0; i < 100; i++ {
+		selected := adaptiveSelection(tasks, global, personal, 1, rnd)
+		if selected[0].ID == 2 {
+			picked++
+		}
+	}
+	assert.Equal(t, picked > 80, true)
+	assert.Equal(t, len(adaptiveSelection(tasks, global, personal, 0, rnd)), 3)
+}
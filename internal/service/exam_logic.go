@@ -0,0 +1,213 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/storage"
+	"github.com/pkg/errors"
+	mathrand "math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrExamDisabled = errors.New("exam mode is disabled for the course")
+	ErrExamCooldown = errors.New("exam cannot be retaken yet")
+	ErrExamFinished = errors.New("exam is already finished")
+	ErrExamExpired  = errors.New("exam deadline has passed")
+	ErrExamNotOwned = errors.New("exam belongs to another user")
+)
+
+// examGrace tolerates the network latency of answers sent just before the deadline
+const examGrace = 5 * time.Second
+
+type ExamHandler interface {
+	Start(userID uint, course *internal.Course) (*internal.Exam, []internal.Task, error)
+	Finish(userID uint, examID uint, answers []internal.AnswerDTO) (*internal.Exam, *internal.Certificate, error)
+}
+
+type ExamLogic struct {
+	db          *storage.Database
+	courseLogic *CourseLogic
+	xp          XPRules
+	now         func() time.Time
+}
+
+func NewExamLogic(db *storage.Database, courseLogic *CourseLogic, xp XPRules) *ExamLogic {
+	return &ExamLogic{
+		db:          db,
+		courseLogic: courseLogic,
+		xp:          xp,
+		now:         time.Now,
+	}
+}
+
+// Start opens an exam on a random subset of the course tasks, the database keeps one exam open per course
+func (e *ExamLogic) Start(userID uint, course *internal.Course) (*internal.Exam, []internal.Task, error) {
+	if course.ExamTimeLimit <= 0 {
+		return nil, nil, ErrExamDisabled
+	}
+	now := e.now()
+
+	last, err := e.db.Exam.GetLastForUser(userID, course.ID)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "while getting last exam")
+	}
+	if last != nil {
+		if retry := e.retryAt(last, course); now.Before(retry) {
+			return nil, nil, errors.Wrapf(ErrExamCooldown, "retry after %s", retry.Format(time.RFC3339))
+		}
+		if last.Open != nil {
+			if err := e.db.Exam.CloseExam(last.ID); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	tasks := pickTasks(course.Task, course.ExamTaskCount, mathrand.New(mathrand.NewSource(now.UnixNano())))
+	ids := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, strconv.Itoa(int(task.ID)))
+	}
+	exam := &internal.Exam{
+		UserID:    userID,
+		CourseID:  course.ID,
+		TaskIDs:   strings.Join(ids, ","),
+		Deadline:  now.Add(time.Duration(course.ExamTimeLimit) * time.Second),
+		MaxPoints: len(tasks) * e.xp.ForTask(course.Type),
+	}
+	opened, err := e.db.Exam.OpenExam(exam)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "while saving exam")
+	}
+	if !opened {
+		// a parallel request started the exam first
+		return nil, nil, errors.Wrap(ErrExamCooldown, "exam is already open")
+	}
+
+	return exam, tasks, nil
+}
+
+// Finish grades the answers and issues a certificate when the exam is passed.
+// Answers sent after the deadline close the exam as failed. Only the request which
+// finishes the exam in the database gets the certificate
+func (e *ExamLogic) Finish(userID uint, examID uint, answers []internal.AnswerDTO) (*internal.Exam, *internal.Certificate, error) {
+	exam, err := e.db.Exam.GetByID(examID)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "while getting exam %d", examID)
+	}
+	if exam.UserID != userID {
+		return nil, nil, ErrExamNotOwned
+	}
+	if exam.FinishedAt != nil {
+		return nil, nil, ErrExamFinished
+	}
+	course, err := e.db.Course.GetByID(strconv.Itoa(int(exam.CourseID)))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "while getting course")
+	}
+
+	now := e.now()
+	exam.FinishedAt = &now
+	expired := now.After(exam.Deadline.Add(examGrace))
+	if !expired {
+		exam.Points = uint(gradeAnswers(exam.TaskIDs, course.Task, answers) * e.xp.ForTask(course.Type))
+		exam.Passed = exam.MaxPoints > 0 && e.courseLogic.isWon(exam.Points, uint(exam.MaxPoints), e.courseLogic.passPercentFor(course))
+	}
+	finished, err := e.db.Exam.FinishExam(exam)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !finished {
+		return nil, nil, ErrExamFinished
+	}
+	if expired {
+		return exam, nil, ErrExamExpired
+	}
+	if !exam.Passed {
+		return exam, nil, nil
+	}
+
+	code, err := newCertificateCode()
+	if err != nil {
+		return exam, nil, errors.Wrap(err, "while generating certificate code")
+	}
+	certificate := &internal.Certificate{
+		Code:      code,
+		UserID:    userID,
+		CourseID:  exam.CourseID,
+		ExamID:    exam.ID,
+		Points:    exam.Points,
+		MaxPoints: exam.MaxPoints,
+	}
+	if err := e.db.Certificate.SaveCertificate(certificate); err != nil {
+		return exam, nil, errors.Wrap(err, "while saving certificate")
+	}
+
+	return exam, certificate, nil
+}
+
+func (e *ExamLogic) retryAt(last *internal.Exam, course *internal.Course) time.Time {
+	cooldown := time.Duration(course.ExamCooldown) * time.Second
+	if last.FinishedAt == nil {
+		// an abandoned exam counts as finished at its deadline
+		return last.Deadline.Add(cooldown)
+	}
+	return last.FinishedAt.Add(cooldown)
+}
+
+// gradeAnswers counts correct answers, answers to tasks outside of the comma separated taskIDs are ignored
+func gradeAnswers(taskIDs string, tasks []internal.Task, answers []internal.AnswerDTO) int {
+	examTasks := make(map[string]internal.Task)
+	for _, id := range strings.Split(taskIDs, ",") {
+		examTasks[id] = internal.Task{}
+	}
+	for _, task := range tasks {
+		id := strconv.Itoa(int(task.ID))
+		if _, ok := examTasks[id]; ok {
+			examTasks[id] = task
+		}
+	}
+
+	correct := 0
+	for _, answer := range answers {
+		task, ok := examTasks[answer.TaskID]
+		if !ok || task.ID == 0 {
+			continue
+		}
+		if IsCorrectAnswer(task.Translate, answer.Answer) {
+			correct++
+		}
+		delete(examTasks, answer.TaskID)
+	}
+	return correct
+}
+
+// pickTasks returns count random tasks, or all of them when count is not set
+func pickTasks(tasks []internal.Task, count int, rnd *mathrand.Rand) []internal.Task {
+	picked := make([]internal.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if task.Word != "" {
+			picked = append(picked, task)
+		}
+	}
+	rnd.Shuffle(len(picked), func(i, j int) {
+		picked[i], picked[j] = picked[j], picked[i]
+	})
+	if count > 0 && count < len(picked) {
+		picked = picked[:count]
+	}
+	return picked
+}
+
+func newCertificateCode() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := strings.ToUpper(hex.EncodeToString(b))
+	return fmt.Sprintf("%s-%s-%s-%s", code[0:4], code[4:8], code[8:12], code[12:16]), nil
+}
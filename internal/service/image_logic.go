@@ -0,0 +1,260 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/media"
+	"github.com/best-project/api/internal/storage"
+	"github.com/pkg/errors"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ImageLogic stores uploads under the hash of their content so a file is kept once,
+// and removes the images nothing points to anymore
+type ImageLogic struct {
+	db    *storage.Database
+	blobs storage.BlobStore
+	grace time.Duration
+
+	// mu keeps uploads from registering an image while the collection removes it,
+	// the database lock does it for the other instances
+	mu sync.Mutex
+}
+
+// imageLockTimeout bounds the wait of an upload for a removal on another instance
+const imageLockTimeout = 10 * time.Second
+
+// ImageReport describes a garbage collection run, nothing is removed in a dry run
+type ImageReport struct {
+	DryRun     bool
+	Images     int
+	Referenced int
+	// Waiting are unreferenced images still in their grace period
+	Waiting    int
+	Removed    []string
+	FreedBytes int64
+}
+
+func NewImageLogic(db *storage.Database, blobs storage.BlobStore, grace time.Duration) *ImageLogic {
+	return &ImageLogic{
+		db:    db,
+		blobs: blobs,
+		grace: grace,
+	}
+}
+
+// Store saves the image unless the same content is stored already, created tells if it was written.
+// The grace period of a known image starts again so the entity about to reference it is saved in time
+func (l *ImageLogic) Store(img *media.Image) (key string, created bool, err error) {
+	return l.store(img.Data, img.Ext, img.ContentType)
+}
+
+// StoreAudio keeps audio clips like images, they are collected the same way
+func (l *ImageLogic) StoreAudio(audio *media.Audio) (string, error) {
+	key, _, err := l.store(audio.Data, audio.Ext, audio.ContentType)
+	return key, err
+}
+
+func (l *ImageLogic) store(data []byte, ext, contentType string) (key string, created bool, err error) {
+	key = ContentKey(data, ext)
+
+	unlock, err := l.lock()
+	if err != nil {
+		return "", false, err
+	}
+	defer unlock()
+
+	image, err := l.db.Image.GetByKey(key)
+	if err != nil {
+		return "", false, err
+	}
+	if image == nil {
+		image = &internal.Image{Key: key, Size: int64(len(data)), ContentType: contentType}
+	}
+	image.UnreferencedAt = nil
+
+	_, err = l.blobs.Stat(key)
+	switch err {
+	case nil:
+	case storage.ErrBlobNotFound:
+		if err := l.blobs.Put(key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+			return "", false, errors.Wrapf(err, "while storing %s", key)
+		}
+		created = true
+	default:
+		return "", false, errors.Wrapf(err, "while checking %s", key)
+	}
+
+	if err := l.db.Image.SaveImage(image); err != nil {
+		return "", false, errors.Wrapf(err, "while saving image %s", key)
+	}
+	return key, created, nil
+}
+
+// URL is the address under which the stored image is served
+func (l *ImageLogic) URL(key string) string {
+	return l.blobs.URL(key)
+}
+
+func (l *ImageLogic) lock() (func(), error) {
+	l.mu.Lock()
+	unlock, err := l.db.Image.Lock(imageLockTimeout)
+	if err != nil {
+		l.mu.Unlock()
+		return nil, errors.Wrap(err, "while locking images")
+	}
+	return func() {
+		unlock()
+		l.mu.Unlock()
+	}, nil
+}
+
+// Collect recounts references of every image and removes the ones unreferenced for longer than the grace period.
+// Blobs stored before images were tracked are adopted first, variants are removed with their image.
+// References are matched by the file name as rows may use an older public url of the blob store
+func (l *ImageLogic) Collect(now time.Time, dryRun bool) (*ImageReport, error) {
+	images, err := l.db.Image.ListImages()
+	if err != nil {
+		return nil, err
+	}
+	blobs, err := l.blobs.List("")
+	if err != nil {
+		return nil, err
+	}
+	images = append(images, untrackedImages(images, blobs)...)
+
+	references, err := l.db.Image.CountReferences()
+	if err != nil {
+		return nil, err
+	}
+	byKey := referencesByKey(references)
+	counts := make(map[string]int, len(images))
+	for _, image := range images {
+		counts[image.Key] = byKey[image.Key]
+	}
+
+	report := &ImageReport{DryRun: dryRun, Images: len(images)}
+	changed, removed := planCollection(images, counts, now, l.grace)
+	for _, image := range removed {
+		report.Removed = append(report.Removed, image.Key)
+		report.FreedBytes += image.Size
+	}
+	for _, image := range images {
+		if counts[image.Key] > 0 {
+			report.Referenced++
+		}
+	}
+	report.Waiting = len(images) - report.Referenced - len(removed)
+	if dryRun {
+		return report, nil
+	}
+
+	for i := range changed {
+		if err := l.db.Image.SaveImage(&changed[i]); err != nil {
+			return nil, errors.Wrapf(err, "while saving image %s", changed[i].Key)
+		}
+	}
+	for i := range removed {
+		if err := l.remove(&removed[i]); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// remove deletes the image unless it was uploaded again since the collection read it
+func (l *ImageLogic) remove(image *internal.Image) error {
+	unlock, err := l.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, err := l.db.Image.GetByKey(image.Key)
+	if err != nil {
+		return err
+	}
+	if current == nil || current.UnreferencedAt == nil || !current.UnreferencedAt.Equal(*image.UnreferencedAt) {
+		return nil
+	}
+
+	for _, variant := range media.Variants {
+		if err := l.blobs.Delete(media.VariantKey(image.Key, variant)); err != nil {
+			return errors.Wrapf(err, "while removing %s variant of image %s", variant.Name, image.Key)
+		}
+	}
+	if err := l.blobs.Delete(image.Key); err != nil {
+		return errors.Wrapf(err, "while removing image %s", image.Key)
+	}
+	if image.ID == 0 {
+		return nil
+	}
+	return errors.Wrapf(l.db.Image.RemoveImage(image), "while removing image %s", image.Key)
+}
+
+// ContentKey names the file after the SHA-256 of its content
+func ContentKey(data []byte, ext string) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]) + ext
+}
+
+// referencesByKey sums the references by the file name of their url, which is the key of the blob
+func referencesByKey(references map[string]int) map[string]int {
+	byKey := make(map[string]int, len(references))
+	for rawURL, count := range references {
+		if parsed, err := url.Parse(rawURL); err == nil {
+			rawURL = parsed.Path
+		}
+		byKey[path.Base(rawURL)] += count
+	}
+	return byKey
+}
+
+// untrackedImages returns the stored blobs without an image record, variants are not images on their own
+func untrackedImages(images []internal.Image, blobs []storage.BlobInfo) []internal.Image {
+	known := make(map[string]bool, len(images))
+	for _, image := range images {
+		known[image.Key] = true
+	}
+	untracked := make([]internal.Image, 0)
+	for _, blob := range blobs {
+		if known[blob.Key] || strings.HasPrefix(blob.Key, media.VariantsPrefix) {
+			continue
+		}
+		untracked = append(untracked, internal.Image{Key: blob.Key, Size: blob.Size, ContentType: blob.ContentType})
+	}
+	return untracked
+}
+
+// planCollection updates reference counts and returns the images to save and the ones to remove.
+// An image found unreferenced is kept for the grace period, as an upload is saved before the entity using it
+func planCollection(images []internal.Image, counts map[string]int, now time.Time, grace time.Duration) (changed, removed []internal.Image) {
+	for _, image := range images {
+		count := counts[image.Key]
+		switch {
+		case count > 0:
+			if image.ID != 0 && image.RefCount == count && image.UnreferencedAt == nil {
+				continue
+			}
+			image.RefCount, image.UnreferencedAt = count, nil
+		case image.UnreferencedAt == nil:
+			unreferencedAt := now
+			image.RefCount, image.UnreferencedAt = 0, &unreferencedAt
+		case now.Sub(*image.UnreferencedAt) >= grace:
+			removed = append(removed, image)
+			continue
+		case image.RefCount == 0:
+			continue
+		default:
+			image.RefCount = 0
+		}
+		changed = append(changed, image)
+	}
+	return changed, removed
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/media"
+	"github.com/best-project/api/internal/storage"
+	"gopkg.in/go-playground/assert.v1"
+	"testing"
+	"time"
+)
+
+func TestPlanCollection(t *testing.T) {
+	now := time.Date(2019, 12, 10, 12, 0, 0, 0, time.UTC)
+	grace := 24 * time.Hour
+	recently, longAgo := now.Add(-time.Hour), now.Add(-48*time.Hour)
+
+	images := []internal.Image{
+		{ID: 1, Key: "used.png", RefCount: 2},
+		{ID: 2, Key: "recounted.png", RefCount: 1},
+		{ID: 3, Key: "reused.png", UnreferencedAt: &longAgo},
+		{ID: 4, Key: "orphaned.png", RefCount: 1},
+		{ID: 5, Key: "waiting.png", UnreferencedAt: &recently},
+		{ID: 6, Key: "expired.png", Size: 10, UnreferencedAt: &longAgo},
+		{Key: "legacy.png"},
+	}
+	counts := map[string]int{"used.png": 2, "recounted.png": 3, "reused.png": 1}
+
+	changed, removed := planCollection(images, counts, now, grace)
+
+	keys := make([]string, 0, len(changed))
+	for _, image := range changed {
+		keys = append(keys, image.Key)
+	}
+	assert.Equal(t, keys, []string{"recounted.png", "reused.png", "orphaned.png", "legacy.png"})
+	assert.Equal(t, changed[0].RefCount, 3)
+	assert.Equal(t, changed[1].UnreferencedAt, (*time.Time)(nil))
+	assert.Equal(t, changed[2].RefCount, 0)
+	assert.Equal(t, *changed[2].UnreferencedAt, now)
+	assert.Equal(t, *changed[3].UnreferencedAt, now)
+
+	assert.Equal(t, len(removed), 1)
+	assert.Equal(t, removed[0].Key, "expired.png")
+	// the input is not modified
+	assert.Equal(t, images[3].RefCount, 1)
+}
+
+func TestUntrackedImages(t *testing.T) {
+	images := []internal.Image{{Key: "a.png"}}
+	blobs := []storage.BlobInfo{
+		{Key: "a.png"},
+		{Key: "b.jpg", Size: 7, ContentType: media.JPEGType},
+		{Key: media.VariantKey("a.png", media.Thumbnail)},
+	}
+
+	untracked := untrackedImages(images, blobs)
+
+	assert.Equal(t, untracked, []internal.Image{{Key: "b.jpg", Size: 7, ContentType: media.JPEGType}})
+}
+
+func TestContentKey(t *testing.T) {
+	first := ContentKey([]byte("same"), ".png")
+	second := ContentKey([]byte("same"), ".png")
+	other := ContentKey([]byte("other"), ".png")
+
+	assert.Equal(t, first, second)
+	assert.NotEqual(t, first, other)
+	assert.Equal(t, len(first), 64+len(".png"))
+}
+
+func TestReferencesByKey(t *testing.T) {
+	references := map[string]int{
+		"/images/a1b2.png":                        2,
+		"https://cdn.example.com/images/a1b2.png": 1,
+		"/images/c3d4.mp3?expires=10&signature=x": 1,
+	}
+
+	assert.Equal(t, referencesByKey(references), map[string]int{"a1b2.png": 3, "c3d4.mp3": 1})
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"github.com/best-project/api/internal/media"
+	"github.com/best-project/api/internal/storage"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// ImportLogic copies images linked from other sites into the blob store, such links expire or change
+type ImportLogic struct {
+	db      *storage.Database
+	images  *ImageLogic
+	fetcher *media.Fetcher
+}
+
+// ImportFailure is a task whose image could not be imported, it is tried again on the next run
+type ImportFailure struct {
+	TaskID uint
+	URL    string
+	Reason string
+}
+
+type ImportReport struct {
+	Imported int
+	Failed   []ImportFailure
+}
+
+func NewImportLogic(db *storage.Database, images *ImageLogic, fetcher *media.Fetcher) *ImportLogic {
+	return &ImportLogic{
+		db:      db,
+		images:  images,
+		fetcher: fetcher,
+	}
+}
+
+// Import fetches the image and returns its local url. Only absolute http(s) urls of other sites are accepted,
+// local blob urls come from uploads so a task cannot point to an image of a private course
+func (l *ImportLogic) Import(ctx context.Context, rawURL string) (string, error) {
+	if !isRemoteImage(rawURL, l.images.URL("")) {
+		return "", errors.Wrapf(media.ErrBlockedAddress, "%s is not a remote image", rawURL)
+	}
+	img, err := l.fetcher.Fetch(ctx, rawURL)
+	if err != nil {
+		return "", errors.Wrapf(err, "while fetching %s", rawURL)
+	}
+	key, _, err := l.images.Store(img)
+	if err != nil {
+		return "", err
+	}
+	return l.images.URL(key), nil
+}
+
+// ImportTaskImages rewrites remote task images to local ones, a failed fetch does not stop the others
+func (l *ImportLogic) ImportTaskImages(ctx context.Context) (*ImportReport, error) {
+	tasks, err := l.db.Task.ListWithRemoteImages()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ImportReport{}
+	for i := range tasks {
+		task := &tasks[i]
+		if !isRemoteImage(task.Image, l.images.URL("")) {
+			continue
+		}
+		local, err := l.Import(ctx, task.Image)
+		if err != nil {
+			if !media.Rejected(err) {
+				return report, err
+			}
+			report.Failed = append(report.Failed, ImportFailure{TaskID: task.ID, URL: task.Image, Reason: err.Error()})
+			continue
+		}
+		replaced, err := l.db.Task.ReplaceImage(task.ID, task.Image, local)
+		if err != nil {
+			return report, err
+		}
+		if !replaced {
+			// the imported copy is collected with the other unreferenced images
+			report.Failed = append(report.Failed, ImportFailure{TaskID: task.ID, URL: task.Image, Reason: "task was edited during the import"})
+			continue
+		}
+		report.Imported++
+	}
+	return report, nil
+}
+
+// isRemoteImage tells if the image is an absolute url of another site
+func isRemoteImage(image, localPrefix string) bool {
+	if !strings.HasPrefix(image, "http://") && !strings.HasPrefix(image, "https://") {
+		return false
+	}
+	return localPrefix == "" || !strings.HasPrefix(image, localPrefix)
+}
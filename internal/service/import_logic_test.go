@@ -0,0 +1,15 @@
+package service
+
+import (
+	"gopkg.in/go-playground/assert.v1"
+	"testing"
+)
+
+func TestIsRemoteImage(t *testing.T) {
+	assert.Equal(t, isRemoteImage("https://pixabay.com/get/54e6dc.jpg", "/images/"), true)
+	assert.Equal(t, isRemoteImage("http://example.com/a.png", "https://cdn.example.com/"), true)
+	assert.Equal(t, isRemoteImage("https://cdn.example.com/a.png", "https://cdn.example.com/"), false)
+	assert.Equal(t, isRemoteImage("/images/a.png", "/images/"), false)
+	assert.Equal(t, isRemoteImage("", "/images/"), false)
+	assert.Equal(t, isRemoteImage("ftp://example.com/a.png", "/images/"), false)
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"fmt"
+	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/event"
+	"github.com/best-project/api/internal/storage"
+	"github.com/pkg/errors"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrUnknownWindow = errors.New("unknown leaderboard window")
+	ErrUnknownScope  = errors.New("unknown leaderboard scope")
+	// ErrScopeNotSupported is returned for group scopes without a registered resolver
+	ErrScopeNotSupported = errors.New("leaderboard scope is not supported")
+)
+
+// GroupResolver returns members of the group, e.g. friends of the user or a classroom
+type GroupResolver func(userID uint, groupID string) ([]uint, error)
+
+type LeaderboardQuery struct {
+	Window  string
+	Scope   string
+	ScopeID string
+	UserID  uint
+	Page    int
+	Limit   int
+	// AroundMe returns the page with the user in the middle instead of the requested page
+	AroundMe bool
+}
+
+type RankedScore struct {
+	internal.ScoreAggregate
+	Rank int
+}
+
+type Leaderboard struct {
+	storage.Board
+	Total   int
+	Entries []RankedScore
+	Me      *RankedScore
+}
+
+type LeaderboardLogic struct {
+	db     *storage.Database
+	groups map[string]GroupResolver
+	now    func() time.Time
+}
+
+type LeaderboardHandler interface {
+	Board(query LeaderboardQuery) (*Leaderboard, error)
+}
+
+func NewLeaderboardLogic(db *storage.Database) *LeaderboardLogic {
+	return &LeaderboardLogic{
+		db:     db,
+		groups: make(map[string]GroupResolver),
+		now:    time.Now,
+	}
+}
+
+// Subscribe keeps the aggregates up to date with earned points
+func (l *LeaderboardLogic) Subscribe(bus *event.Bus) {
+	bus.Subscribe(event.XPEarned, l.record)
+}
+
+// RegisterGroup enables a scope which ranks a group of users by their global points
+func (l *LeaderboardLogic) RegisterGroup(scope string, resolver GroupResolver) {
+	l.groups[scope] = resolver
+}
+
+func (l *LeaderboardLogic) Board(query LeaderboardQuery) (*Leaderboard, error) {
+	period, err := PeriodFor(query.Window, l.now())
+	if err != nil {
+		return nil, err
+	}
+	board := storage.Board{Window: query.Window, Period: period, Scope: query.Scope, ScopeID: query.ScopeID}
+
+	var users []uint
+	switch query.Scope {
+	case internal.GlobalScope:
+		board.ScopeID = ""
+	case internal.CourseScope, internal.LanguageScope:
+	case internal.FriendsScope, internal.ClassroomScope:
+		resolver, ok := l.groups[query.Scope]
+		if !ok {
+			return nil, ErrScopeNotSupported
+		}
+		if users, err = resolver(query.UserID, query.ScopeID); err != nil {
+			return nil, errors.Wrapf(err, "while resolving %s group", query.Scope)
+		}
+		board.Scope, board.ScopeID = internal.GlobalScope, ""
+	default:
+		return nil, ErrUnknownScope
+	}
+
+	result := &Leaderboard{Board: board}
+	result.Scope, result.ScopeID = query.Scope, query.ScopeID
+	if result.Total, err = l.db.Score.Count(board, users); err != nil {
+		return nil, err
+	}
+
+	offset := (query.Page - 1) * query.Limit
+	if result.Me, err = l.rankOf(board, users, query.UserID); err != nil {
+		return nil, err
+	}
+	if query.AroundMe && result.Me != nil {
+		offset = result.Me.Rank - 1 - query.Limit/2
+		if offset < 0 {
+			offset = 0
+		}
+	}
+
+	scores, err := l.db.Score.ListTop(board, users, offset, query.Limit)
+	if err != nil {
+		return nil, err
+	}
+	result.Entries = make([]RankedScore, 0, len(scores))
+	for i, score := range scores {
+		result.Entries = append(result.Entries, RankedScore{ScoreAggregate: score, Rank: offset + i + 1})
+	}
+
+	return result, nil
+}
+
+// BackfillAllTime fills the global all-time board with points users earned before the aggregates existed
+func (l *LeaderboardLogic) BackfillAllTime() error {
+	users, err := l.db.User.GetAll()
+	if err != nil {
+		return errors.Wrap(err, "while listing users")
+	}
+	board := storage.Board{Window: internal.AllWindow, Period: internal.AllWindow, Scope: internal.GlobalScope}
+	for _, user := range users {
+		if err := l.db.Score.SetXP(board, user.ID, user.Points); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *LeaderboardLogic) rankOf(board storage.Board, users []uint, userID uint) (*RankedScore, error) {
+	if users != nil && !containsID(users, userID) {
+		return nil, nil
+	}
+	score, err := l.db.Score.GetScore(board, userID)
+	if err != nil || score == nil {
+		return nil, err
+	}
+	ahead, err := l.db.Score.CountAhead(board, users, score)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RankedScore{ScoreAggregate: *score, Rank: ahead + 1}, nil
+}
+
+func (l *LeaderboardLogic) record(e event.Event) error {
+	scopes := [][2]string{{internal.GlobalScope, ""}}
+	if e.CourseID != 0 {
+		course, err := l.db.Course.GetByID(strconv.Itoa(int(e.CourseID)))
+		if err != nil {
+			return errors.Wrapf(err, "while getting course %d", e.CourseID)
+		}
+		scopes = append(scopes, [2]string{internal.CourseScope, strconv.Itoa(int(course.ID))})
+		if course.Language != "" {
+			scopes = append(scopes, [2]string{internal.LanguageScope, course.Language})
+		}
+	}
+
+	for _, window := range []string{internal.DayWindow, internal.WeekWindow, internal.MonthWindow, internal.AllWindow} {
+		period, _ := PeriodFor(window, e.Time)
+		for _, scope := range scopes {
+			board := storage.Board{Window: window, Period: period, Scope: scope[0], ScopeID: scope[1]}
+			if err := l.db.Score.AddXP(board, e.UserID, int(e.Points)); err != nil {
+				return errors.Wrapf(err, "while saving %s %s score", window, scope[0])
+			}
+		}
+	}
+	return nil
+}
+
+// PeriodFor identifies the UTC day, ISO week or month containing t
+func PeriodFor(window string, t time.Time) (string, error) {
+	t = t.UTC()
+	switch window {
+	case internal.DayWindow:
+		return t.Format(internal.DayLayout), nil
+	case internal.WeekWindow:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week), nil
+	case internal.MonthWindow:
+		return t.Format("2006-01"), nil
+	case internal.AllWindow:
+		return internal.AllWindow, nil
+	default:
+		return "", ErrUnknownWindow
+	}
+}
+
+func containsID(ids []uint, id uint) bool {
+	for _, item := range ids {
+		if item == id {
+			return true
+		}
+	}
+	return false
+}
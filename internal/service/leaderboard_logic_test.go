@@ -0,0 +1,28 @@
+package service
+
+import (
+	"github.com/best-project/api/internal"
+	"gopkg.in/go-playground/assert.v1"
+	"testing"
+	"time"
+)
+
+func TestPeriodFor(t *testing.T) {
+	warsaw, _ := time.LoadLocation("Europe/Warsaw")
+	// 2021-01-01 00:30 in Warsaw is still 2020-12-31 in UTC, in the 53rd ISO week of 2020
+	moment := time.Date(2021, 1, 1, 0, 30, 0, 0, warsaw)
+
+	for window, expected := range map[string]string{
+		internal.DayWindow:   "2020-12-31",
+		internal.WeekWindow:  "2020-W53",
+		internal.MonthWindow: "2020-12",
+		internal.AllWindow:   "all",
+	} {
+		period, err := PeriodFor(window, moment)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, period, expected)
+	}
+
+	_, err := PeriodFor("year", moment)
+	assert.Equal(t, err, ErrUnknownWindow)
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"fmt"
+	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/event"
+	"github.com/best-project/api/internal/storage"
+	"github.com/pkg/errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// leagueLockTimeout bounds the wait for another instance joining users or closing weeks
+const leagueLockTimeout = 10 * time.Second
+
+// LeagueSettings configure weekly leagues. The week ends every CutoffDay at CutoffHour UTC.
+type LeagueSettings struct {
+	CohortSize int
+	Tiers      int
+	Promote    int
+	Relegate   int
+	CutoffDay  time.Weekday
+	CutoffHour int
+}
+
+type LeagueLogic struct {
+	db       *storage.Database
+	settings LeagueSettings
+	now      func() time.Time
+}
+
+type LeagueHandler interface {
+	Current(user *internal.User) (*internal.League, []internal.LeagueMember, error)
+	History(userID uint) ([]internal.LeagueMember, map[uint]internal.League, error)
+	Settings() LeagueSettings
+}
+
+func NewLeagueLogic(db *storage.Database, settings LeagueSettings) *LeagueLogic {
+	return &LeagueLogic{
+		db:       db,
+		settings: settings,
+		now:      time.Now,
+	}
+}
+
+// ParseWeekday parses english day names, e.g. sunday
+func ParseWeekday(name string) (time.Weekday, error) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(day.String(), name) {
+			return day, nil
+		}
+	}
+	return time.Sunday, fmt.Errorf("unknown week day %s", name)
+}
+
+// Subscribe counts earned points towards the league of the current week
+func (l *LeagueLogic) Subscribe(bus *event.Bus) {
+	bus.Subscribe(event.XPEarned, l.recordXP)
+}
+
+func (l *LeagueLogic) Settings() LeagueSettings {
+	return l.settings
+}
+
+// Current returns the league of the user for the current week, the league has no ID
+// when the user has not earned any points this week
+func (l *LeagueLogic) Current(user *internal.User) (*internal.League, []internal.LeagueMember, error) {
+	week := WeekStart(l.now(), l.settings.CutoffDay, l.settings.CutoffHour)
+	member, err := l.db.League.FindMember(user.ID, week)
+	if err != nil {
+		return nil, nil, err
+	}
+	if member == nil {
+		return &internal.League{Tier: user.LeagueTier, Week: week}, []internal.LeagueMember{}, nil
+	}
+
+	league, err := l.db.League.GetByID(member.LeagueID)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "while getting league %d", member.LeagueID)
+	}
+	members, err := l.db.League.ListMembers(league.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range members {
+		members[i].Rank = i + 1
+	}
+
+	return league, members, nil
+}
+
+// History returns closed leagues of the user, the latest first
+func (l *LeagueLogic) History(userID uint) ([]internal.LeagueMember, map[uint]internal.League, error) {
+	members, err := l.db.League.ListHistoryForUser(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	ids := make([]uint, 0, len(members))
+	for _, member := range members {
+		ids = append(ids, member.LeagueID)
+	}
+	leagues, err := l.db.League.GetManyByID(ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	byID := make(map[uint]internal.League, len(leagues))
+	for _, league := range leagues {
+		byID[league.ID] = league
+	}
+
+	return members, byID, nil
+}
+
+// CloseWeeks ranks leagues of the finished weeks and moves users between tiers
+func (l *LeagueLogic) CloseWeeks(now time.Time) error {
+	unlock, err := l.db.League.Lock(leagueLockTimeout)
+	if err != nil {
+		return errors.Wrap(err, "while locking leagues")
+	}
+	defer unlock()
+
+	week := WeekStart(now, l.settings.CutoffDay, l.settings.CutoffHour)
+	leagues, err := l.db.League.ListOpenBefore(week)
+	if err != nil {
+		return err
+	}
+
+	for i := range leagues {
+		if err := l.closeLeague(&leagues[i]); err != nil {
+			return errors.Wrapf(err, "while closing league %d", leagues[i].ID)
+		}
+	}
+	return nil
+}
+
+func (l *LeagueLogic) closeLeague(league *internal.League) error {
+	members, err := l.db.League.ListMembers(league.ID)
+	if err != nil {
+		return err
+	}
+
+	rankMembers(members, league.Tier, l.settings)
+	for i := range members {
+		member := &members[i]
+		if err := l.db.League.SaveRanking(member); err != nil {
+			return err
+		}
+		if member.Result == internal.StayedResult {
+			continue
+		}
+
+		user, err := l.db.User.GetByID(member.UserID)
+		if err != nil {
+			return errors.Wrapf(err, "while getting user %d", member.UserID)
+		}
+		user.LeagueTier = league.Tier + 1
+		if member.Result == internal.RelegatedResult {
+			user.LeagueTier = league.Tier - 1
+		}
+		if err := l.db.User.SaveUser(user); err != nil {
+			return errors.Wrapf(err, "while saving user %d", user.ID)
+		}
+	}
+
+	league.Closed = true
+	return l.db.League.SaveLeague(league)
+}
+
+func (l *LeagueLogic) recordXP(e event.Event) error {
+	week := WeekStart(e.Time, l.settings.CutoffDay, l.settings.CutoffHour)
+	member, err := l.db.League.FindMember(e.UserID, week)
+	if err != nil {
+		return err
+	}
+	if member == nil {
+		if member, err = l.join(e.UserID, week); err != nil {
+			return errors.Wrapf(err, "while joining league of user %d", e.UserID)
+		}
+	}
+
+	return l.db.League.AddXP(member.ID, int(e.Points))
+}
+
+// join puts the user into a cohort of the user tier which is not full yet, the lock is shared
+// by the instances so the user joins once and cohorts do not grow past their size
+func (l *LeagueLogic) join(userID uint, week time.Time) (*internal.LeagueMember, error) {
+	unlock, err := l.db.League.Lock(leagueLockTimeout)
+	if err != nil {
+		return nil, errors.Wrap(err, "while locking leagues")
+	}
+	defer unlock()
+
+	// another instance could have joined the user while this one waited for the lock
+	member, err := l.db.League.FindMember(userID, week)
+	if err != nil || member != nil {
+		return member, err
+	}
+	user, err := l.db.User.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	league, err := l.db.League.FindOpen(user.LeagueTier, week, l.settings.CohortSize)
+	if err != nil {
+		return nil, err
+	}
+	if league == nil {
+		league = &internal.League{Tier: user.LeagueTier, Week: week}
+		if err := l.db.League.CreateLeague(league); err != nil {
+			return nil, errors.Wrap(err, "while creating league")
+		}
+	}
+
+	member = &internal.LeagueMember{LeagueID: league.ID, UserID: userID}
+	if err := l.db.League.SaveMember(member); err != nil {
+		return nil, errors.Wrap(err, "while saving league member")
+	}
+	return member, nil
+}
+
+// rankMembers sorts members by points and marks the promoted and relegated ones.
+// Nobody is promoted from the highest tier nor relegated from the lowest one.
+func rankMembers(members []internal.LeagueMember, tier int, settings LeagueSettings) {
+	sort.SliceStable(members, func(i, j int) bool {
+		return members[i].XP > members[j].XP
+	})
+
+	for i := range members {
+		members[i].Rank = i + 1
+		switch {
+		case i < settings.Promote && tier < settings.Tiers-1:
+			members[i].Result = internal.PromotedResult
+		case i >= len(members)-settings.Relegate && i >= settings.Promote && tier > 0:
+			members[i].Result = internal.RelegatedResult
+		default:
+			members[i].Result = internal.StayedResult
+		}
+	}
+}
+
+// WeekStart returns the last weekly cut-off which is not after now
+func WeekStart(now time.Time, cutoffDay time.Weekday, cutoffHour int) time.Time {
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), now.Day(), cutoffHour, 0, 0, 0, time.UTC)
+	start = start.AddDate(0, 0, -((int(now.Weekday()) - int(cutoffDay) + 7) % 7))
+	if start.After(now) {
+		start = start.AddDate(0, 0, -7)
+	}
+	return start
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"github.com/best-project/api/internal"
+	"gopkg.in/go-playground/assert.v1"
+	"testing"
+	"time"
+)
+
+func TestWeekStart(t *testing.T) {
+	// 2020-03-01 is a Sunday
+	cutoff := time.Date(2020, 3, 1, 20, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, WeekStart(cutoff, time.Sunday, 20), cutoff)
+	assert.Equal(t, WeekStart(cutoff.Add(-time.Minute), time.Sunday, 20), cutoff.AddDate(0, 0, -7))
+	assert.Equal(t, WeekStart(cutoff.AddDate(0, 0, 3), time.Sunday, 20), cutoff)
+	assert.Equal(t, WeekStart(cutoff.AddDate(0, 0, 7), time.Sunday, 20), cutoff.AddDate(0, 0, 7))
+
+	warsaw, _ := time.LoadLocation("Europe/Warsaw")
+	assert.Equal(t, WeekStart(time.Date(2020, 3, 2, 0, 30, 0, 0, warsaw), time.Sunday, 20), cutoff)
+}
+
+func TestRankMembers(t *testing.T) {
+	settings := LeagueSettings{Tiers: 3, Promote: 2, Relegate: 2}
+	members := func() []internal.LeagueMember {
+		return []internal.LeagueMember{{UserID: 1, XP: 10}, {UserID: 2, XP: 50}, {UserID: 3, XP: 30}, {UserID: 4, XP: 5}, {UserID: 5, XP: 20}}
+	}
+
+	middle := members()
+	rankMembers(middle, 1, settings)
+	assert.Equal(t, middle[0].UserID, uint(2))
+	assert.Equal(t, middle[0].Rank, 1)
+	assert.Equal(t, middle[0].Result, internal.PromotedResult)
+	assert.Equal(t, middle[1].Result, internal.PromotedResult)
+	assert.Equal(t, middle[2].Result, internal.StayedResult)
+	assert.Equal(t, middle[3].Result, internal.RelegatedResult)
+	assert.Equal(t, middle[4].UserID, uint(4))
+	assert.Equal(t, middle[4].Result, internal.RelegatedResult)
+
+	lowest := members()
+	rankMembers(lowest, 0, settings)
+	assert.Equal(t, lowest[4].Result, internal.StayedResult)
+
+	highest := members()
+	rankMembers(highest, 2, settings)
+	assert.Equal(t, highest[0].Result, internal.StayedResult)
+	assert.Equal(t, highest[4].Result, internal.RelegatedResult)
+
+	small := members()[:3]
+	rankMembers(small, 1, settings)
+	assert.Equal(t, small[1].Result, internal.PromotedResult)
+	assert.Equal(t, small[2].Result, internal.RelegatedResult)
+}
+
+func TestParseWeekday(t *testing.T) {
+	day, err := ParseWeekday("Monday")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, day, time.Monday)
+
+	_, err = ParseWeekday("someday")
+	assert.NotEqual(t, err, nil)
+}
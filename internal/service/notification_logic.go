@@ -0,0 +1,170 @@
+package service
+
+import (
+	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/event"
+	"github.com/best-project/api/internal/storage"
+	"github.com/pkg/errors"
+	"sync"
+)
+
+var ErrUnknownNotificationType = errors.New("unknown notification type")
+
+type NotificationLogic struct {
+	db *storage.Database
+
+	// mu serializes upserts of preferences
+	mu sync.Mutex
+}
+
+type NotificationHandler interface {
+	List(userID uint, unreadOnly bool, page, limit int) ([]internal.Notification, int, error)
+	MarkRead(userID uint, ids []uint) error
+	MarkAllRead(userID uint) error
+	Preferences(userID uint) (map[string]bool, error)
+	SetPreferences(userID uint, preferences map[string]bool) (map[string]bool, error)
+}
+
+func NewNotificationLogic(db *storage.Database) *NotificationLogic {
+	return &NotificationLogic{
+		db: db,
+	}
+}
+
+// Subscribe creates notifications for the users affected by the events
+func (n *NotificationLogic) Subscribe(bus *event.Bus) {
+	bus.Subscribe(event.RatingReceived, n.notify)
+	bus.Subscribe(event.AssignmentCreated, n.notify)
+	bus.Subscribe(event.BadgeEarned, n.notify)
+	bus.Subscribe(event.ChallengeReceived, n.notify)
+}
+
+// List returns a page of notifications, the latest first, with the number of all unread ones
+func (n *NotificationLogic) List(userID uint, unreadOnly bool, page, limit int) ([]internal.Notification, int, error) {
+	notifications, err := n.db.Notification.ListForUser(userID, unreadOnly, (page-1)*limit, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	unread, err := n.db.Notification.CountUnread(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return notifications, unread, nil
+}
+
+func (n *NotificationLogic) MarkRead(userID uint, ids []uint) error {
+	return n.db.Notification.MarkRead(userID, ids)
+}
+
+func (n *NotificationLogic) MarkAllRead(userID uint) error {
+	return n.db.Notification.MarkAllRead(userID)
+}
+
+// Preferences returns every notification type with its state, types not changed by the user are enabled
+func (n *NotificationLogic) Preferences(userID uint) (map[string]bool, error) {
+	stored, err := n.db.Notification.ListPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return mergePreferences(stored), nil
+}
+
+// SetPreferences changes only the given types, the whole set is returned
+func (n *NotificationLogic) SetPreferences(userID uint, preferences map[string]bool) (map[string]bool, error) {
+	for kind := range preferences {
+		if !isNotificationType(kind) {
+			return nil, errors.Wrap(ErrUnknownNotificationType, kind)
+		}
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	stored, err := n.db.Notification.ListPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+	byType := make(map[string]internal.NotificationPreference, len(stored))
+	for _, preference := range stored {
+		byType[preference.Type] = preference
+	}
+	for kind, enabled := range preferences {
+		preference, ok := byType[kind]
+		if !ok {
+			preference = internal.NotificationPreference{UserID: userID, Type: kind}
+		}
+		preference.Enabled = enabled
+		if err := n.db.Notification.SavePreference(&preference); err != nil {
+			return nil, errors.Wrapf(err, "while saving %s notification preference", kind)
+		}
+		byType[kind] = preference
+	}
+
+	result := make([]internal.NotificationPreference, 0, len(byType))
+	for _, preference := range byType {
+		result = append(result, preference)
+	}
+	return mergePreferences(result), nil
+}
+
+func (n *NotificationLogic) notify(e event.Event) error {
+	notification := notificationFor(e)
+	if notification == nil {
+		return nil
+	}
+	preferences, err := n.Preferences(e.UserID)
+	if err != nil {
+		return errors.Wrapf(err, "while getting notification preferences of user %d", e.UserID)
+	}
+	if !preferences[notification.Type] {
+		return nil
+	}
+
+	return errors.Wrap(n.db.Notification.SaveNotification(notification), "while saving notification")
+}
+
+// notificationFor returns the notification describing the event, nil when the user is not notified about it
+func notificationFor(e event.Event) *internal.Notification {
+	// users are not told about what they did themselves, e.g. rating their own course
+	if e.ActorID != 0 && e.ActorID == e.UserID {
+		return nil
+	}
+	notification := &internal.Notification{UserID: e.UserID, ActorID: e.ActorID, CourseID: e.CourseID, RefID: e.RefID}
+	switch e.Type {
+	case event.RatingReceived:
+		notification.Type, notification.Value = internal.RatingNotification, e.Value
+	case event.AssignmentCreated:
+		notification.Type = internal.AssignmentNotification
+	case event.BadgeEarned:
+		notification.Type, notification.Name = internal.BadgeNotification, e.Name
+	case event.ChallengeReceived:
+		notification.Type = internal.ChallengeNotification
+	default:
+		return nil
+	}
+	return notification
+}
+
+func mergePreferences(stored []internal.NotificationPreference) map[string]bool {
+	result := make(map[string]bool, len(internal.NotificationTypes))
+	for _, kind := range internal.NotificationTypes {
+		result[kind] = true
+	}
+	for _, preference := range stored {
+		if _, ok := result[preference.Type]; ok {
+			result[preference.Type] = preference.Enabled
+		}
+	}
+	return result
+}
+
+func isNotificationType(kind string) bool {
+	for _, known := range internal.NotificationTypes {
+		if known == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/event"
+	"gopkg.in/go-playground/assert.v1"
+	"testing"
+)
+
+func TestNotificationFor(t *testing.T) {
+	assert.Equal(t, notificationFor(event.Event{Type: event.XPEarned, UserID: 1}), (*internal.Notification)(nil))
+	assert.Equal(t, notificationFor(event.Event{Type: event.RatingReceived, UserID: 1, ActorID: 1, Value: 5}), (*internal.Notification)(nil))
+
+	notification := notificationFor(event.Event{Type: event.RatingReceived, UserID: 1, ActorID: 2, CourseID: 3, Value: 4})
+	assert.Equal(t, notification.Type, internal.RatingNotification)
+	assert.Equal(t, notification.ActorID, uint(2))
+	assert.Equal(t, notification.Value, float32(4))
+
+	notification = notificationFor(event.Event{Type: event.AssignmentCreated, UserID: 1, ActorID: 2, CourseID: 3, RefID: 4})
+	assert.Equal(t, notification.Type, internal.AssignmentNotification)
+	assert.Equal(t, notification.RefID, uint(4))
+
+	notification = notificationFor(event.Event{Type: event.BadgeEarned, UserID: 1, Name: "first-course"})
+	assert.Equal(t, notification.Type, internal.BadgeNotification)
+	assert.Equal(t, notification.Name, "first-course")
+}
+
+func TestMergePreferences(t *testing.T) {
+	preferences := mergePreferences([]internal.NotificationPreference{
+		{Type: internal.BadgeNotification, Enabled: false},
+		{Type: internal.RatingNotification, Enabled: true},
+		{Type: "removed", Enabled: true},
+	})
+
+	assert.Equal(t, preferences, map[string]bool{
+		internal.RatingNotification:     true,
+		internal.AssignmentNotification: true,
+		internal.BadgeNotification:      false,
+		internal.ChallengeNotification:  true,
+	})
+}
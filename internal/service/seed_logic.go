@@ -0,0 +1,298 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/storage"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Fixtures are the users and courses loaded by the seed command, courses name their author by email
+type Fixtures struct {
+	Users   []UserFixture   `json:"users"`
+	Courses []CourseFixture `json:"courses"`
+}
+
+type UserFixture struct {
+	Email     string `json:"email"`
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	Avatar    string `json:"avatar"`
+	// Password is usually left out, the default password is used then
+	Password string `json:"password"`
+}
+
+type CourseFixture struct {
+	Name            string        `json:"name"`
+	Author          string        `json:"author"`
+	Type            string        `json:"type"`
+	Language        string        `json:"language"`
+	DifficultyLevel string        `json:"difficultyLevel"`
+	Rate            float32       `json:"rate"`
+	Description     string        `json:"description"`
+	Image           string        `json:"image"`
+	Private         bool          `json:"private"`
+	Tasks           []TaskFixture `json:"tasks"`
+}
+
+type TaskFixture struct {
+	Word      string `json:"word"`
+	Translate string `json:"translate"`
+	Image     string `json:"image"`
+	Audio     string `json:"audio"`
+}
+
+type SeedReport struct {
+	Users          int
+	Courses        int
+	SkippedUsers   int
+	SkippedCourses int
+	// GeneratedPassword is set when users got a generated password, it is shown only this once
+	GeneratedPassword string
+	GeneratedFor      []string
+}
+
+// SeedLogic inserts fixtures which are not in the database yet, users are matched by email
+// and courses by author and name so seeding again adds only what is new
+type SeedLogic struct {
+	db       *storage.Database
+	xp       XPRules
+	password string
+}
+
+// NewSeedLogic takes the password of users without one in the fixtures, a random one is generated when empty
+func NewSeedLogic(db *storage.Database, xp XPRules, password string) *SeedLogic {
+	return &SeedLogic{
+		db:       db,
+		xp:       xp,
+		password: password,
+	}
+}
+
+// LoadFixtures reads YAML or JSON files, directories are read in the order of file names
+func LoadFixtures(paths ...string) (*Fixtures, error) {
+	files, err := fixtureFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+	fixtures := &Fixtures{}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "while reading fixtures file %s", file)
+		}
+		loaded := Fixtures{}
+		if err := yaml.Unmarshal(data, &loaded); err != nil {
+			return nil, errors.Wrapf(err, "while decoding fixtures file %s", file)
+		}
+		fixtures.Users = append(fixtures.Users, loaded.Users...)
+		fixtures.Courses = append(fixtures.Courses, loaded.Courses...)
+	}
+	if err := validateFixtures(fixtures); err != nil {
+		return nil, err
+	}
+	return fixtures, nil
+}
+
+func (s *SeedLogic) Seed(fixtures *Fixtures) (*SeedReport, error) {
+	report := &SeedReport{}
+	authors := make(map[string]uint)
+	for _, fixture := range fixtures.Users {
+		id, created, err := s.seedUser(fixture, report)
+		if err != nil {
+			return report, err
+		}
+		authors[strings.ToLower(fixture.Email)] = id
+		if created {
+			report.Users++
+		} else {
+			report.SkippedUsers++
+		}
+	}
+
+	for _, fixture := range fixtures.Courses {
+		authorID, ok := authors[strings.ToLower(fixture.Author)]
+		if !ok {
+			users, err := s.db.User.GetByMail(fixture.Author)
+			if err != nil || len(users) == 0 {
+				return report, errors.Errorf("author %s of course %s is neither in the fixtures nor in the database", fixture.Author, fixture.Name)
+			}
+			authorID = users[0].ID
+		}
+		created, err := s.seedCourse(fixture, authorID)
+		if err != nil {
+			return report, err
+		}
+		if created {
+			report.Courses++
+		} else {
+			report.SkippedCourses++
+		}
+	}
+	return report, nil
+}
+
+func (s *SeedLogic) seedUser(fixture UserFixture, report *SeedReport) (uint, bool, error) {
+	existing, err := s.db.User.GetByMail(fixture.Email)
+	if err != nil {
+		return 0, false, errors.Wrapf(err, "while getting user %s", fixture.Email)
+	}
+	if len(existing) > 0 {
+		return existing[0].ID, false, nil
+	}
+
+	password := fixture.Password
+	if password == "" {
+		if password, err = s.defaultPassword(report); err != nil {
+			return 0, false, err
+		}
+		if s.password == "" {
+			report.GeneratedFor = append(report.GeneratedFor, fixture.Email)
+		}
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return 0, false, errors.Wrap(err, "while hashing password")
+	}
+	user := &internal.User{
+		Email:     fixture.Email,
+		FirstName: fixture.FirstName,
+		LastName:  fixture.LastName,
+		Avatar:    fixture.Avatar,
+		Password:  string(hash),
+		Level:     1,
+		NextLevel: "0.00",
+	}
+	if err := s.db.User.SaveUser(user); err != nil {
+		return 0, false, errors.Wrapf(err, "while saving user %s", fixture.Email)
+	}
+	return user.ID, true, nil
+}
+
+// defaultPassword generates the password on first use so it is the same for all users of one run
+func (s *SeedLogic) defaultPassword(report *SeedReport) (string, error) {
+	if s.password != "" {
+		return s.password, nil
+	}
+	if report.GeneratedPassword == "" {
+		random := make([]byte, 12)
+		if _, err := rand.Read(random); err != nil {
+			return "", errors.Wrap(err, "while generating password")
+		}
+		report.GeneratedPassword = base64.RawURLEncoding.EncodeToString(random)
+	}
+	return report.GeneratedPassword, nil
+}
+
+func (s *SeedLogic) seedCourse(fixture CourseFixture, authorID uint) (bool, error) {
+	courses, err := s.db.Course.GetByUserID(authorID)
+	if err != nil {
+		return false, err
+	}
+	for _, course := range courses {
+		if course.Name == fixture.Name {
+			return false, nil
+		}
+	}
+
+	course := courseFromFixture(fixture, authorID)
+	if err := s.db.Course.SaveCourse(course, s.xp.ForTask(course.Type)); err != nil {
+		return false, errors.Wrapf(err, "while saving course %s", fixture.Name)
+	}
+	return true, nil
+}
+
+func courseFromFixture(fixture CourseFixture, authorID uint) *internal.Course {
+	course := &internal.Course{
+		UserID:          authorID,
+		Name:            fixture.Name,
+		Type:            fixture.Type,
+		Language:        fixture.Language,
+		DifficultyLevel: fixture.DifficultyLevel,
+		Rate:            fixture.Rate,
+		Description:     fixture.Description,
+		Image:           fixture.Image,
+		Private:         fixture.Private,
+		Task:            make([]internal.Task, 0, len(fixture.Tasks)),
+	}
+	if course.Type == "" {
+		course.Type = internal.NormalType
+	}
+	for _, task := range fixture.Tasks {
+		course.Task = append(course.Task, internal.Task{
+			Word:      task.Word,
+			Translate: task.Translate,
+			Image:     task.Image,
+			Audio:     task.Audio,
+		})
+	}
+	return course
+}
+
+func validateFixtures(fixtures *Fixtures) error {
+	emails := make(map[string]bool)
+	for _, user := range fixtures.Users {
+		email := strings.ToLower(user.Email)
+		if email == "" || emails[email] {
+			return fmt.Errorf("user email %q is empty or not unique", user.Email)
+		}
+		emails[email] = true
+	}
+
+	courses := make(map[string]bool)
+	for _, course := range fixtures.Courses {
+		key := strings.ToLower(course.Author) + "\n" + course.Name
+		if course.Name == "" || course.Author == "" || courses[key] {
+			return fmt.Errorf("course %q of %q has no name or author or is not unique", course.Name, course.Author)
+		}
+		courses[key] = true
+		if course.Type != "" && course.Type != internal.NormalType && course.Type != internal.PuzzleType {
+			return fmt.Errorf("course %s: unknown type %s", course.Name, course.Type)
+		}
+		for i, task := range course.Tasks {
+			if task.Word == "" || task.Translate == "" {
+				return fmt.Errorf("course %s: task %d has no word or translation", course.Name, i+1)
+			}
+		}
+	}
+	return nil
+}
+
+func fixtureFiles(paths []string) ([]string, error) {
+	files := make([]string, 0)
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "while reading fixtures %s", path)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		entries, err := ioutil.ReadDir(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "while listing fixtures in %s", path)
+		}
+		names := make([]string, 0)
+		for _, entry := range entries {
+			switch strings.ToLower(filepath.Ext(entry.Name())) {
+			case ".yaml", ".yml", ".json":
+				if !entry.IsDir() {
+					names = append(names, filepath.Join(path, entry.Name()))
+				}
+			}
+		}
+		sort.Strings(names)
+		files = append(files, names...)
+	}
+	return files, nil
+}
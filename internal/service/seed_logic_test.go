@@ -0,0 +1,63 @@
+package service
+
+import (
+	"github.com/best-project/api/internal"
+	"gopkg.in/go-playground/assert.v1"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadFixturesFromRepository(t *testing.T) {
+	fixtures, err := LoadFixtures("../../fixtures")
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(fixtures.Users), 1)
+	assert.Equal(t, len(fixtures.Courses), 3)
+	assert.Equal(t, fixtures.Courses[0].Tasks[0].Word, "aisle")
+}
+
+func TestLoadFixturesMergesYAMLAndJSON(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fixtures")
+	assert.Equal(t, err, nil)
+	defer os.RemoveAll(dir)
+
+	write := func(name, content string) {
+		assert.Equal(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644), nil)
+	}
+	write("2-courses.json", `{"courses": [{"name": "Animals", "author": "a@b.pl", "tasks": [{"word": "cat", "translate": "kot"}]}]}`)
+	write("1-users.yaml", "users:\n  - email: a@b.pl\n")
+	write("notes.txt", "not a fixture")
+
+	fixtures, err := LoadFixtures(dir)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, fixtures.Users, []UserFixture{{Email: "a@b.pl"}})
+	assert.Equal(t, fixtures.Courses[0].Tasks, []TaskFixture{{Word: "cat", Translate: "kot"}})
+}
+
+func TestValidateFixtures(t *testing.T) {
+	task := TaskFixture{Word: "cat", Translate: "kot"}
+	invalid := []Fixtures{
+		{Users: []UserFixture{{Email: "a@b.pl"}, {Email: "A@b.pl"}}},
+		{Courses: []CourseFixture{{Name: "A", Author: "a@b.pl"}, {Name: "A", Author: "a@b.pl"}}},
+		{Courses: []CourseFixture{{Name: "A"}}},
+		{Courses: []CourseFixture{{Name: "A", Author: "a@b.pl", Type: "quiz"}}},
+		{Courses: []CourseFixture{{Name: "A", Author: "a@b.pl", Tasks: []TaskFixture{task, {Word: "dog"}}}}},
+	}
+	for i := range invalid {
+		assert.NotEqual(t, validateFixtures(&invalid[i]), nil)
+	}
+
+	valid := Fixtures{Courses: []CourseFixture{{Name: "A", Author: "a@b.pl"}, {Name: "A", Author: "c@d.pl", Tasks: []TaskFixture{task}}}}
+	assert.Equal(t, validateFixtures(&valid), nil)
+}
+
+func TestCourseFromFixture(t *testing.T) {
+	course := courseFromFixture(CourseFixture{Name: "A", Tasks: []TaskFixture{{Word: "cat", Translate: "kot", Audio: "cat.mp3"}}}, 7)
+
+	assert.Equal(t, course.UserID, uint(7))
+	assert.Equal(t, course.Type, internal.NormalType)
+	assert.Equal(t, course.Task, []internal.Task{{Word: "cat", Translate: "kot", Audio: "cat.mp3"}})
+}
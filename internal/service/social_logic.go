@@ -0,0 +1,160 @@
+package service
+
+import (
+	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/event"
+	"github.com/best-project/api/internal/storage"
+	"github.com/pkg/errors"
+)
+
+var ErrSelfFollow = errors.New("users cannot follow themselves")
+
+type SocialLogic struct {
+	db *storage.Database
+}
+
+type SocialHandler interface {
+	Follow(followerID, followeeID uint) error
+	Unfollow(followerID, followeeID uint) error
+	Followers(userID uint) ([]uint, error)
+	Following(userID uint) ([]uint, error)
+	Feed(userID uint, page, limit int) ([]internal.FeedItem, error)
+}
+
+func NewSocialLogic(db *storage.Database) *SocialLogic {
+	return &SocialLogic{
+		db: db,
+	}
+}
+
+// Subscribe records activities shown in the feed of followers
+func (s *SocialLogic) Subscribe(bus *event.Bus) {
+	bus.Subscribe(event.ResultSaved, s.record)
+	bus.Subscribe(event.LevelUp, s.record)
+	bus.Subscribe(event.BadgeEarned, s.record)
+	bus.Subscribe(event.CoursePublished, s.record)
+}
+
+// Follow is idempotent, following the same user twice keeps a single follow
+func (s *SocialLogic) Follow(followerID, followeeID uint) error {
+	if followerID == followeeID {
+		return ErrSelfFollow
+	}
+	follow, err := s.db.Follow.Find(followerID, followeeID)
+	if err != nil || follow != nil {
+		return err
+	}
+
+	return s.db.Follow.SaveFollow(&internal.Follow{FollowerID: followerID, FolloweeID: followeeID})
+}
+
+func (s *SocialLogic) Unfollow(followerID, followeeID uint) error {
+	follow, err := s.db.Follow.Find(followerID, followeeID)
+	if err != nil || follow == nil {
+		return err
+	}
+
+	return s.db.Follow.RemoveFollow(follow)
+}
+
+func (s *SocialLogic) Followers(userID uint) ([]uint, error) {
+	return s.db.Follow.ListFollowers(userID)
+}
+
+func (s *SocialLogic) Following(userID uint) ([]uint, error) {
+	return s.db.Follow.ListFollowing(userID)
+}
+
+// Friends resolves the friends leaderboard scope, the user competes with the followed users
+func (s *SocialLogic) Friends(userID uint, _ string) ([]uint, error) {
+	ids, err := s.db.Follow.ListFollowing(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(ids, userID), nil
+}
+
+// Feed returns activities of the followed users, the latest first. Privacy settings are checked
+// again as activities recorded before a user hid them stay in the database
+func (s *SocialLogic) Feed(userID uint, page, limit int) ([]internal.FeedItem, error) {
+	ids, err := s.db.Follow.ListFollowing(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return []internal.FeedItem{}, nil
+	}
+	authors, err := s.db.User.GetManyByID(ids)
+	if err != nil {
+		return nil, errors.Wrap(err, "while getting followed users")
+	}
+
+	return s.db.Feed.ListShared(sharedAuthors(authors), (page-1)*limit, limit)
+}
+
+// sharedAuthors groups the users by the feed types they currently share
+func sharedAuthors(users []internal.User) map[string][]uint {
+	authors := make(map[string][]uint)
+	for i := range users {
+		for _, feedType := range []string{internal.CourseFinishedFeed, internal.LevelUpFeed, internal.BadgeEarnedFeed, internal.CoursePublishedFeed} {
+			if isShared(&users[i], feedType) {
+				authors[feedType] = append(authors[feedType], users[i].ID)
+			}
+		}
+	}
+	return authors
+}
+
+func (s *SocialLogic) record(e event.Event) error {
+	item := feedItemFor(e)
+	if item == nil {
+		return nil
+	}
+	user, err := s.db.User.GetByID(e.UserID)
+	if err != nil {
+		return errors.Wrapf(err, "while getting user %d", e.UserID)
+	}
+	if !isShared(user, item.Type) {
+		return nil
+	}
+
+	return errors.Wrap(s.db.Feed.SaveItem(item), "while saving feed item")
+}
+
+// feedItemFor returns the feed item describing the event, nil when the event is not shown in feeds
+func feedItemFor(e event.Event) *internal.FeedItem {
+	item := &internal.FeedItem{UserID: e.UserID, CourseID: e.CourseID}
+	switch e.Type {
+	case event.ResultSaved:
+		if !e.Passed {
+			return nil
+		}
+		item.Type, item.Value = internal.CourseFinishedFeed, int(e.Points)
+	case event.LevelUp:
+		item.Type, item.Value = internal.LevelUpFeed, int(e.Value)
+	case event.BadgeEarned:
+		item.Type, item.Name = internal.BadgeEarnedFeed, e.Name
+	case event.CoursePublished:
+		item.Type = internal.CoursePublishedFeed
+	default:
+		return nil
+	}
+	return item
+}
+
+// isShared checks the privacy settings of the user
+func isShared(user *internal.User, feedType string) bool {
+	switch feedType {
+	case internal.CourseFinishedFeed:
+		return !user.HideResults
+	case internal.LevelUpFeed:
+		return !user.HideLevelUps
+	case internal.BadgeEarnedFeed:
+		return !user.HideBadges
+	case internal.CoursePublishedFeed:
+		return !user.HideCourses
+	default:
+		return false
+	}
+}
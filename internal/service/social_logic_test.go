@@ -0,0 +1,45 @@
+package service
+
+import (
+	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/event"
+	"gopkg.in/go-playground/assert.v1"
+	"testing"
+)
+
+func TestFeedItemFor(t *testing.T) {
+	assert.Equal(t, feedItemFor(event.Event{Type: event.ResultSaved, UserID: 1, Points: 40}), (*internal.FeedItem)(nil))
+	assert.Equal(t, feedItemFor(event.Event{Type: event.XPEarned, UserID: 1}), (*internal.FeedItem)(nil))
+
+	item := feedItemFor(event.Event{Type: event.ResultSaved, UserID: 1, CourseID: 2, Points: 40, Passed: true})
+	assert.Equal(t, item.Type, internal.CourseFinishedFeed)
+	assert.Equal(t, item.CourseID, uint(2))
+	assert.Equal(t, item.Value, 40)
+
+	item = feedItemFor(event.Event{Type: event.LevelUp, UserID: 1, Value: 3})
+	assert.Equal(t, item.Type, internal.LevelUpFeed)
+	assert.Equal(t, item.Value, 3)
+
+	item = feedItemFor(event.Event{Type: event.BadgeEarned, UserID: 1, Name: "first-course"})
+	assert.Equal(t, item.Name, "first-course")
+}
+
+func TestIsShared(t *testing.T) {
+	user := &internal.User{HideBadges: true}
+
+	assert.Equal(t, isShared(user, internal.CourseFinishedFeed), true)
+	assert.Equal(t, isShared(user, internal.LevelUpFeed), true)
+	assert.Equal(t, isShared(user, internal.BadgeEarnedFeed), false)
+	assert.Equal(t, isShared(user, internal.CoursePublishedFeed), true)
+}
+
+func TestSharedAuthors(t *testing.T) {
+	users := []internal.User{{ID: 1, HideResults: true}, {ID: 2, HideBadges: true, HideCourses: true}}
+
+	assert.Equal(t, sharedAuthors(users), map[string][]uint{
+		internal.CourseFinishedFeed:  {2},
+		internal.LevelUpFeed:         {1, 2},
+		internal.BadgeEarnedFeed:     {1},
+		internal.CoursePublishedFeed: {1},
+	})
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"github.com/best-project/api/internal/media"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	NoSpeech   = "none"
+	HTTPSpeech = "http"
+	FakeSpeech = "fake"
+)
+
+// SpeechProvider reads the text aloud in the language given as an ISO 639-1 code,
+// the clip has to be in one of media.AudioTypes
+type SpeechProvider interface {
+	Synthesize(ctx context.Context, text, language string) ([]byte, error)
+}
+
+type SpeechSettings struct {
+	Provider string
+	URL      string
+	Timeout  time.Duration
+	MaxBytes int64
+}
+
+// NewSpeechProvider returns nil for NoSpeech, tasks then keep only uploaded audio
+func NewSpeechProvider(settings SpeechSettings) (SpeechProvider, error) {
+	switch settings.Provider {
+	case NoSpeech, "":
+		return nil, nil
+	case HTTPSpeech:
+		return NewHTTPSpeechProvider(settings.URL, settings.Timeout, settings.MaxBytes)
+	case FakeSpeech:
+		return &FakeSpeechProvider{}, nil
+	default:
+		return nil, errors.Errorf("unknown speech provider %s", settings.Provider)
+	}
+}
+
+// HTTPSpeechProvider calls a text-to-speech service with GET <url>?text=...&lang=...
+// which responds with the audio, e.g. a small proxy in front of a cloud service
+type HTTPSpeechProvider struct {
+	endpoint *url.URL
+	client   *http.Client
+	maxBytes int64
+}
+
+func NewHTTPSpeechProvider(endpoint string, timeout time.Duration, maxBytes int64) (*HTTPSpeechProvider, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Host == "" {
+		return nil, errors.Errorf("invalid speech url %q", endpoint)
+	}
+	return &HTTPSpeechProvider{
+		endpoint: parsed,
+		client:   &http.Client{Timeout: timeout},
+		maxBytes: maxBytes,
+	}, nil
+}
+
+func (p *HTTPSpeechProvider) Synthesize(ctx context.Context, text, language string) ([]byte, error) {
+	target := *p.endpoint
+	query := target.Query()
+	query.Set("text", text)
+	query.Set("lang", language)
+	target.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "while creating speech request")
+	}
+	resp, err := p.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, "while calling speech service")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("speech service responded with %d", resp.StatusCode)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, p.maxBytes+1))
+	if err != nil {
+		return nil, errors.Wrap(err, "while reading speech")
+	}
+	return data, nil
+}
+
+// FakeSpeechProvider returns silence lasting 100ms per character and remembers the requests,
+// it is meant for tests and development without a speech service
+type FakeSpeechProvider struct {
+	mu       sync.Mutex
+	Requests []string
+	// Err is returned instead of the clip when set
+	Err error
+}
+
+func (f *FakeSpeechProvider) Synthesize(ctx context.Context, text, language string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.Requests = append(f.Requests, language+":"+text)
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	return media.SilentWAV(time.Duration(len(text)) * 100 * time.Millisecond), nil
+}
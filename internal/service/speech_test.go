@@ -0,0 +1,63 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"github.com/best-project/api/internal/media"
+	"gopkg.in/go-playground/assert.v1"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFakeSpeechProvider(t *testing.T) {
+	fake := &FakeSpeechProvider{}
+
+	clip, err := fake.Synthesize(context.Background(), "artist", "en")
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, fake.Requests, []string{"en:artist"})
+	audio, err := media.ProcessAudio(bytes.NewReader(clip), 1<<20)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, audio.ContentType, media.WAVType)
+}
+
+func TestHTTPSpeechProvider(t *testing.T) {
+	clip := media.SilentWAV(100 * time.Millisecond)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("text") != "zespół" || r.URL.Query().Get("lang") != "pl" || r.URL.Query().Get("voice") != "a" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write(clip)
+	}))
+	defer srv.Close()
+
+	provider, err := NewSpeechProvider(SpeechSettings{Provider: HTTPSpeech, URL: srv.URL + "/speak?voice=a", Timeout: time.Second, MaxBytes: 1 << 20})
+	assert.Equal(t, err, nil)
+
+	data, err := provider.Synthesize(context.Background(), "zespół", "pl")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, data, clip)
+
+	_, err = provider.Synthesize(context.Background(), "band", "en")
+	assert.NotEqual(t, err, nil)
+}
+
+func TestNewSpeechProvider(t *testing.T) {
+	provider, err := NewSpeechProvider(SpeechSettings{Provider: NoSpeech})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, provider, nil)
+
+	_, err = NewSpeechProvider(SpeechSettings{Provider: "robot"})
+	assert.NotEqual(t, err, nil)
+	_, err = NewSpeechProvider(SpeechSettings{Provider: HTTPSpeech, URL: "not a url"})
+	assert.NotEqual(t, err, nil)
+}
+
+func TestSpeechRetryAfter(t *testing.T) {
+	assert.Equal(t, speechRetryAfter(1), time.Hour)
+	assert.Equal(t, speechRetryAfter(3), 4*time.Hour)
+	assert.Equal(t, speechRetryAfter(20), 7*24*time.Hour)
+}
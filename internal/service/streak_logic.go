@@ -0,0 +1,135 @@
+package service
+
+import (
+	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/storage"
+	"github.com/pkg/errors"
+	"time"
+)
+
+type StreakLogic struct {
+	db          *storage.Database
+	dailyGoal   int
+	freezeEvery int
+	freezeMax   int
+	now         func() time.Time
+}
+
+type StreakHandler interface {
+	RecordXP(user *internal.User, xp int) error
+	Status(user *internal.User) (int, int, error)
+	Goal(user *internal.User) int
+}
+
+// NewStreakLogic creates streak logic. A user earns a streak freeze every freezeEvery
+// days of the streak and can keep at most freezeMax of them.
+func NewStreakLogic(db *storage.Database, dailyGoal, freezeEvery, freezeMax int) *StreakLogic {
+	return &StreakLogic{
+		db:          db,
+		dailyGoal:   dailyGoal,
+		freezeEvery: freezeEvery,
+		freezeMax:   freezeMax,
+		now:         time.Now,
+	}
+}
+
+// RecordXP counts points towards the daily goal of the user and extends the streak
+// when the goal is met. The user is modified but not saved.
+func (s *StreakLogic) RecordXP(user *internal.User, xp int) error {
+	today := Today(user, s.now())
+	day, err := s.db.Activity.AddXP(user.ID, today, xp)
+	if err != nil {
+		return errors.Wrap(err, "while saving activity day")
+	}
+
+	if day.XP >= s.Goal(user) {
+		s.extendStreak(user, today)
+	}
+	return nil
+}
+
+// Status returns points earned today and the current streak of the user
+func (s *StreakLogic) Status(user *internal.User) (int, int, error) {
+	today := Today(user, s.now())
+	day, err := s.db.Activity.GetDay(user.ID, today)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "while getting activity day")
+	}
+	xp := 0
+	if day != nil {
+		xp = day.XP
+	}
+
+	return xp, currentStreak(user, today), nil
+}
+
+// Goal returns the daily goal of the user, falling back to the configured one
+func (s *StreakLogic) Goal(user *internal.User) int {
+	if user.DailyGoal > 0 {
+		return user.DailyGoal
+	}
+	return s.dailyGoal
+}
+
+// extendStreak marks today as active, missed days are covered by streak freezes when possible
+func (s *StreakLogic) extendStreak(user *internal.User, today string) {
+	if user.LastActiveDay == today {
+		return
+	}
+	missed := daysBetween(user.LastActiveDay, today) - 1
+	switch {
+	case user.LastActiveDay == "" || missed < 0:
+		user.CurrentStreak = 1
+	case missed == 0:
+		user.CurrentStreak++
+	case missed <= user.StreakFreezes:
+		user.StreakFreezes -= missed
+		user.CurrentStreak++
+	default:
+		user.CurrentStreak = 1
+	}
+	user.LastActiveDay = today
+
+	if user.CurrentStreak > user.LongestStreak {
+		user.LongestStreak = user.CurrentStreak
+	}
+	if s.freezeEvery > 0 && user.CurrentStreak%s.freezeEvery == 0 && user.StreakFreezes < s.freezeMax {
+		user.StreakFreezes++
+	}
+}
+
+// currentStreak returns the streak as it is today, a streak is lost when the missed
+// days cannot be covered by the streak freezes of the user
+func currentStreak(user *internal.User, today string) int {
+	if user.LastActiveDay == "" {
+		return 0
+	}
+	missed := daysBetween(user.LastActiveDay, today) - 1
+	if missed > user.StreakFreezes {
+		return 0
+	}
+	return user.CurrentStreak
+}
+
+// Today returns the current day in the user time zone
+func Today(user *internal.User, now time.Time) string {
+	loc, err := time.LoadLocation(user.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+	return now.In(loc).Format(internal.DayLayout)
+}
+
+// daysBetween counts calendar days between two days. Days are compared as UTC dates,
+// so days shortened or lengthened by DST changes still count as one.
+func daysBetween(from, to string) int {
+	fromDay, err := time.Parse(internal.DayLayout, from)
+	if err != nil {
+		return 0
+	}
+	toDay, err := time.Parse(internal.DayLayout, to)
+	if err != nil {
+		return 0
+	}
+	return int(toDay.Sub(fromDay).Hours()/24 + 0.5)
+}
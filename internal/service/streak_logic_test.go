@@ -0,0 +1,44 @@
+package service
+
+import (
+	"github.com/best-project/api/internal"
+	"gopkg.in/go-playground/assert.v1"
+	"testing"
+	"time"
+)
+
+func TestTodayAcrossDST(t *testing.T) {
+	user := &internal.User{TimeZone: "Europe/Warsaw"}
+
+	// clocks went back at 01:00 UTC on 27 October 2019
+	assert.Equal(t, Today(user, time.Date(2019, 10, 26, 21, 59, 0, 0, time.UTC)), "2019-10-26")
+	assert.Equal(t, Today(user, time.Date(2019, 10, 26, 22, 0, 0, 0, time.UTC)), "2019-10-27")
+	assert.Equal(t, Today(user, time.Date(2019, 10, 27, 22, 59, 0, 0, time.UTC)), "2019-10-27")
+	assert.Equal(t, Today(user, time.Date(2019, 10, 27, 23, 0, 0, 0, time.UTC)), "2019-10-28")
+
+	assert.Equal(t, daysBetween("2019-10-26", "2019-10-27"), 1)
+	assert.Equal(t, daysBetween("2019-03-30", "2019-04-01"), 2)
+}
+
+func TestExtendStreak(t *testing.T) {
+	logic := NewStreakLogic(nil, 20, 3, 1)
+	user := &internal.User{}
+
+	logic.extendStreak(user, "2019-10-26")
+	logic.extendStreak(user, "2019-10-26")
+	logic.extendStreak(user, "2019-10-27")
+	logic.extendStreak(user, "2019-10-28")
+	assert.Equal(t, user.CurrentStreak, 3)
+	assert.Equal(t, user.StreakFreezes, 1)
+
+	// a missed day is covered by the freeze
+	assert.Equal(t, currentStreak(user, "2019-10-30"), 3)
+	logic.extendStreak(user, "2019-10-30")
+	assert.Equal(t, user.CurrentStreak, 4)
+	assert.Equal(t, user.StreakFreezes, 0)
+
+	assert.Equal(t, currentStreak(user, "2019-11-01"), 0)
+	logic.extendStreak(user, "2019-11-01")
+	assert.Equal(t, user.CurrentStreak, 1)
+	assert.Equal(t, user.LongestStreak, 4)
+}
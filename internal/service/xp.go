@@ -0,0 +1,131 @@
+package service
+
+import (
+	"fmt"
+	"github.com/best-project/api/internal"
+	"math"
+)
+
+// XPRules define how many points are awarded for finished courses
+type XPRules struct {
+	// PerTask maps the course type to points for a single task
+	PerTask              map[string]int
+	PerfectBonus         int
+	FirstCompletionBonus int
+	// RepeatPercent scales points for a course which was already passed
+	RepeatPercent float32
+}
+
+// ForTask returns points for a single task of the course type
+func (x XPRules) ForTask(courseType string) int {
+	if xp, ok := x.PerTask[courseType]; ok {
+		return xp
+	}
+	return x.PerTask[internal.NormalType]
+}
+
+// ForResult returns points awarded for a passed result
+func (x XPRules) ForResult(points uint, maxPoints int, alreadyPassed bool) int {
+	xp := int(points)
+	if alreadyPassed {
+		return int(float32(xp) * x.RepeatPercent)
+	}
+	xp += x.FirstCompletionBonus
+	if maxPoints > 0 && int(points) >= maxPoints {
+		xp += x.PerfectBonus
+	}
+	return xp
+}
+
+// LevelCurve returns points needed to advance from the level to the next one
+type LevelCurve interface {
+	XPForLevel(level int) int
+}
+
+const (
+	LinearCurveName     = "linear"
+	PolynomialCurveName = "polynomial"
+	TableCurveName      = "table"
+)
+
+// LinearCurve needs (Base + Step*level) * Factor points for the level
+type LinearCurve struct {
+	Base   float64
+	Step   float64
+	Factor float64
+}
+
+func (l LinearCurve) XPForLevel(level int) int {
+	return int((l.Base + l.Step*float64(level)) * l.Factor)
+}
+
+// PolynomialCurve needs Base * level^Exponent points for the level
+type PolynomialCurve struct {
+	Base     float64
+	Exponent float64
+}
+
+func (p PolynomialCurve) XPForLevel(level int) int {
+	return int(p.Base * math.Pow(float64(level), p.Exponent))
+}
+
+// TableCurve lists points needed for each level, the last entry repeats for higher levels
+type TableCurve []int
+
+func (t TableCurve) XPForLevel(level int) int {
+	if level > len(t) {
+		return t[len(t)-1]
+	}
+	return t[level-1]
+}
+
+// LevelCurveConfig holds parameters of every supported curve
+type LevelCurveConfig struct {
+	Name     string
+	Base     float64
+	Step     float64
+	Factor   float64
+	Exponent float64
+	Table    []int
+}
+
+func NewLevelCurve(cfg LevelCurveConfig) (LevelCurve, error) {
+	var curve LevelCurve
+	switch cfg.Name {
+	case LinearCurveName:
+		curve = LinearCurve{Base: cfg.Base, Step: cfg.Step, Factor: cfg.Factor}
+	case PolynomialCurveName:
+		curve = PolynomialCurve{Base: cfg.Base, Exponent: cfg.Exponent}
+	case TableCurveName:
+		if len(cfg.Table) == 0 {
+			return nil, fmt.Errorf("level table cannot be empty")
+		}
+		curve = TableCurve(cfg.Table)
+	default:
+		return nil, fmt.Errorf("unknown level curve %s", cfg.Name)
+	}
+
+	for level := 1; level <= MaxLevel; level++ {
+		if curve.XPForLevel(level) <= 0 {
+			return nil, fmt.Errorf("%s level curve has to need positive points for every level, level %d needs %d", cfg.Name, level, curve.XPForLevel(level))
+		}
+	}
+	for _, xp := range cfg.Table {
+		if xp <= 0 {
+			return nil, fmt.Errorf("level table entries have to be positive")
+		}
+	}
+	return curve, nil
+}
+
+// MaxLevel is the highest level a user can reach, curves are checked up to it
+const MaxLevel = 1000
+
+// DefaultLevelCurve is the curve used before the curve became configurable
+var DefaultLevelCurve LevelCurve = LinearCurve{Base: 100, Step: 10, Factor: 1.2}
+
+// DefaultXPRules award 10 points for a task without bonuses
+var DefaultXPRules = XPRules{
+	PerTask:       map[string]int{internal.NormalType: 10, internal.PuzzleType: 10},
+	RepeatPercent: 1,
+}
@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"github.com/best-project/api/internal"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+type AchievementDB struct {
+	db *gorm.DB
+}
+
+// Unlock stores the achievement of the user, it returns false when the user already has it
+func (a *AchievementDB) Unlock(achievement *internal.UserAchievement) (bool, error) {
+	err := a.db.Create(achievement).Error
+	if err == nil {
+		return true, nil
+	}
+	// the unique index rejects a badge unlocked in parallel, the error differs between databases
+	count := 0
+	where := &internal.UserAchievement{UserID: achievement.UserID, AchievementID: achievement.AchievementID}
+	if a.db.Model(&internal.UserAchievement{}).Where(where).Count(&count).Error == nil && count > 0 {
+		return false, nil
+	}
+	return false, err
+}
+
+func (a *AchievementDB) ListForUser(userID uint) ([]internal.UserAchievement, error) {
+	a.db.RLock()
+	defer a.db.RUnlock()
+
+	achievements := make([]internal.UserAchievement, 0)
+	err := a.db.Where(&internal.UserAchievement{UserID: userID}).Order("unlocked_at").Find(&achievements).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "while listing achievements of user %d", userID)
+	}
+
+	return achievements, nil
+}
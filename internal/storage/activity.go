@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"github.com/best-project/api/internal"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+type ActivityDB struct {
+	db *gorm.DB
+}
+
+// AddXP adds the points to the day of the user and returns the day with the points of parallel requests
+func (a *ActivityDB) AddXP(userID uint, day string, xp int) (*internal.ActivityDay, error) {
+	row := &internal.ActivityDay{UserID: userID, Day: day, XP: xp}
+	if err := increment(a.db, row, &internal.ActivityDay{UserID: userID, Day: day}, "xp", xp); err != nil {
+		return nil, errors.Wrapf(err, "while adding points of user %d on %s", userID, day)
+	}
+
+	return a.GetDay(userID, day)
+}
+
+func (a *ActivityDB) GetDay(userID uint, day string) (*internal.ActivityDay, error) {
+	a.db.RLock()
+	defer a.db.RUnlock()
+
+	days := make([]internal.ActivityDay, 0)
+	if err := a.db.Where(&internal.ActivityDay{UserID: userID, Day: day}).Find(&days).Error; err != nil {
+		return nil, errors.Wrapf(err, "while getting activity of user %d on %s", userID, day)
+	}
+	if len(days) == 0 {
+		return nil, nil
+	}
+
+	return &days[0], nil
+}
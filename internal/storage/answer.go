@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"github.com/best-project/api/internal"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+type AnswerDB struct {
+	db *gorm.DB
+}
+
+func (a *AnswerDB) SaveAnswer(answer *internal.Answer) error {
+	return a.db.Save(answer).Error
+}
+
+const taskStatSelect = "task_id, count(*) as attempts, sum(case when correct then 1 else 0 end) as correct, avg(duration) as avg_duration"
+
+func (a *AnswerDB) StatsForCourse(courseID uint) ([]internal.TaskStat, error) {
+	a.db.RLock()
+	defer a.db.RUnlock()
+
+	stats := make([]internal.TaskStat, 0)
+	err := a.db.Model(&internal.Answer{}).Select(taskStatSelect).
+		Where("course_id = ?", courseID).Group("task_id").Scan(&stats).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "while computing task stats for course %d", courseID)
+	}
+
+	return stats, nil
+}
+
+func (a *AnswerDB) StatsForUser(userID, courseID uint) ([]internal.TaskStat, error) {
+	a.db.RLock()
+	defer a.db.RUnlock()
+
+	stats := make([]internal.TaskStat, 0)
+	err := a.db.Model(&internal.Answer{}).Select(taskStatSelect).
+		Where("course_id = ? AND user_id = ?", courseID, userID).Group("task_id").Scan(&stats).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "while computing task stats of user %d for course %d", userID, courseID)
+	}
+
+	return stats, nil
+}
+
+// TimeForUsers sums answering time of the users per course
+func (a *AnswerDB) TimeForUsers(userIDs, courseIDs []uint) ([]internal.CourseTime, error) {
+	a.db.RLock()
+	defer a.db.RUnlock()
+
+	times := make([]internal.CourseTime, 0)
+	err := a.db.Model(&internal.Answer{}).Select("user_id, course_id, sum(duration) as duration").
+		Where("user_id IN (?) AND course_id IN (?)", userIDs, courseIDs).Group("user_id, course_id").Scan(&times).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "while computing time spent on courses")
+	}
+
+	return times, nil
+}
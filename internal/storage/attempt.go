@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"github.com/best-project/api/internal"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+type AttemptDB struct {
+	db *gorm.DB
+}
+
+// CountAttempt adds an attempt of the user at the course, the first one creates the row
+func (a *AttemptDB) CountAttempt(userID, courseID uint) error {
+	row := &internal.CourseAttempt{UserID: userID, CourseID: courseID, Attempts: 1}
+	err := increment(a.db, row, &internal.CourseAttempt{UserID: userID, CourseID: courseID}, "attempts", 1)
+	return errors.Wrapf(err, "while counting attempt of user %d for course %d", userID, courseID)
+}
+
+func (a *AttemptDB) Find(userID, courseID uint) (*internal.CourseAttempt, error) {
+	a.db.RLock()
+	defer a.db.RUnlock()
+
+	attempts := make([]internal.CourseAttempt, 0)
+	err := a.db.Where(&internal.CourseAttempt{UserID: userID, CourseID: courseID}).Limit(1).Find(&attempts).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "while finding attempts of user %d for course %d", userID, courseID)
+	}
+	if len(attempts) == 0 {
+		return nil, nil
+	}
+
+	return &attempts[0], nil
+}
+
+func (a *AttemptDB) ListForUsers(userIDs, courseIDs []uint) ([]internal.CourseAttempt, error) {
+	a.db.RLock()
+	defer a.db.RUnlock()
+
+	attempts := make([]internal.CourseAttempt, 0)
+	err := a.db.Where("user_id IN (?) AND course_id IN (?)", userIDs, courseIDs).Find(&attempts).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "while listing course attempts")
+	}
+
+	return attempts, nil
+}
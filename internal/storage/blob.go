@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"bytes"
+	"github.com/best-project/api/internal/config"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	LocalBlobBackend = "local"
+	S3BlobBackend    = "s3"
+)
+
+var (
+	ErrBlobNotFound   = errors.New("blob not found")
+	ErrInvalidBlobKey = errors.New("invalid blob key")
+)
+
+// BlobInfo describes a stored blob
+type BlobInfo struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+	ETag        string
+}
+
+// BlobStore keeps uploaded files such as course images and avatars,
+// keys are slash separated relative paths
+type BlobStore interface {
+	Put(key string, r io.Reader, size int64, contentType string) error
+	// Get returns the content which has to be closed by the caller
+	Get(key string) (io.ReadCloser, *BlobInfo, error)
+	Delete(key string) error
+	Stat(key string) (*BlobInfo, error)
+	// List returns every blob whose key starts with the prefix
+	List(prefix string) ([]BlobInfo, error)
+	// URL is the address under which clients fetch the blob
+	URL(key string) string
+}
+
+// RangeGetter is implemented by stores able to read a part of a blob without downloading all of it
+type RangeGetter interface {
+	// GetRange returns the content from offset to the end
+	GetRange(key string, offset int64) (io.ReadCloser, error)
+}
+
+type ReadSeekCloser interface {
+	io.ReadSeeker
+	io.Closer
+}
+
+// OpenBlob returns the content with random access so range requests can be served,
+// blobs of stores without range reads are buffered in memory
+func OpenBlob(store BlobStore, key string) (ReadSeekCloser, *BlobInfo, error) {
+	if ranged, ok := store.(RangeGetter); ok {
+		info, err := store.Stat(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &rangeReader{store: ranged, key: key, size: info.Size}, info, nil
+	}
+
+	body, info, err := store.Get(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if seeker, ok := body.(ReadSeekCloser); ok {
+		return seeker, info, nil
+	}
+	defer body.Close()
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "while reading blob %s", key)
+	}
+	return nopCloser{bytes.NewReader(data)}, info, nil
+}
+
+type nopCloser struct {
+	io.ReadSeeker
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+// rangeReader opens a ranged read on the first Read after every Seek
+type rangeReader struct {
+	store  RangeGetter
+	key    string
+	size   int64
+	offset int64
+	body   io.ReadCloser
+}
+
+func (r *rangeReader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if r.body == nil {
+		body, err := r.store.GetRange(r.key, r.offset)
+		if err != nil {
+			return 0, err
+		}
+		r.body = body
+	}
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
+
+func (r *rangeReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	if offset != r.offset {
+		r.Close()
+		r.offset = offset
+	}
+	return offset, nil
+}
+
+func (r *rangeReader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body = nil
+	return err
+}
+
+// NewBlobStore returns the store of the configured backend
+func NewBlobStore(cfg *config.Config) (BlobStore, error) {
+	switch cfg.BlobBackend {
+	case LocalBlobBackend:
+		return NewLocalBlobStore(cfg.BlobLocalDir, cfg.BlobPublicURL)
+	case S3BlobBackend:
+		return NewS3BlobStore(S3Settings{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PathStyle: cfg.S3PathStyle,
+		}, cfg.BlobPublicURL)
+	default:
+		return nil, errors.Errorf("unknown blob backend %s", cfg.BlobBackend)
+	}
+}
+
+// cleanBlobKey rejects keys escaping the store, e.g. through "..", keys come from request paths
+func cleanBlobKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", ErrInvalidBlobKey
+	}
+	cleaned := path.Clean(key)
+	if cleaned != key || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", ErrInvalidBlobKey
+	}
+	return cleaned, nil
+}
+
+func blobURL(publicURL, key string) string {
+	return strings.TrimSuffix(publicURL, "/") + "/" + key
+}
@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LocalBlobStore keeps blobs in a directory, the content type is derived from the key extension
+type LocalBlobStore struct {
+	dir       string
+	publicURL string
+}
+
+func NewLocalBlobStore(dir, publicURL string) (*LocalBlobStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrapf(err, "while creating blob directory %s", dir)
+	}
+	return &LocalBlobStore{dir: dir, publicURL: publicURL}, nil
+}
+
+// Put writes to a temporary file first so readers never see a partial blob
+func (l *LocalBlobStore) Put(key string, r io.Reader, size int64, contentType string) error {
+	name, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return errors.Wrapf(err, "while creating directory of blob %s", key)
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(name), ".upload-")
+	if err != nil {
+		return errors.Wrapf(err, "while creating blob %s", key)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return errors.Wrapf(err, "while writing blob %s", key)
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrapf(err, "while writing blob %s", key)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return errors.Wrapf(err, "while writing blob %s", key)
+	}
+	return errors.Wrapf(os.Rename(tmp.Name(), name), "while storing blob %s", key)
+}
+
+func (l *LocalBlobStore) Get(key string) (io.ReadCloser, *BlobInfo, error) {
+	name, err := l.path(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil, nil, ErrBlobNotFound
+	}
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "while opening blob %s", key)
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, errors.Wrapf(err, "while reading blob %s", key)
+	}
+
+	return file, l.info(key, stat), nil
+}
+
+func (l *LocalBlobStore) Delete(key string) error {
+	name, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "while removing blob %s", key)
+	}
+	return nil
+}
+
+func (l *LocalBlobStore) Stat(key string) (*BlobInfo, error) {
+	name, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := os.Stat(name)
+	if os.IsNotExist(err) {
+		return nil, ErrBlobNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "while reading blob %s", key)
+	}
+
+	return l.info(key, stat), nil
+}
+
+// List skips temporary files of uploads in progress
+func (l *LocalBlobStore) List(prefix string) ([]BlobInfo, error) {
+	blobs := make([]BlobInfo, 0)
+	err := filepath.Walk(l.dir, func(name string, stat os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if stat.IsDir() || strings.HasPrefix(stat.Name(), ".") {
+			return nil
+		}
+		rel, err := filepath.Rel(l.dir, name)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if strings.HasPrefix(key, prefix) {
+			blobs = append(blobs, *l.info(key, stat))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "while listing blobs in %s", l.dir)
+	}
+
+	return blobs, nil
+}
+
+func (l *LocalBlobStore) URL(key string) string {
+	return blobURL(l.publicURL, key)
+}
+
+func (l *LocalBlobStore) path(key string) (string, error) {
+	key, err := cleanBlobKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(l.dir, filepath.FromSlash(key)), nil
+}
+
+func (l *LocalBlobStore) info(key string, stat os.FileInfo) *BlobInfo {
+	return &BlobInfo{
+		Key:         key,
+		Size:        stat.Size(),
+		ContentType: mime.TypeByExtension(path.Ext(key)),
+		ModTime:     stat.ModTime(),
+	}
+}
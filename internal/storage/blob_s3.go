@@ -0,0 +1,344 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	s3Timeout = 30 * time.Second
+	// unsignedPayload lets uploads stream without hashing the body upfront
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	amzDateFormat   = "20060102T150405Z"
+)
+
+type S3Settings struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PathStyle puts the bucket in the path instead of the host name, MinIO needs it
+	PathStyle bool
+}
+
+// S3BlobStore talks to an S3 compatible service, requests are signed with AWS Signature Version 4
+type S3BlobStore struct {
+	settings  S3Settings
+	endpoint  *url.URL
+	publicURL string
+	client    *http.Client
+	now       func() time.Time
+}
+
+func NewS3BlobStore(settings S3Settings, publicURL string) (*S3BlobStore, error) {
+	endpoint, err := url.Parse(settings.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, errors.Errorf("invalid S3 endpoint %q", settings.Endpoint)
+	}
+	if settings.Bucket == "" {
+		return nil, errors.New("S3 bucket is not set")
+	}
+	return &S3BlobStore{
+		settings:  settings,
+		endpoint:  endpoint,
+		publicURL: publicURL,
+		client:    &http.Client{Timeout: s3Timeout},
+		now:       time.Now,
+	}, nil
+}
+
+func (s *S3BlobStore) Put(key string, r io.Reader, size int64, contentType string) error {
+	req, err := s.request(http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return errors.Wrapf(err, "while uploading blob %s", key)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *S3BlobStore) Get(key string) (io.ReadCloser, *BlobInfo, error) {
+	req, err := s.request(http.MethodGet, key, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := s.do(req)
+	if err == ErrBlobNotFound {
+		return nil, nil, err
+	}
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "while downloading blob %s", key)
+	}
+	return resp.Body, s.info(key, resp), nil
+}
+
+func (s *S3BlobStore) GetRange(key string, offset int64) (io.ReadCloser, error) {
+	req, err := s.request(http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+
+	resp, err := s.do(req)
+	if err == ErrBlobNotFound {
+		return nil, err
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "while downloading blob %s from %d", key, offset)
+	}
+	return resp.Body, nil
+}
+
+func (s *S3BlobStore) Delete(key string) error {
+	req, err := s.request(http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req)
+	if err == ErrBlobNotFound {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "while removing blob %s", key)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *S3BlobStore) Stat(key string) (*BlobInfo, error) {
+	req, err := s.request(http.MethodHead, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(req)
+	if err == ErrBlobNotFound {
+		return nil, err
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "while reading blob %s", key)
+	}
+	resp.Body.Close()
+	return s.info(key, resp), nil
+}
+
+type s3ListResult struct {
+	Contents []struct {
+		Key          string
+		Size         int64
+		LastModified time.Time
+		ETag         string
+	}
+	IsTruncated           bool
+	NextContinuationToken string
+}
+
+// List pages through ListObjectsV2 until every matching object is read
+func (s *S3BlobStore) List(prefix string) ([]BlobInfo, error) {
+	blobs := make([]BlobInfo, 0)
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		req, err := http.NewRequest(http.MethodGet, s.bucketURL().String(), nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "while creating list request")
+		}
+		req.URL.RawQuery = s3EscapeQuery(query)
+
+		resp, err := s.do(req)
+		if err != nil {
+			return nil, errors.Wrapf(err, "while listing blobs with prefix %q", prefix)
+		}
+		result := s3ListResult{}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, errors.Wrap(err, "while decoding list response")
+		}
+
+		for _, object := range result.Contents {
+			blobs = append(blobs, BlobInfo{
+				Key:     object.Key,
+				Size:    object.Size,
+				ModTime: object.LastModified,
+				ETag:    strings.Trim(object.ETag, `"`),
+			})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return blobs, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+func (s *S3BlobStore) URL(key string) string {
+	return blobURL(s.publicURL, key)
+}
+
+func (s *S3BlobStore) request(method, key string, body io.Reader) (*http.Request, error) {
+	key, err := cleanBlobKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	target := s.bucketURL()
+	target.Path += key
+	req, err := http.NewRequest(method, target.String(), body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "while creating request for blob %s", key)
+	}
+	return req, nil
+}
+
+// bucketURL is the address of the bucket, its path ends with a slash so keys can be appended
+func (s *S3BlobStore) bucketURL() *url.URL {
+	target := *s.endpoint
+	if s.settings.PathStyle {
+		target.Path = strings.TrimSuffix(target.Path, "/") + "/" + s.settings.Bucket + "/"
+	} else {
+		target.Host = s.settings.Bucket + "." + target.Host
+		target.Path = strings.TrimSuffix(target.Path, "/") + "/"
+	}
+	return &target
+}
+
+// do signs and sends the request, responses other than 2xx are returned as errors
+func (s *S3BlobStore) do(req *http.Request) (*http.Response, error) {
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+	signS3Request(req, s.settings.AccessKey, s.settings.SecretKey, s.settings.Region, s.now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrBlobNotFound
+	}
+	message, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+	return nil, errors.Errorf("S3 responded with %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+}
+
+func (s *S3BlobStore) info(key string, resp *http.Response) *BlobInfo {
+	info := &BlobInfo{
+		Key:         key,
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+		ETag:        strings.Trim(resp.Header.Get("ETag"), `"`),
+	}
+	if size, err := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64); err == nil {
+		info.Size = size
+	}
+	if modTime, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.ModTime = modTime
+	}
+	return info
+}
+
+// signS3Request adds the Signature Version 4 authorization,
+// the payload hash has to be set in X-Amz-Content-Sha256 already
+func signS3Request(req *http.Request, accessKey, secretKey, region string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format(amzDateFormat)
+	day := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "content-type" || name == "range" || strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	canonicalHeaders := ""
+	for _, name := range names {
+		canonicalHeaders += name + ":" + headers[name] + "\n"
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3EscapePath(req.URL.Path),
+		s3EscapeQuery(req.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		req.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", day, region)
+	hashed := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hex.EncodeToString(hashed[:])}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), day)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+// s3EscapePath encodes everything but unreserved characters and slashes
+func s3EscapePath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// s3EscapeQuery sorts and encodes the parameters, spaces have to be %20 instead of +
+func s3EscapeQuery(query url.Values) string {
+	return strings.Replace(query.Encode(), "+", "%20", -1)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"github.com/best-project/api/internal"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	"time"
+)
+
+type ChallengeDB struct {
+	db *gorm.DB
+}
+
+func (c *ChallengeDB) SaveChallenge(challenge *internal.Challenge) error {
+	return c.db.Save(challenge).Error
+}
+
+func (c *ChallengeDB) GetByID(id uint) (*internal.Challenge, error) {
+	c.db.RLock()
+	defer c.db.RUnlock()
+
+	challenge := &internal.Challenge{}
+	if err := c.db.First(challenge, id).Error; err != nil {
+		return nil, err
+	}
+
+	return challenge, nil
+}
+
+// ListForUser returns challenges the user takes part in, the latest first
+func (c *ChallengeDB) ListForUser(userID uint) ([]internal.Challenge, error) {
+	c.db.RLock()
+	defer c.db.RUnlock()
+
+	challenges := make([]internal.Challenge, 0)
+	err := c.db.Where("challenger_id = ? OR opponent_id = ?", userID, userID).Order("created_at DESC").Find(&challenges).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "while listing challenges of user %d", userID)
+	}
+
+	return challenges, nil
+}
+
+// ListExpired returns pending challenges which expired before now
+func (c *ChallengeDB) ListExpired(now time.Time) ([]internal.Challenge, error) {
+	c.db.RLock()
+	defer c.db.RUnlock()
+
+	challenges := make([]internal.Challenge, 0)
+	err := c.db.Where("status = ? AND expires_at < ?", internal.PendingChallenge, now).Find(&challenges).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "while listing expired challenges")
+	}
+
+	return challenges, nil
+}
+
+// FinishSide stores the points of the player, it returns false when the player already finished
+// or the challenge was decided meanwhile, e.g. by another instance
+func (c *ChallengeDB) FinishSide(challengeID uint, challenger bool, points uint, finishedAt time.Time) (bool, error) {
+	side := "opponent"
+	if challenger {
+		side = "challenger"
+	}
+	result := c.db.Model(&internal.Challenge{}).
+		Where("id = ? AND status = ? AND "+side+"_finished_at IS NULL", challengeID, internal.PendingChallenge).
+		Updates(map[string]interface{}{side + "_points": points, side + "_finished_at": finishedAt})
+	if result.Error != nil {
+		return false, errors.Wrapf(result.Error, "while finishing challenge %d", challengeID)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Decide stores the status and the winner of the pending challenge, it returns false when the challenge
+// was decided meanwhile or a player finished after it was read
+func (c *ChallengeDB) Decide(challenge *internal.Challenge) (bool, error) {
+	query := c.db.Model(&internal.Challenge{}).Where("id = ? AND status = ?", challenge.ID, internal.PendingChallenge)
+	for column, finishedAt := range map[string]*time.Time{
+		"challenger_finished_at": challenge.ChallengerFinishedAt,
+		"opponent_finished_at":   challenge.OpponentFinishedAt,
+	} {
+		if finishedAt == nil {
+			query = query.Where(column + " IS NULL")
+		} else {
+			query = query.Where(column + " IS NOT NULL")
+		}
+	}
+	result := query.Updates(map[string]interface{}{"status": challenge.Status, "winner_id": challenge.WinnerID})
+	if result.Error != nil {
+		return false, errors.Wrapf(result.Error, "while deciding challenge %d", challenge.ID)
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"github.com/best-project/api/internal"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+type ClassroomDB struct {
+	db *gorm.DB
+}
+
+func (c *ClassroomDB) SaveClassroom(classroom *internal.Classroom) error {
+	return c.db.Save(classroom).Error
+}
+
+func (c *ClassroomDB) GetByID(id uint) (*internal.Classroom, error) {
+	c.db.RLock()
+	defer c.db.RUnlock()
+
+	classroom := &internal.Classroom{}
+	if err := c.db.First(classroom, id).Error; err != nil {
+		return nil, err
+	}
+
+	return classroom, nil
+}
+
+func (c *ClassroomDB) GetByCode(code string) (*internal.Classroom, error) {
+	c.db.RLock()
+	defer c.db.RUnlock()
+
+	classrooms := make([]internal.Classroom, 0)
+	if err := c.db.Where(&internal.Classroom{JoinCode: code}).Limit(1).Find(&classrooms).Error; err != nil {
+		return nil, errors.Wrap(err, "while getting classroom by code")
+	}
+	if len(classrooms) == 0 {
+		return nil, nil
+	}
+
+	return &classrooms[0], nil
+}
+
+// ListForUser returns classrooms the user teaches or attends
+func (c *ClassroomDB) ListForUser(userID uint) ([]internal.Classroom, error) {
+	c.db.RLock()
+	defer c.db.RUnlock()
+
+	classrooms := make([]internal.Classroom, 0)
+	err := c.db.Table("classrooms").Select("classrooms.*").
+		Joins("JOIN classroom_members ON classroom_members.classroom_id = classrooms.id").
+		Where("classroom_members.user_id = ?", userID).Order("classrooms.created_at DESC").Find(&classrooms).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "while listing classrooms of user %d", userID)
+	}
+
+	return classrooms, nil
+}
+
+func (c *ClassroomDB) SaveMember(member *internal.ClassroomMember) error {
+	return c.db.Save(member).Error
+}
+
+func (c *ClassroomDB) FindMember(classroomID, userID uint) (*internal.ClassroomMember, error) {
+	c.db.RLock()
+	defer c.db.RUnlock()
+
+	members := make([]internal.ClassroomMember, 0)
+	err := c.db.Where(&internal.ClassroomMember{ClassroomID: classroomID, UserID: userID}).Limit(1).Find(&members).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "while finding member %d of classroom %d", userID, classroomID)
+	}
+	if len(members) == 0 {
+		return nil, nil
+	}
+
+	return &members[0], nil
+}
+
+func (c *ClassroomDB) ListMembers(classroomID uint) ([]internal.ClassroomMember, error) {
+	c.db.RLock()
+	defer c.db.RUnlock()
+
+	members := make([]internal.ClassroomMember, 0)
+	err := c.db.Where(&internal.ClassroomMember{ClassroomID: classroomID}).Order("created_at").Find(&members).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "while listing members of classroom %d", classroomID)
+	}
+
+	return members, nil
+}
+
+func (c *ClassroomDB) SaveAssignment(assignment *internal.Assignment) error {
+	return c.db.Save(assignment).Error
+}
+
+func (c *ClassroomDB) ListAssignments(classroomID uint) ([]internal.Assignment, error) {
+	c.db.RLock()
+	defer c.db.RUnlock()
+
+	assignments := make([]internal.Assignment, 0)
+	err := c.db.Where(&internal.Assignment{ClassroomID: classroomID}).Order("due_at").Find(&assignments).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "while listing assignments of classroom %d", classroomID)
+	}
+
+	return assignments, nil
+}
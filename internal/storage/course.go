@@ -83,3 +83,7 @@ func (c *CourseDB) GetManyByID(ids []string) ([]*internal.Course, error) {
 
 	return courses, nil
 }
+
+func (c *CourseDB) UpdateDifficulty(courseID uint, score float32) error {
+	return c.db.Model(&internal.Course{ID: courseID}).UpdateColumn("difficulty_score", score).Error
+}
@@ -55,31 +55,51 @@ func (c *CourseResultDB) ListStartedForUser(userID uint) ([]internal.CourseResul
 	return results, nil
 }
 
+// GetStartedForUserForCourse returns the last started result of the course or nil when there is none
+func (c *CourseResultDB) GetStartedForUserForCourse(userID, courseID uint) (*internal.CourseResult, error) {
+	c.db.RLock()
+	defer c.db.RUnlock()
+
+	results := make([]internal.CourseResult, 0)
+	err := c.db.Where(internal.CourseResult{UserID: userID, CourseID: courseID, Phase: internal.StartedPhase}).
+		Order("updated_at desc").Limit(1).Find(&results).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, nil
+	}
+
+	return &results[0], nil
+}
+
+// ListBestResultsForUser returns one result per course, the one with the most points in any direction
 func (c *CourseResultDB) ListBestResultsForUser(userID uint) ([]internal.CourseResult, error) {
 	c.db.RLock()
 	defer c.db.RUnlock()
 
 	results := make([]internal.CourseResult, 0)
-	err := c.db.Where(internal.CourseResult{UserID: userID, Phase: internal.FinishedPhase}).Find(&results).Error
+	err := c.db.Where(internal.CourseResult{UserID: userID, Phase: internal.FinishedPhase}).Order("id").Find(&results).Error
 	if err != nil {
 		return nil, err
 	}
 
-	topResults := make(map[string]internal.CourseResult, 0)
+	topResults := make(map[uint]internal.CourseResult, 0)
+	order := make([]uint, 0)
 	for _, result := range results {
-		uid := strconv.Itoa(int(result.CourseID))
-		if topResult, ok := topResults[uid]; !ok {
-			topResults[uid] = result
+		if topResult, ok := topResults[result.CourseID]; !ok {
+			topResults[result.CourseID] = result
+			order = append(order, result.CourseID)
 		} else {
 			if result.Points > topResult.Points {
-				topResults[uid] = result
+				topResults[result.CourseID] = result
 			}
 		}
 	}
 
-	results = make([]internal.CourseResult, 0)
-	for _, result := range topResults {
-		results = append(results, result)
+	results = make([]internal.CourseResult, 0, len(order))
+	for _, courseID := range order {
+		results = append(results, topResults[courseID])
 	}
 
 	return results, nil
@@ -114,7 +134,7 @@ func (c *CourseResultDB) ReplaceIfExist(result *internal.CourseResult) (*interna
 	defer c.db.RUnlock()
 
 	results := make([]internal.CourseResult, 0)
-	err := c.db.Where(internal.CourseResult{UserID: result.UserID, CourseID: result.CourseID, Phase: internal.FinishedPhase}).Find(&results).Error
+	err := c.db.Where(internal.CourseResult{UserID: result.UserID, CourseID: result.CourseID, Phase: internal.FinishedPhase, Direction: result.Direction}).Find(&results).Error
 	if err != nil {
 		return nil, 0, err
 	}
@@ -143,3 +163,31 @@ func (c *CourseResultDB) ListAllForUser(userID uint) ([]internal.CourseResult, e
 
 	return results, nil
 }
+
+func (c *CourseResultDB) HasPassed(userID, courseID uint) (bool, error) {
+	c.db.RLock()
+	defer c.db.RUnlock()
+
+	results := make([]internal.CourseResult, 0)
+	err := c.db.Where(internal.CourseResult{UserID: userID, CourseID: courseID, Passed: true}).Limit(1).Find(&results).Error
+	if err != nil {
+		return false, err
+	}
+
+	return len(results) > 0, nil
+}
+
+// ListPassedForUser returns the passing results of the user, a result is created when the attempt passes
+// so CreatedAt tells when the course was passed
+func (c *CourseResultDB) ListPassedForUser(userID uint) ([]internal.CourseResult, error) {
+	c.db.RLock()
+	defer c.db.RUnlock()
+
+	results := make([]internal.CourseResult, 0)
+	err := c.db.Where(internal.CourseResult{UserID: userID, Passed: true}).Find(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
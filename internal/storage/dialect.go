@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"fmt"
+	"github.com/best-project/api/internal/config"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	"net/url"
+	"strings"
+)
+
+// Dialects supported by NewDatabase, the names are the ones of gorm
+const (
+	MySQL    = "mysql"
+	Postgres = "postgres"
+	SQLite   = "sqlite3"
+)
+
+// dataSource builds the connection string of the configured dialect, DbName is the file path for SQLite
+func dataSource(cfg *config.Config) (string, error) {
+	if cfg.DbDialect != SQLite && (cfg.DbHost == "" || cfg.DbPort == "" || cfg.DbUser == "") {
+		return "", errors.Errorf("DB_HOST, DB_PORT and DB_USER are required for %s", cfg.DbDialect)
+	}
+	switch cfg.DbDialect {
+	case MySQL:
+		return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8&parseTime=True",
+			cfg.DbUser, cfg.DbPass, cfg.DbHost, cfg.DbPort, cfg.DbName), nil
+	case Postgres:
+		source := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(cfg.DbUser, cfg.DbPass),
+			Host:     cfg.DbHost + ":" + cfg.DbPort,
+			Path:     "/" + cfg.DbName,
+			RawQuery: url.Values{"sslmode": {cfg.DbSSLMode}}.Encode(),
+		}
+		return source.String(), nil
+	case SQLite:
+		if !sqliteSupported {
+			return "", errors.Errorf("%s needs a binary built with CGO_ENABLED=1", SQLite)
+		}
+		separator := "?"
+		if strings.Contains(cfg.DbName, "?") {
+			separator = "&"
+		}
+		// foreign keys are off unless enabled on every connection
+		return cfg.DbName + separator + "_foreign_keys=1&_busy_timeout=5000", nil
+	default:
+		return "", errors.Errorf("unknown database dialect %s, use %s, %s or %s", cfg.DbDialect, MySQL, Postgres, SQLite)
+	}
+}
+
+// addSQLiteForeignKeys recreates the tables with their foreign keys as SQLite cannot add them to an existing table
+func addSQLiteForeignKeys(db *gorm.DB, relations []relation) error {
+	tables := make([]string, 0)
+	byTable := make(map[string][]relation)
+	for _, r := range relations {
+		if _, ok := byTable[r.table]; !ok {
+			tables = append(tables, r.table)
+		}
+		byTable[r.table] = append(byTable[r.table], r)
+	}
+
+	// dropping a referenced table would otherwise delete the rows referring to it
+	if err := db.Exec("PRAGMA foreign_keys = OFF").Error; err != nil {
+		return errors.Wrap(err, "while disabling foreign keys")
+	}
+	defer db.Exec("PRAGMA foreign_keys = ON")
+
+	for _, table := range tables {
+		if err := rebuildSQLiteTable(db, table, byTable[table]); err != nil {
+			return errors.Wrapf(err, "while adding foreign keys to %s", table)
+		}
+	}
+	return nil
+}
+
+func rebuildSQLiteTable(db *gorm.DB, table string, relations []relation) error {
+	var create string
+	if err := db.Raw("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?", table).Row().Scan(&create); err != nil {
+		return errors.Wrap(err, "while reading table definition")
+	}
+	indexes := make([]string, 0)
+	rows, err := db.Raw("SELECT sql FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND sql IS NOT NULL", table).Rows()
+	if err != nil {
+		return errors.Wrap(err, "while reading indexes")
+	}
+	for rows.Next() {
+		var index string
+		if err := rows.Scan(&index); err != nil {
+			rows.Close()
+			return errors.Wrap(err, "while reading indexes")
+		}
+		indexes = append(indexes, index)
+	}
+	rows.Close()
+
+	rebuilt := table + "_rebuilt"
+	create, err = withForeignKeys(create, rebuilt, relations)
+	if err != nil {
+		return err
+	}
+	tx := db.Begin()
+	statements := append([]string{
+		create,
+		fmt.Sprintf(`INSERT INTO "%s" SELECT * FROM "%s"`, rebuilt, table),
+		fmt.Sprintf(`DROP TABLE "%s"`, table),
+		fmt.Sprintf(`ALTER TABLE "%s" RENAME TO "%s"`, rebuilt, table),
+	}, indexes...)
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+// withForeignKeys renames the table of a CREATE TABLE statement and appends the foreign key constraints
+func withForeignKeys(create, table string, relations []relation) (string, error) {
+	start, end := strings.Index(create, "("), strings.LastIndex(create, ")")
+	if start < 0 || end < start {
+		return "", errors.Errorf("unexpected table definition %s", create)
+	}
+	constraints := make([]string, 0, len(relations))
+	for _, r := range relations {
+		constraints = append(constraints, fmt.Sprintf(`FOREIGN KEY ("%s") REFERENCES %s ON DELETE CASCADE ON UPDATE CASCADE`, r.field, r.dest))
+	}
+	return fmt.Sprintf(`CREATE TABLE "%s" %s,%s)`, table, create[start:end], strings.Join(constraints, ",")), nil
+}
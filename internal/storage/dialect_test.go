@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"github.com/best-project/api/internal/config"
+	"gopkg.in/go-playground/assert.v1"
+	"testing"
+)
+
+func TestDataSource(t *testing.T) {
+	cfg := &config.Config{DbHost: "db", DbPort: "5432", DbUser: "api", DbPass: "p@ss word", DbName: "best", DbSSLMode: "disable"}
+
+	cfg.DbDialect = Postgres
+	source, err := dataSource(cfg)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, source, "postgres://api:p%40ss%20word@db:5432/best?sslmode=disable")
+
+	cfg.DbDialect = MySQL
+	source, err = dataSource(cfg)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, source, "api:p@ss word@tcp(db:5432)/best?charset=utf8&parseTime=True")
+
+	cfg.DbDialect = "oracle"
+	_, err = dataSource(cfg)
+	assert.NotEqual(t, err, nil)
+
+	source, err = dataSource(&config.Config{DbDialect: SQLite, DbName: "file:best.db?cache=shared"})
+	if sqliteSupported {
+		assert.Equal(t, err, nil)
+		assert.Equal(t, source, "file:best.db?cache=shared&_foreign_keys=1&_busy_timeout=5000")
+	} else {
+		assert.NotEqual(t, err, nil)
+	}
+
+	_, err = dataSource(&config.Config{DbDialect: MySQL, DbName: "best"})
+	assert.NotEqual(t, err, nil)
+}
+
+func TestWithForeignKeys(t *testing.T) {
+	create := `CREATE TABLE "tasks" ("id" integer primary key autoincrement,"course_id" integer )`
+	relations := []relation{{"tasks", "course_id", "courses(id)"}}
+
+	rebuilt, err := withForeignKeys(create, "tasks_rebuilt", relations)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, rebuilt, `CREATE TABLE "tasks_rebuilt" ("id" integer primary key autoincrement,"course_id" integer ,`+
+		`FOREIGN KEY ("course_id") REFERENCES courses(id) ON DELETE CASCADE ON UPDATE CASCADE)`)
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"github.com/best-project/api/internal"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+type ExamDB struct {
+	db *gorm.DB
+}
+
+func (e *ExamDB) SaveExam(exam *internal.Exam) error {
+	return e.db.Save(exam).Error
+}
+
+func (e *ExamDB) GetByID(id uint) (*internal.Exam, error) {
+	e.db.RLock()
+	defer e.db.RUnlock()
+
+	exam := &internal.Exam{}
+	if err := e.db.First(exam, id).Error; err != nil {
+		return nil, err
+	}
+
+	return exam, nil
+}
+
+func (e *ExamDB) GetLastForUser(userID, courseID uint) (*internal.Exam, error) {
+	e.db.RLock()
+	defer e.db.RUnlock()
+
+	exams := make([]internal.Exam, 0)
+	err := e.db.Where(&internal.Exam{UserID: userID, CourseID: courseID}).Order("created_at desc").Limit(1).Find(&exams).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "while getting last exam for course %d", courseID)
+	}
+	if len(exams) == 0 {
+		return nil, nil
+	}
+
+	return &exams[0], nil
+}
+
+// OpenExam stores the new exam as open, it returns false when the user already has an open exam of the course
+func (e *ExamDB) OpenExam(exam *internal.Exam) (bool, error) {
+	open := true
+	exam.Open = &open
+	err := e.db.Create(exam).Error
+	if err == nil {
+		return true, nil
+	}
+	// the unique index rejects an exam started in parallel, the error differs between databases
+	count := 0
+	where := "user_id = ? AND course_id = ? AND is_open IS NOT NULL"
+	if e.db.Model(&internal.Exam{}).Where(where, exam.UserID, exam.CourseID).Count(&count).Error == nil && count > 0 {
+		exam.ID, exam.Open = 0, nil
+		return false, nil
+	}
+	return false, err
+}
+
+// CloseExam leaves the abandoned exam unfinished but no longer open so another one can start
+func (e *ExamDB) CloseExam(id uint) error {
+	err := e.db.Model(&internal.Exam{}).Where("id = ?", id).Update("is_open", gorm.Expr("NULL")).Error
+	return errors.Wrapf(err, "while closing exam %d", id)
+}
+
+// FinishExam stores the outcome of the exam, it returns false when a parallel request finished it first
+func (e *ExamDB) FinishExam(exam *internal.Exam) (bool, error) {
+	result := e.db.Model(&internal.Exam{}).Where("id = ? AND finished_at IS NULL", exam.ID).
+		Updates(map[string]interface{}{"finished_at": exam.FinishedAt, "points": exam.Points, "passed": exam.Passed, "is_open": gorm.Expr("NULL")})
+	if result.Error != nil {
+		return false, errors.Wrapf(result.Error, "while finishing exam %d", exam.ID)
+	}
+	if result.RowsAffected != 1 {
+		return false, nil
+	}
+	exam.Open = nil
+	return true, nil
+}
+
+type CertificateDB struct {
+	db *gorm.DB
+}
+
+func (c *CertificateDB) SaveCertificate(certificate *internal.Certificate) error {
+	return c.db.Save(certificate).Error
+}
+
+func (c *CertificateDB) GetByCode(code string) (*internal.Certificate, error) {
+	c.db.RLock()
+	defer c.db.RUnlock()
+
+	certificate := &internal.Certificate{}
+	if err := c.db.Where(&internal.Certificate{Code: code}).First(certificate).Error; err != nil {
+		return nil, err
+	}
+
+	return certificate, nil
+}
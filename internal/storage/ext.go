@@ -1,6 +1,9 @@
 package storage
 
-import "github.com/best-project/api/internal"
+import (
+	"github.com/best-project/api/internal"
+	"time"
+)
 
 type Course interface {
 	SaveCourse(course *internal.Course, xpForTask int) error
@@ -9,6 +12,7 @@ type Course interface {
 	GetByID(id string) (*internal.Course, error)
 	Exist(courseID string) bool
 	GetManyByID(ids []string) ([]*internal.Course, error)
+	UpdateDifficulty(courseID uint, score float32) error
 }
 type CourseResult interface {
 	SaveResult(course *internal.CourseResult) error
@@ -17,8 +21,11 @@ type CourseResult interface {
 	GetBestResultForUserForCourse(userID uint, courseID string) (*internal.CourseResult, error)
 	ListAllForUser(userID uint) ([]internal.CourseResult, error)
 	ListStartedForUser(userID uint) ([]internal.CourseResult, error)
+	GetStartedForUserForCourse(userID, courseID uint) (*internal.CourseResult, error)
 	ListFinishedForUser(userID uint) ([]internal.CourseResult, error)
 	ListResultsForCourse(courseID string) ([]internal.CourseResult, error)
+	HasPassed(userID, courseID uint) (bool, error)
+	ListPassedForUser(userID uint) ([]internal.CourseResult, error)
 }
 type Task interface {
 	SaveTask(task *internal.Task) error
@@ -27,6 +34,11 @@ type Task interface {
 	GetByID(id uint) (*internal.Task, error)
 	GetManyByID(ids []uint) ([]internal.Task, error)
 	RemoveByID(task *internal.Task) error
+	ListWithRemoteImages() ([]internal.Task, error)
+	ReplaceImage(taskID uint, old, image string) (bool, error)
+	ListWithoutAudio(limit int, now time.Time) ([]internal.Task, error)
+	SetAudio(taskID uint, audio string) (bool, error)
+	DeferAudio(taskID uint, attempts int, retryAt time.Time) error
 }
 type User interface {
 	SaveUser(user *internal.User) error
@@ -36,3 +48,111 @@ type User interface {
 	GetByMail(email string) ([]internal.User, error)
 	GetAll() ([]internal.User, error)
 }
+type Mistake interface {
+	SaveMistake(mistake *internal.Mistake) error
+	FindForTask(userID, taskID uint, direction string) (*internal.Mistake, error)
+	ListForUser(userID uint) ([]internal.Mistake, error)
+	RemoveMistake(mistake *internal.Mistake) error
+}
+type Exam interface {
+	SaveExam(exam *internal.Exam) error
+	OpenExam(exam *internal.Exam) (bool, error)
+	CloseExam(id uint) error
+	GetByID(id uint) (*internal.Exam, error)
+	GetLastForUser(userID, courseID uint) (*internal.Exam, error)
+	FinishExam(exam *internal.Exam) (bool, error)
+}
+type Certificate interface {
+	SaveCertificate(certificate *internal.Certificate) error
+	GetByCode(code string) (*internal.Certificate, error)
+}
+type Answer interface {
+	SaveAnswer(answer *internal.Answer) error
+	StatsForCourse(courseID uint) ([]internal.TaskStat, error)
+	StatsForUser(userID, courseID uint) ([]internal.TaskStat, error)
+	TimeForUsers(userIDs, courseIDs []uint) ([]internal.CourseTime, error)
+}
+type Activity interface {
+	AddXP(userID uint, day string, xp int) (*internal.ActivityDay, error)
+	GetDay(userID uint, day string) (*internal.ActivityDay, error)
+}
+type Achievement interface {
+	Unlock(achievement *internal.UserAchievement) (bool, error)
+	ListForUser(userID uint) ([]internal.UserAchievement, error)
+}
+type League interface {
+	SaveLeague(league *internal.League) error
+	CreateLeague(league *internal.League) error
+	SaveMember(member *internal.LeagueMember) error
+	AddXP(memberID uint, xp int) error
+	SaveRanking(member *internal.LeagueMember) error
+	Lock(timeout time.Duration) (func(), error)
+	FindOpen(tier int, week time.Time, size int) (*internal.League, error)
+	FindMember(userID uint, week time.Time) (*internal.LeagueMember, error)
+	GetByID(id uint) (*internal.League, error)
+	GetManyByID(ids []uint) ([]internal.League, error)
+	ListMembers(leagueID uint) ([]internal.LeagueMember, error)
+	ListOpenBefore(week time.Time) ([]internal.League, error)
+	ListHistoryForUser(userID uint) ([]internal.LeagueMember, error)
+}
+type Score interface {
+	AddXP(board Board, userID uint, xp int) error
+	SetXP(board Board, userID uint, xp int) error
+	GetScore(board Board, userID uint) (*internal.ScoreAggregate, error)
+	ListTop(board Board, users []uint, offset, limit int) ([]internal.ScoreAggregate, error)
+	Count(board Board, users []uint) (int, error)
+	CountAhead(board Board, users []uint, score *internal.ScoreAggregate) (int, error)
+}
+type Follow interface {
+	SaveFollow(follow *internal.Follow) error
+	Find(followerID, followeeID uint) (*internal.Follow, error)
+	RemoveFollow(follow *internal.Follow) error
+	ListFollowers(userID uint) ([]uint, error)
+	ListFollowing(userID uint) ([]uint, error)
+}
+type Feed interface {
+	SaveItem(item *internal.FeedItem) error
+	ListShared(authors map[string][]uint, offset, limit int) ([]internal.FeedItem, error)
+}
+type Challenge interface {
+	SaveChallenge(challenge *internal.Challenge) error
+	GetByID(id uint) (*internal.Challenge, error)
+	ListForUser(userID uint) ([]internal.Challenge, error)
+	ListExpired(now time.Time) ([]internal.Challenge, error)
+	FinishSide(challengeID uint, challenger bool, points uint, finishedAt time.Time) (bool, error)
+	Decide(challenge *internal.Challenge) (bool, error)
+}
+type Classroom interface {
+	SaveClassroom(classroom *internal.Classroom) error
+	GetByID(id uint) (*internal.Classroom, error)
+	GetByCode(code string) (*internal.Classroom, error)
+	ListForUser(userID uint) ([]internal.Classroom, error)
+	SaveMember(member *internal.ClassroomMember) error
+	FindMember(classroomID, userID uint) (*internal.ClassroomMember, error)
+	ListMembers(classroomID uint) ([]internal.ClassroomMember, error)
+	SaveAssignment(assignment *internal.Assignment) error
+	ListAssignments(classroomID uint) ([]internal.Assignment, error)
+}
+type Attempt interface {
+	CountAttempt(userID, courseID uint) error
+	Find(userID, courseID uint) (*internal.CourseAttempt, error)
+	ListForUsers(userIDs, courseIDs []uint) ([]internal.CourseAttempt, error)
+}
+type Notification interface {
+	SaveNotification(notification *internal.Notification) error
+	ListForUser(userID uint, unreadOnly bool, offset, limit int) ([]internal.Notification, error)
+	CountUnread(userID uint) (int, error)
+	MarkRead(userID uint, ids []uint) error
+	MarkAllRead(userID uint) error
+	SavePreference(preference *internal.NotificationPreference) error
+	ListPreferences(userID uint) ([]internal.NotificationPreference, error)
+}
+type Image interface {
+	SaveImage(image *internal.Image) error
+	GetByKey(key string) (*internal.Image, error)
+	ListImages() ([]internal.Image, error)
+	RemoveImage(image *internal.Image) error
+	CountReferences() (map[string]int, error)
+	CountVisibility(url string) (private, public int, err error)
+	Lock(timeout time.Duration) (func(), error)
+}
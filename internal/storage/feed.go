@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"github.com/best-project/api/internal"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	"sort"
+	"strings"
+)
+
+type FeedDB struct {
+	db *gorm.DB
+}
+
+func (f *FeedDB) SaveItem(item *internal.FeedItem) error {
+	return f.db.Save(item).Error
+}
+
+// ListShared returns activities of the types by their authors, the latest first
+func (f *FeedDB) ListShared(authors map[string][]uint, offset, limit int) ([]internal.FeedItem, error) {
+	f.db.RLock()
+	defer f.db.RUnlock()
+
+	items := make([]internal.FeedItem, 0)
+	types := make([]string, 0, len(authors))
+	for feedType, userIDs := range authors {
+		if len(userIDs) > 0 {
+			types = append(types, feedType)
+		}
+	}
+	if len(types) == 0 {
+		return items, nil
+	}
+	sort.Strings(types)
+	conditions := make([]string, 0, len(types))
+	args := make([]interface{}, 0, 2*len(types))
+	for _, feedType := range types {
+		conditions = append(conditions, "(type = ? AND user_id IN (?))")
+		args = append(args, feedType, authors[feedType])
+	}
+
+	err := f.db.Where(strings.Join(conditions, " OR "), args...).Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&items).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "while listing feed items")
+	}
+
+	return items, nil
+}
@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"github.com/best-project/api/internal"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+type FollowDB struct {
+	db *gorm.DB
+}
+
+func (f *FollowDB) SaveFollow(follow *internal.Follow) error {
+	return f.db.Save(follow).Error
+}
+
+func (f *FollowDB) Find(followerID, followeeID uint) (*internal.Follow, error) {
+	f.db.RLock()
+	defer f.db.RUnlock()
+
+	follows := make([]internal.Follow, 0)
+	err := f.db.Where(&internal.Follow{FollowerID: followerID, FolloweeID: followeeID}).Limit(1).Find(&follows).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "while finding follow of user %d by %d", followeeID, followerID)
+	}
+	if len(follows) == 0 {
+		return nil, nil
+	}
+
+	return &follows[0], nil
+}
+
+func (f *FollowDB) RemoveFollow(follow *internal.Follow) error {
+	return f.db.Delete(follow).Error
+}
+
+// ListFollowers returns ids of users following the user
+func (f *FollowDB) ListFollowers(userID uint) ([]uint, error) {
+	f.db.RLock()
+	defer f.db.RUnlock()
+
+	ids := make([]uint, 0)
+	err := f.db.Model(&internal.Follow{}).Where("followee_id = ?", userID).Order("created_at DESC").Pluck("follower_id", &ids).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "while listing followers of user %d", userID)
+	}
+
+	return ids, nil
+}
+
+// ListFollowing returns ids of users followed by the user
+func (f *FollowDB) ListFollowing(userID uint) ([]uint, error) {
+	f.db.RLock()
+	defer f.db.RUnlock()
+
+	ids := make([]uint, 0)
+	err := f.db.Model(&internal.Follow{}).Where("follower_id = ?", userID).Order("created_at DESC").Pluck("followee_id", &ids).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "while listing users followed by %d", userID)
+	}
+
+	return ids, nil
+}
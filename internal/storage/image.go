@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"context"
+	"github.com/best-project/api/internal"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	"time"
+)
+
+type ImageDB struct {
+	db *gorm.DB
+}
+
+func (i *ImageDB) SaveImage(image *internal.Image) error {
+	return i.db.Save(image).Error
+}
+
+// GetByKey returns nil when the image is not known
+func (i *ImageDB) GetByKey(key string) (*internal.Image, error) {
+	i.db.RLock()
+	defer i.db.RUnlock()
+
+	images := make([]internal.Image, 0)
+	if err := i.db.Where("blob_key = ?", key).Limit(1).Find(&images).Error; err != nil {
+		return nil, errors.Wrapf(err, "while getting image %s", key)
+	}
+	if len(images) == 0 {
+		return nil, nil
+	}
+
+	return &images[0], nil
+}
+
+func (i *ImageDB) ListImages() ([]internal.Image, error) {
+	i.db.RLock()
+	defer i.db.RUnlock()
+
+	images := make([]internal.Image, 0)
+	if err := i.db.Order("id").Find(&images).Error; err != nil {
+		return nil, errors.Wrap(err, "while listing images")
+	}
+
+	return images, nil
+}
+
+func (i *ImageDB) RemoveImage(image *internal.Image) error {
+	return i.db.Delete(image).Error
+}
+
+type imageReference struct {
+	URL   string
+	Count int
+}
+
+// CountReferences returns how many courses, tasks and users point to each image or audio url
+func (i *ImageDB) CountReferences() (map[string]int, error) {
+	i.db.RLock()
+	defer i.db.RUnlock()
+
+	counts := make(map[string]int)
+	for _, source := range []struct {
+		model  interface{}
+		column string
+	}{
+		{&internal.Course{}, "image"},
+		{&internal.Task{}, "image"},
+		{&internal.Task{}, "audio"},
+		{&internal.User{}, "avatar"},
+	} {
+		references := make([]imageReference, 0)
+		err := i.db.Model(source.model).
+			Select(source.column + " AS url, COUNT(*) AS count").
+			Where(source.column + " <> ''").
+			Group(source.column).
+			Scan(&references).Error
+		if err != nil {
+			return nil, errors.Wrapf(err, "while counting references in %s", source.column)
+		}
+		for _, reference := range references {
+			counts[reference.URL] += reference.Count
+		}
+	}
+
+	return counts, nil
+}
+
+// CountVisibility returns how many private courses and their tasks use the url,
+// and how many public courses, their tasks and users do
+func (i *ImageDB) CountVisibility(url string) (private, public int, err error) {
+	i.db.RLock()
+	defer i.db.RUnlock()
+
+	for _, isPrivate := range []bool{true, false} {
+		courses, tasks := 0, 0
+		err := i.db.Model(&internal.Course{}).Where("private = ? AND image = ?", isPrivate, url).Count(&courses).Error
+		if err != nil {
+			return 0, 0, errors.Wrap(err, "while counting course images")
+		}
+		err = i.db.Model(&internal.Task{}).
+			Joins("JOIN courses ON courses.id = tasks.course_id").
+			Where("courses.private = ? AND (tasks.image = ? OR tasks.audio = ?)", isPrivate, url, url).
+			Count(&tasks).Error
+		if err != nil {
+			return 0, 0, errors.Wrap(err, "while counting task media")
+		}
+		if isPrivate {
+			private = courses + tasks
+		} else {
+			public = courses + tasks
+		}
+	}
+
+	avatars := 0
+	if err := i.db.Model(&internal.User{}).Where("avatar = ?", url).Count(&avatars).Error; err != nil {
+		return 0, 0, errors.Wrap(err, "while counting avatars")
+	}
+	return private, public + avatars, nil
+}
+
+const (
+	imageLockName = "images"
+	// imageLockKey is the PostgreSQL advisory lock, the bytes of "images"
+	imageLockKey int64 = 0x696d61676573
+)
+
+// Lock keeps uploads of every instance from registering an image while the collection removes it
+func (i *ImageDB) Lock(timeout time.Duration) (func(), error) {
+	return acquireLock(context.Background(), i.db, imageLockName, imageLockKey, timeout)
+}
@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"github.com/best-project/api/internal"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	"time"
+)
+
+type LeagueDB struct {
+	db *gorm.DB
+}
+
+func (l *LeagueDB) SaveLeague(league *internal.League) error {
+	return l.db.Save(league).Error
+}
+
+// CreateLeague stores a new cohort of the tier and week, the unique index rejects a cohort created in parallel
+func (l *LeagueDB) CreateLeague(league *internal.League) error {
+	var last struct{ Cohort sql.NullInt64 }
+	err := l.db.Table("leagues").Select("MAX(cohort) AS cohort").
+		Where("tier = ? AND week = ?", league.Tier, league.Week).Scan(&last).Error
+	if err != nil {
+		return errors.Wrapf(err, "while numbering league of tier %d", league.Tier)
+	}
+	league.Cohort = 0
+	if last.Cohort.Valid {
+		league.Cohort = int(last.Cohort.Int64) + 1
+	}
+
+	return l.db.Create(league).Error
+}
+
+func (l *LeagueDB) SaveMember(member *internal.LeagueMember) error {
+	return l.db.Save(member).Error
+}
+
+// AddXP adds the points to the member without overwriting points added in parallel
+func (l *LeagueDB) AddXP(memberID uint, xp int) error {
+	err := l.db.Model(&internal.LeagueMember{}).Where("id = ?", memberID).Update("xp", gorm.Expr("xp + ?", xp)).Error
+	return errors.Wrapf(err, "while adding points to league member %d", memberID)
+}
+
+// SaveRanking stores the rank and result of the member in the closed league
+func (l *LeagueDB) SaveRanking(member *internal.LeagueMember) error {
+	err := l.db.Model(&internal.LeagueMember{}).Where("id = ?", member.ID).
+		Updates(map[string]interface{}{"rank": member.Rank, "result": member.Result}).Error
+	return errors.Wrapf(err, "while saving ranking of league member %d", member.ID)
+}
+
+const (
+	leagueLockName = "leagues"
+	// leagueLockKey is the PostgreSQL advisory lock, the bytes of "leagues"
+	leagueLockKey int64 = 0x6c656167756573
+)
+
+// Lock keeps the instances from joining the same user twice or closing a league while users join it
+func (l *LeagueDB) Lock(timeout time.Duration) (func(), error) {
+	return acquireLock(context.Background(), l.db, leagueLockName, leagueLockKey, timeout)
+}
+
+// FindOpen returns a league of the tier and week which has less than size members
+func (l *LeagueDB) FindOpen(tier int, week time.Time, size int) (*internal.League, error) {
+	l.db.RLock()
+	defer l.db.RUnlock()
+
+	leagues := make([]internal.League, 0)
+	err := l.db.Table("leagues").Select("leagues.*").
+		Joins("LEFT JOIN league_members ON league_members.league_id = leagues.id").
+		Where("leagues.tier = ? AND leagues.week = ? AND leagues.closed = ?", tier, week, false).
+		Group("leagues.id").Having("COUNT(league_members.id) < ?", size).
+		Order("leagues.id").Limit(1).Find(&leagues).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "while finding open league of tier %d", tier)
+	}
+	if len(leagues) == 0 {
+		return nil, nil
+	}
+
+	return &leagues[0], nil
+}
+
+// FindMember returns the membership of the user in a league of the week
+func (l *LeagueDB) FindMember(userID uint, week time.Time) (*internal.LeagueMember, error) {
+	l.db.RLock()
+	defer l.db.RUnlock()
+
+	members := make([]internal.LeagueMember, 0)
+	err := l.db.Table("league_members").Select("league_members.*").
+		Joins("JOIN leagues ON leagues.id = league_members.league_id").
+		Where("league_members.user_id = ? AND leagues.week = ?", userID, week).
+		Limit(1).Find(&members).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "while finding league of user %d", userID)
+	}
+	if len(members) == 0 {
+		return nil, nil
+	}
+
+	return &members[0], nil
+}
+
+func (l *LeagueDB) GetByID(id uint) (*internal.League, error) {
+	l.db.RLock()
+	defer l.db.RUnlock()
+
+	league := &internal.League{}
+	if err := l.db.First(league, id).Error; err != nil {
+		return nil, err
+	}
+
+	return league, nil
+}
+
+func (l *LeagueDB) GetManyByID(ids []uint) ([]internal.League, error) {
+	l.db.RLock()
+	defer l.db.RUnlock()
+
+	leagues := make([]internal.League, 0)
+	if len(ids) == 0 {
+		return leagues, nil
+	}
+	if err := l.db.Where(ids).Find(&leagues).Error; err != nil {
+		return nil, errors.Wrap(err, "while getting leagues")
+	}
+
+	return leagues, nil
+}
+
+// ListMembers returns members of the league starting from the one with most points
+func (l *LeagueDB) ListMembers(leagueID uint) ([]internal.LeagueMember, error) {
+	l.db.RLock()
+	defer l.db.RUnlock()
+
+	members := make([]internal.LeagueMember, 0)
+	err := l.db.Where(&internal.LeagueMember{LeagueID: leagueID}).Order("xp DESC, id").Find(&members).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "while listing members of league %d", leagueID)
+	}
+
+	return members, nil
+}
+
+// ListOpenBefore returns leagues which are not closed and started before the week
+func (l *LeagueDB) ListOpenBefore(week time.Time) ([]internal.League, error) {
+	l.db.RLock()
+	defer l.db.RUnlock()
+
+	leagues := make([]internal.League, 0)
+	if err := l.db.Where("closed = ? AND week < ?", false, week).Find(&leagues).Error; err != nil {
+		return nil, errors.Wrap(err, "while listing open leagues")
+	}
+
+	return leagues, nil
+}
+
+// ListHistoryForUser returns memberships of the user in closed leagues, the latest first
+func (l *LeagueDB) ListHistoryForUser(userID uint) ([]internal.LeagueMember, error) {
+	l.db.RLock()
+	defer l.db.RUnlock()
+
+	members := make([]internal.LeagueMember, 0)
+	err := l.db.Table("league_members").Select("league_members.*").
+		Joins("JOIN leagues ON leagues.id = league_members.league_id").
+		Where("league_members.user_id = ? AND leagues.closed = ?", userID, true).
+		Order("leagues.week DESC").Find(&members).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "while listing league history of user %d", userID)
+	}
+
+	return members, nil
+}
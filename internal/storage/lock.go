@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	"time"
+)
+
+var ErrLocked = errors.New("lock is held by another instance")
+
+// lockPollInterval is the wait between attempts to take a PostgreSQL advisory lock
+const lockPollInterval = 100 * time.Millisecond
+
+// acquireLock takes a lock shared by the instances using the database, MySQL locks are named and
+// PostgreSQL ones are numbered. Both belong to a connection so one is set aside until unlock
+func acquireLock(ctx context.Context, db *gorm.DB, name string, key int64, timeout time.Duration) (func(), error) {
+	dialect := db.Dialect().GetName()
+	if dialect == SQLite {
+		// a database file has one instance and SQLite serializes the writes
+		return func() {}, nil
+	}
+	conn, err := db.DB().Conn(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "while getting connection for the lock")
+	}
+
+	var locked bool
+	var unlock string
+	switch dialect {
+	case MySQL:
+		locked, err = mysqlLock(ctx, conn, name, timeout)
+		unlock = "SELECT RELEASE_LOCK('" + name + "')"
+	case Postgres:
+		locked, err = postgresLock(ctx, conn, key, timeout)
+		unlock = fmt.Sprintf("SELECT pg_advisory_unlock(%d)", key)
+	default:
+		err = errors.Errorf("locks are not supported on %s", dialect)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !locked {
+		conn.Close()
+		return nil, ErrLocked
+	}
+	return func() {
+		conn.ExecContext(ctx, unlock)
+		conn.Close()
+	}, nil
+}
+
+func mysqlLock(ctx context.Context, conn *sql.Conn, name string, timeout time.Duration) (bool, error) {
+	var locked sql.NullInt64
+	seconds := int((timeout + time.Second - 1) / time.Second)
+	err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", name, seconds).Scan(&locked)
+	return locked.Valid && locked.Int64 == 1, err
+}
+
+// postgresLock polls as advisory locks cannot wait with a timeout
+func postgresLock(ctx context.Context, conn *sql.Conn, key int64, timeout time.Duration) (bool, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		var locked bool
+		if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&locked); err != nil {
+			return false, err
+		}
+		if locked || time.Now().After(deadline) {
+			return locked, nil
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
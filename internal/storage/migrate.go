@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"context"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	"sort"
+	"time"
+)
+
+const (
+	migrationLockName = "schema_migrations"
+	// migrationLockKey is the PostgreSQL advisory lock, the bytes of "schema"
+	migrationLockKey int64 = 0x736368656d61
+)
+
+var ErrMigrationLocked = errors.New("another instance is migrating the database")
+
+// Migration changes the schema forward, it runs once and is recorded in schema_migrations.
+// A released migration is never edited, a new one fixes it. MySQL commits DDL right away
+// so Up should be safe to run again after it failed halfway
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(db *gorm.DB) error
+}
+
+// SchemaMigration is a row of schema_migrations
+type SchemaMigration struct {
+	Version   int `gorm:"primary_key;auto_increment:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationStatus tells if the migration is applied, migrations applied by a newer build have no Up
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+	Known     bool
+}
+
+// Migrator applies the migrations of the repository, a database lock lets only one
+// of several replicas starting at once do it
+type Migrator struct {
+	db          *gorm.DB
+	migrations  []Migration
+	lockTimeout time.Duration
+}
+
+func NewMigrator(db *gorm.DB, migrations []Migration, lockTimeout time.Duration) *Migrator {
+	return &Migrator{
+		db:          db,
+		migrations:  migrations,
+		lockTimeout: lockTimeout,
+	}
+}
+
+// Migrate applies the pending migrations in order and returns them
+func (m *Migrator) Migrate() ([]Migration, error) {
+	if err := validateMigrations(m.migrations); err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if err := m.db.AutoMigrate(&SchemaMigration{}).Error; err != nil {
+		return nil, errors.Wrap(err, "while creating schema_migrations")
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	done := make([]Migration, 0)
+	for _, migration := range pendingMigrations(m.migrations, applied) {
+		if err := migration.Up(m.db); err != nil {
+			return done, errors.Wrapf(err, "while applying migration %d %s", migration.Version, migration.Name)
+		}
+		record := &SchemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}
+		if err := m.db.Create(record).Error; err != nil {
+			return done, errors.Wrapf(err, "while recording migration %d", migration.Version)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Status lists the migrations of the repository and the ones found only in the database
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied := make(map[int]SchemaMigration)
+	if m.db.HasTable(&SchemaMigration{}) {
+		var err error
+		if applied, err = m.applied(); err != nil {
+			return nil, err
+		}
+	}
+	return migrationStatus(m.migrations, applied), nil
+}
+
+func (m *Migrator) applied() (map[int]SchemaMigration, error) {
+	rows := make([]SchemaMigration, 0)
+	if err := m.db.Find(&rows).Error; err != nil {
+		return nil, errors.Wrap(err, "while listing applied migrations")
+	}
+	applied := make(map[int]SchemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// lock keeps the lock for the whole migration
+func (m *Migrator) lock(ctx context.Context) (func(), error) {
+	unlock, err := acquireLock(ctx, m.db, migrationLockName, migrationLockKey, m.lockTimeout)
+	if err == ErrLocked {
+		return nil, ErrMigrationLocked
+	}
+	return unlock, errors.Wrap(err, "while taking the migration lock")
+}
+
+func validateMigrations(migrations []Migration) error {
+	for i, migration := range migrations {
+		if migration.Up == nil {
+			return errors.Errorf("migration %d has nothing to apply", migration.Version)
+		}
+		if i > 0 && migration.Version <= migrations[i-1].Version {
+			return errors.Errorf("migration %d is not after %d", migration.Version, migrations[i-1].Version)
+		}
+	}
+	return nil
+}
+
+func pendingMigrations(migrations []Migration, applied map[int]SchemaMigration) []Migration {
+	pending := make([]Migration, 0)
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending
+}
+
+func migrationStatus(migrations []Migration, applied map[int]SchemaMigration) []MigrationStatus {
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	known := make(map[int]bool, len(migrations))
+	for _, migration := range migrations {
+		known[migration.Version] = true
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name, Known: true}
+		if row, ok := applied[migration.Version]; ok {
+			appliedAt := row.AppliedAt
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	for version, row := range applied {
+		if !known[version] {
+			appliedAt := row.AppliedAt
+			statuses = append(statuses, MigrationStatus{Version: version, Name: row.Name, AppliedAt: &appliedAt})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"github.com/jinzhu/gorm"
+	"gopkg.in/go-playground/assert.v1"
+	"testing"
+	"time"
+)
+
+func TestMigrationsAreValid(t *testing.T) {
+	assert.Equal(t, validateMigrations(Migrations), nil)
+}
+
+func TestValidateMigrations(t *testing.T) {
+	up := func(db *gorm.DB) error { return nil }
+
+	assert.NotEqual(t, validateMigrations([]Migration{{Version: 2, Up: up}, {Version: 1, Up: up}}), nil)
+	assert.NotEqual(t, validateMigrations([]Migration{{Version: 1, Up: up}, {Version: 1, Up: up}}), nil)
+	assert.NotEqual(t, validateMigrations([]Migration{{Version: 1}}), nil)
+	assert.Equal(t, validateMigrations([]Migration{{Version: 1, Up: up}, {Version: 3, Up: up}}), nil)
+}
+
+func TestPendingMigrations(t *testing.T) {
+	migrations := []Migration{{Version: 1, Name: "a"}, {Version: 2, Name: "b"}, {Version: 3, Name: "c"}}
+	applied := map[int]SchemaMigration{2: {Version: 2}}
+
+	pending := pendingMigrations(migrations, applied)
+
+	assert.Equal(t, len(pending), 2)
+	assert.Equal(t, pending[0].Name, "a")
+	assert.Equal(t, pending[1].Name, "c")
+}
+
+func TestMigrationStatus(t *testing.T) {
+	appliedAt := time.Date(2019, 12, 10, 12, 0, 0, 0, time.UTC)
+	migrations := []Migration{{Version: 1, Name: "a"}, {Version: 2, Name: "b"}}
+	applied := map[int]SchemaMigration{
+		1: {Version: 1, Name: "a", AppliedAt: appliedAt},
+		5: {Version: 5, Name: "newer", AppliedAt: appliedAt},
+	}
+
+	statuses := migrationStatus(migrations, applied)
+
+	assert.Equal(t, len(statuses), 3)
+	assert.Equal(t, *statuses[0].AppliedAt, appliedAt)
+	assert.Equal(t, statuses[1].AppliedAt, (*time.Time)(nil))
+	assert.Equal(t, statuses[1].Known, true)
+	assert.Equal(t, statuses[2].Name, "newer")
+	assert.Equal(t, statuses[2].Known, false)
+}
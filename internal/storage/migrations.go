@@ -0,0 +1,453 @@
+package storage
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// Migrations are applied in order of their versions, new ones go at the end. A change of a model
+// needs a new migration, changing the tables of a released one does not reach migrated databases
+var Migrations = []Migration{
+	{Version: 1, Name: "create tables", Up: createTables},
+	{Version: 2, Name: "backfill practice direction", Up: backfillDirection},
+	{Version: 3, Name: "add audio retries", Up: addAudioRetries},
+	{Version: 4, Name: "unique user achievements", Up: uniqueUserAchievements},
+	{Version: 5, Name: "one open exam per course", Up: uniqueOpenExams},
+	{Version: 6, Name: "add session max points", Up: addSessionMaxPoints},
+	{Version: 7, Name: "unique activity days", Up: uniqueActivityDays},
+	{Version: 8, Name: "unique league cohorts and members", Up: uniqueLeagueMembers},
+	{Version: 9, Name: "unique scores", Up: uniqueScores},
+}
+
+// table is a table of a migration with a copy of its model at that version, models of the
+// internal package keep changing while a released migration has to create the same schema
+type table struct {
+	name  string
+	model interface{}
+}
+
+// migrateTables creates the tables or adds their missing columns and indexes
+func migrateTables(db *gorm.DB, tables []table) error {
+	for _, t := range tables {
+		if err := db.Table(t.name).AutoMigrate(t.model).Error; err != nil {
+			return errors.Wrapf(err, "while migrating table %s", t.name)
+		}
+	}
+	return nil
+}
+
+// v1Base holds the columns every table of the first version starts with
+type v1Base struct {
+	ID        uint `gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// createTables is the baseline. Databases set up by the former INIT_DB already have the tables
+// and their foreign keys, they only get the columns and indexes added since
+func createTables(db *gorm.DB) error {
+	tables := []table{
+		{"courses", &struct {
+			Base            v1Base `gorm:"embedded"`
+			UserID          uint
+			Name            string
+			Description     string
+			Image           string
+			Language        string
+			DifficultyLevel string
+			Rate            float32
+			RateCounter     int
+			Type            string
+			MaxPoints       int
+			Private         bool
+			PassPercent     float32
+			ExamTimeLimit   int
+			ExamTaskCount   int
+			ExamCooldown    int
+			DifficultyScore float32
+		}{}},
+		{"users", &struct {
+			Base            v1Base `gorm:"embedded"`
+			Email           string
+			FirstName       string
+			LastName        string
+			Password        string
+			Avatar          string
+			Level           int
+			NextLevel       string
+			Points          int
+			TimeZone        string
+			DailyGoal       int
+			CurrentStreak   int
+			LongestStreak   int
+			StreakFreezes   int
+			LastActiveDay   string
+			LeagueTier      int
+			HideResults     bool
+			HideLevelUps    bool
+			HideBadges      bool
+			HideCourses     bool
+			HideFollows     bool
+			ChallengeWins   int
+			ChallengeLosses int
+			ChallengeDraws  int
+		}{}},
+		{"tasks", &struct {
+			Base      v1Base `gorm:"embedded"`
+			CourseID  uint
+			Word      string
+			Translate string
+			Image     string
+			Audio     string
+		}{}},
+		{"course_results", &struct {
+			Base      v1Base `gorm:"embedded"`
+			UserID    uint
+			CourseID  uint
+			Points    uint
+			Phase     string
+			Passed    bool
+			Direction string
+		}{}},
+		{"mistakes", &struct {
+			Base          v1Base `gorm:"embedded"`
+			UserID        uint
+			TaskID        uint
+			CourseID      uint
+			Direction     string
+			Count         int
+			CorrectStreak int
+			LastSeen      time.Time
+		}{}},
+		{"exams", &struct {
+			Base       v1Base `gorm:"embedded"`
+			UserID     uint
+			CourseID   uint
+			TaskIDs    string
+			Deadline   time.Time
+			FinishedAt *time.Time
+			Points     uint
+			MaxPoints  int
+			Passed     bool
+		}{}},
+		{"certificates", &struct {
+			Base      v1Base `gorm:"embedded"`
+			Code      string `gorm:"unique_index"`
+			UserID    uint
+			CourseID  uint
+			ExamID    uint
+			Points    uint
+			MaxPoints int
+		}{}},
+		{"answers", &struct {
+			Base      v1Base `gorm:"embedded"`
+			UserID    uint
+			TaskID    uint
+			CourseID  uint
+			Direction string
+			Correct   bool
+			Duration  int
+		}{}},
+		{"activity_days", &struct {
+			Base   v1Base `gorm:"embedded"`
+			UserID uint
+			Day    string
+			XP     int
+		}{}},
+		{"user_achievements", &struct {
+			Base          v1Base `gorm:"embedded"`
+			UserID        uint
+			AchievementID string
+			UnlockedAt    time.Time
+		}{}},
+		{"leagues", &struct {
+			Base   v1Base `gorm:"embedded"`
+			Tier   int
+			Week   time.Time
+			Closed bool
+		}{}},
+		{"league_members", &struct {
+			Base     v1Base `gorm:"embedded"`
+			LeagueID uint
+			UserID   uint
+			XP       int
+			Rank     int
+			Result   string
+		}{}},
+		{"score_aggregates", &struct {
+			Base    v1Base `gorm:"embedded"`
+			Window  string `gorm:"column:time_window;index:idx_score_board"`
+			Period  string `gorm:"index:idx_score_board"`
+			Scope   string `gorm:"index:idx_score_board"`
+			ScopeID string `gorm:"index:idx_score_board"`
+			UserID  uint
+			XP      int
+		}{}},
+		{"follows", &struct {
+			Base       v1Base `gorm:"embedded"`
+			FollowerID uint   `gorm:"unique_index:idx_follow"`
+			FolloweeID uint   `gorm:"unique_index:idx_follow;index"`
+		}{}},
+		{"feed_items", &struct {
+			Base     v1Base `gorm:"embedded"`
+			UserID   uint   `gorm:"index"`
+			Type     string
+			CourseID uint
+			Value    int
+			Name     string
+		}{}},
+		{"challenges", &struct {
+			Base                 v1Base `gorm:"embedded"`
+			CourseID             uint
+			ChallengerID         uint
+			OpponentID           uint
+			TaskIDs              string
+			MaxPoints            int
+			ExpiresAt            time.Time
+			Status               string
+			ChallengerPoints     uint
+			ChallengerFinishedAt *time.Time
+			OpponentPoints       uint
+			OpponentFinishedAt   *time.Time
+			WinnerID             uint
+		}{}},
+		{"classrooms", &struct {
+			Base      v1Base `gorm:"embedded"`
+			TeacherID uint
+			Name      string
+			JoinCode  string `gorm:"unique_index"`
+		}{}},
+		{"classroom_members", &struct {
+			Base        v1Base `gorm:"embedded"`
+			ClassroomID uint   `gorm:"unique_index:idx_classroom_member"`
+			UserID      uint   `gorm:"unique_index:idx_classroom_member;index"`
+			Role        string
+		}{}},
+		{"assignments", &struct {
+			Base        v1Base `gorm:"embedded"`
+			ClassroomID uint   `gorm:"index"`
+			CourseID    uint
+			DueAt       time.Time
+		}{}},
+		{"course_attempts", &struct {
+			Base     v1Base `gorm:"embedded"`
+			UserID   uint   `gorm:"unique_index:idx_course_attempt"`
+			CourseID uint   `gorm:"unique_index:idx_course_attempt"`
+			Attempts int
+		}{}},
+		{"notifications", &struct {
+			Base     v1Base `gorm:"embedded"`
+			UserID   uint   `gorm:"index"`
+			Type     string
+			ActorID  uint
+			CourseID uint
+			RefID    uint
+			Value    float32
+			Name     string
+			Read     bool `gorm:"column:is_read"`
+		}{}},
+		{"notification_preferences", &struct {
+			Base    v1Base `gorm:"embedded"`
+			UserID  uint   `gorm:"unique_index:idx_notification_preference"`
+			Type    string `gorm:"unique_index:idx_notification_preference"`
+			Enabled bool
+		}{}},
+		{"images", &struct {
+			Base           v1Base `gorm:"embedded"`
+			Key            string `gorm:"column:blob_key;unique_index"`
+			Size           int64
+			ContentType    string
+			RefCount       int
+			UnreferencedAt *time.Time
+		}{}},
+	}
+
+	fresh := !db.HasTable("users")
+	if err := migrateTables(db, tables); err != nil {
+		return err
+	}
+	if fresh {
+		return addRelations(db, v1Relations)
+	}
+	return nil
+}
+
+var v1Relations = []relation{
+	{"courses", "user_id", "users(id)"},
+	{"tasks", "course_id", "courses(id)"},
+	{"course_results", "user_id", "users(id)"},
+	{"course_results", "course_id", "courses(id)"},
+	{"mistakes", "user_id", "users(id)"},
+	{"mistakes", "task_id", "tasks(id)"},
+	{"exams", "user_id", "users(id)"},
+	{"exams", "course_id", "courses(id)"},
+	{"certificates", "user_id", "users(id)"},
+	{"certificates", "course_id", "courses(id)"},
+	{"answers", "user_id", "users(id)"},
+	{"answers", "task_id", "tasks(id)"},
+	{"activity_days", "user_id", "users(id)"},
+	{"user_achievements", "user_id", "users(id)"},
+	{"league_members", "user_id", "users(id)"},
+	{"league_members", "league_id", "leagues(id)"},
+	{"score_aggregates", "user_id", "users(id)"},
+	{"follows", "follower_id", "users(id)"},
+	{"follows", "followee_id", "users(id)"},
+	{"feed_items", "user_id", "users(id)"},
+	{"challenges", "course_id", "courses(id)"},
+	{"challenges", "challenger_id", "users(id)"},
+	{"challenges", "opponent_id", "users(id)"},
+	{"classrooms", "teacher_id", "users(id)"},
+	{"classroom_members", "classroom_id", "classrooms(id)"},
+	{"classroom_members", "user_id", "users(id)"},
+	{"assignments", "classroom_id", "classrooms(id)"},
+	{"assignments", "course_id", "courses(id)"},
+	{"course_attempts", "user_id", "users(id)"},
+	{"course_attempts", "course_id", "courses(id)"},
+	{"notifications", "user_id", "users(id)"},
+	{"notification_preferences", "user_id", "users(id)"},
+}
+
+// backfillDirection sets the forward direction on rows saved before practice directions,
+// queries match the direction so such rows were never replaced or found
+func backfillDirection(db *gorm.DB) error {
+	for _, name := range []string{"course_results", "mistakes", "answers"} {
+		err := db.Exec("UPDATE "+name+" SET direction = ? WHERE direction = '' OR direction IS NULL", "forward").Error
+		if err != nil {
+			return errors.Wrapf(err, "while backfilling direction of %s", name)
+		}
+	}
+	return nil
+}
+
+func addAudioRetries(db *gorm.DB) error {
+	return migrateTables(db, []table{
+		{"tasks", &struct {
+			AudioAttempts int
+			AudioRetryAt  *time.Time `gorm:"index"`
+		}{}},
+	})
+}
+
+// uniqueUserAchievements removes badges unlocked twice and keeps it from happening again
+func uniqueUserAchievements(db *gorm.DB) error {
+	// the derived table lets MySQL read the table it deletes from
+	err := db.Exec(`DELETE FROM user_achievements WHERE id NOT IN (SELECT id FROM (SELECT MIN(id) AS id FROM user_achievements GROUP BY user_id, achievement_id) AS kept)`).Error
+	if err != nil {
+		return errors.Wrap(err, "while removing duplicated achievements")
+	}
+	return migrateTables(db, []table{
+		{"user_achievements", &struct {
+			UserID        uint   `gorm:"unique_index:idx_user_achievement"`
+			AchievementID string `gorm:"unique_index:idx_user_achievement"`
+		}{}},
+	})
+}
+
+// uniqueOpenExams marks the last unfinished exam of every user and course as open,
+// the unique index then keeps parallel requests from starting a second one
+func uniqueOpenExams(db *gorm.DB) error {
+	err := migrateTables(db, []table{
+		{"exams", &struct {
+			UserID   uint  `gorm:"unique_index:idx_open_exam"`
+			CourseID uint  `gorm:"unique_index:idx_open_exam"`
+			Open     *bool `gorm:"column:is_open;unique_index:idx_open_exam"`
+		}{}},
+	})
+	if err != nil {
+		return err
+	}
+	err = db.Exec(`UPDATE exams SET is_open = ? WHERE finished_at IS NULL AND id IN (SELECT id FROM (SELECT MAX(id) AS id FROM exams GROUP BY user_id, course_id) AS last)`, true).Error
+	return errors.Wrap(err, "while marking open exams")
+}
+
+func addSessionMaxPoints(db *gorm.DB) error {
+	return migrateTables(db, []table{
+		{"course_results", &struct {
+			MaxPoints int
+		}{}},
+	})
+}
+
+// uniqueActivityDays merges the points of days saved twice into the first row of the day and keeps
+// it from happening again
+func uniqueActivityDays(db *gorm.DB) error {
+	// derived tables let MySQL read the table it changes
+	err := db.Exec(`UPDATE activity_days SET xp = (SELECT xp FROM (SELECT user_id, day, SUM(xp) AS xp FROM activity_days GROUP BY user_id, day) AS total WHERE total.user_id = activity_days.user_id AND total.day = activity_days.day)`).Error
+	if err != nil {
+		return errors.Wrap(err, "while merging duplicated activity days")
+	}
+	err = db.Exec(`DELETE FROM activity_days WHERE id NOT IN (SELECT id FROM (SELECT MIN(id) AS id FROM activity_days GROUP BY user_id, day) AS kept)`).Error
+	if err != nil {
+		return errors.Wrap(err, "while removing duplicated activity days")
+	}
+	return migrateTables(db, []table{
+		{"activity_days", &struct {
+			UserID uint   `gorm:"unique_index:idx_activity_day"`
+			Day    string `gorm:"unique_index:idx_activity_day"`
+		}{}},
+	})
+}
+
+// uniqueLeagueMembers numbers the cohorts of every tier and week and merges the points of users
+// who joined a league twice, unique indexes keep instances from doing it again
+func uniqueLeagueMembers(db *gorm.DB) error {
+	err := migrateTables(db, []table{
+		{"leagues", &struct {
+			Cohort int
+		}{}},
+	})
+	if err != nil {
+		return err
+	}
+	// identifiers are unique, new cohorts continue after the largest number of their week
+	if err := db.Exec(`UPDATE leagues SET cohort = id`).Error; err != nil {
+		return errors.Wrap(err, "while numbering league cohorts")
+	}
+	// derived tables let MySQL read the table it changes
+	err = db.Exec(`UPDATE league_members SET xp = (SELECT xp FROM (SELECT league_id, user_id, SUM(xp) AS xp FROM league_members GROUP BY league_id, user_id) AS total WHERE total.league_id = league_members.league_id AND total.user_id = league_members.user_id)`).Error
+	if err != nil {
+		return errors.Wrap(err, "while merging duplicated league members")
+	}
+	err = db.Exec(`DELETE FROM league_members WHERE id NOT IN (SELECT id FROM (SELECT MIN(id) AS id FROM league_members GROUP BY league_id, user_id) AS kept)`).Error
+	if err != nil {
+		return errors.Wrap(err, "while removing duplicated league members")
+	}
+	return migrateTables(db, []table{
+		{"leagues", &struct {
+			Tier   int       `gorm:"unique_index:idx_league_cohort"`
+			Week   time.Time `gorm:"unique_index:idx_league_cohort"`
+			Cohort int       `gorm:"unique_index:idx_league_cohort"`
+		}{}},
+		{"league_members", &struct {
+			LeagueID uint `gorm:"unique_index:idx_league_member"`
+			UserID   uint `gorm:"unique_index:idx_league_member"`
+		}{}},
+	})
+}
+
+// uniqueScores merges the points of users counted twice on a board and makes the board index unique
+// with the user, so every instance adds to the same row
+func uniqueScores(db *gorm.DB) error {
+	// derived tables let MySQL read the table it changes
+	err := db.Exec(`UPDATE score_aggregates SET xp = (SELECT xp FROM (SELECT time_window, period, scope, scope_id, user_id, SUM(xp) AS xp FROM score_aggregates GROUP BY time_window, period, scope, scope_id, user_id) AS total WHERE total.time_window = score_aggregates.time_window AND total.period = score_aggregates.period AND total.scope = score_aggregates.scope AND total.scope_id = score_aggregates.scope_id AND total.user_id = score_aggregates.user_id)`).Error
+	if err != nil {
+		return errors.Wrap(err, "while merging duplicated scores")
+	}
+	err = db.Exec(`DELETE FROM score_aggregates WHERE id NOT IN (SELECT id FROM (SELECT MIN(id) AS id FROM score_aggregates GROUP BY time_window, period, scope, scope_id, user_id) AS kept)`).Error
+	if err != nil {
+		return errors.Wrap(err, "while removing duplicated scores")
+	}
+	if err := db.Table("score_aggregates").RemoveIndex("idx_score_board").Error; err != nil {
+		return errors.Wrap(err, "while removing score board index")
+	}
+	return migrateTables(db, []table{
+		{"score_aggregates", &struct {
+			Window  string `gorm:"column:time_window;unique_index:idx_score_board"`
+			Period  string `gorm:"unique_index:idx_score_board"`
+			Scope   string `gorm:"unique_index:idx_score_board"`
+			ScopeID string `gorm:"unique_index:idx_score_board"`
+			UserID  uint   `gorm:"unique_index:idx_score_board"`
+		}{}},
+	})
+}
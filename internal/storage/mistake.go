@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"github.com/best-project/api/internal"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+type MistakeDB struct {
+	db *gorm.DB
+}
+
+func (m *MistakeDB) SaveMistake(mistake *internal.Mistake) error {
+	return m.db.Save(mistake).Error
+}
+
+func (m *MistakeDB) FindForTask(userID, taskID uint, direction string) (*internal.Mistake, error) {
+	m.db.RLock()
+	defer m.db.RUnlock()
+
+	mistakes := make([]internal.Mistake, 0)
+	if err := m.db.Where(&internal.Mistake{UserID: userID, TaskID: taskID, Direction: direction}).Find(&mistakes).Error; err != nil {
+		return nil, errors.Wrapf(err, "while getting mistake for task %d", taskID)
+	}
+	if len(mistakes) == 0 {
+		return nil, nil
+	}
+
+	return &mistakes[0], nil
+}
+
+func (m *MistakeDB) ListForUser(userID uint) ([]internal.Mistake, error) {
+	m.db.RLock()
+	defer m.db.RUnlock()
+
+	mistakes := make([]internal.Mistake, 0)
+	if err := m.db.Where(&internal.Mistake{UserID: userID}).Order("last_seen desc").Find(&mistakes).Error; err != nil {
+		return nil, errors.Wrapf(err, "while listing mistakes for user %d", userID)
+	}
+
+	return mistakes, nil
+}
+
+func (m *MistakeDB) RemoveMistake(mistake *internal.Mistake) error {
+	if mistake.ID == 0 {
+		return errors.New("cannot remove mistake with id 0")
+	}
+
+	return m.db.Delete(mistake).Error
+}
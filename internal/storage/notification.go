@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"github.com/best-project/api/internal"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+type NotificationDB struct {
+	db *gorm.DB
+}
+
+func (n *NotificationDB) SaveNotification(notification *internal.Notification) error {
+	return n.db.Save(notification).Error
+}
+
+// ListForUser returns notifications of the user, the latest first
+func (n *NotificationDB) ListForUser(userID uint, unreadOnly bool, offset, limit int) ([]internal.Notification, error) {
+	n.db.RLock()
+	defer n.db.RUnlock()
+
+	query := n.db.Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("is_read = ?", false)
+	}
+	notifications := make([]internal.Notification, 0)
+	err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&notifications).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "while listing notifications of user %d", userID)
+	}
+
+	return notifications, nil
+}
+
+func (n *NotificationDB) CountUnread(userID uint) (int, error) {
+	n.db.RLock()
+	defer n.db.RUnlock()
+
+	count := 0
+	err := n.db.Model(&internal.Notification{}).Where("user_id = ? AND is_read = ?", userID, false).Count(&count).Error
+	if err != nil {
+		return 0, errors.Wrapf(err, "while counting unread notifications of user %d", userID)
+	}
+
+	return count, nil
+}
+
+// MarkRead skips ids of notifications which belong to other users
+func (n *NotificationDB) MarkRead(userID uint, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return n.db.Model(&internal.Notification{}).Where("user_id = ? AND id IN (?)", userID, ids).UpdateColumn("is_read", true).Error
+}
+
+func (n *NotificationDB) MarkAllRead(userID uint) error {
+	return n.db.Model(&internal.Notification{}).Where("user_id = ? AND is_read = ?", userID, false).UpdateColumn("is_read", true).Error
+}
+
+func (n *NotificationDB) SavePreference(preference *internal.NotificationPreference) error {
+	return n.db.Save(preference).Error
+}
+
+func (n *NotificationDB) ListPreferences(userID uint) ([]internal.NotificationPreference, error) {
+	n.db.RLock()
+	defer n.db.RUnlock()
+
+	preferences := make([]internal.NotificationPreference, 0)
+	if err := n.db.Where("user_id = ?", userID).Find(&preferences).Error; err != nil {
+		return nil, errors.Wrapf(err, "while listing notification preferences of user %d", userID)
+	}
+
+	return preferences, nil
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"github.com/best-project/api/internal"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+type ScoreDB struct {
+	db *gorm.DB
+}
+
+// Board identifies a single leaderboard
+type Board struct {
+	Window  string
+	Period  string
+	Scope   string
+	ScopeID string
+}
+
+// AddXP adds the points to the score of the user on the board without overwriting points added in parallel
+func (s *ScoreDB) AddXP(board Board, userID uint, xp int) error {
+	err := increment(s.db, newScore(board, userID, xp), scoreWhere(board, userID), "xp", xp)
+	return errors.Wrapf(err, "while adding points of user %d", userID)
+}
+
+// SetXP replaces the score of the user on the board
+func (s *ScoreDB) SetXP(board Board, userID uint, xp int) error {
+	err := upsert(s.db, newScore(board, userID, xp), scoreWhere(board, userID), "xp", xp)
+	return errors.Wrapf(err, "while setting points of user %d", userID)
+}
+
+func newScore(board Board, userID uint, xp int) *internal.ScoreAggregate {
+	return &internal.ScoreAggregate{
+		Window:  board.Window,
+		Period:  board.Period,
+		Scope:   board.Scope,
+		ScopeID: board.ScopeID,
+		UserID:  userID,
+		XP:      xp,
+	}
+}
+
+// scoreWhere matches the score of the user, the global scope has an empty ScopeID which a struct would skip
+func scoreWhere(board Board, userID uint) map[string]interface{} {
+	return map[string]interface{}{"time_window": board.Window, "period": board.Period, "scope": board.Scope,
+		"scope_id": board.ScopeID, "user_id": userID}
+}
+
+func (s *ScoreDB) GetScore(board Board, userID uint) (*internal.ScoreAggregate, error) {
+	s.db.RLock()
+	defer s.db.RUnlock()
+
+	scores := make([]internal.ScoreAggregate, 0)
+	err := s.boardQuery(board, nil).Where("user_id = ?", userID).Limit(1).Find(&scores).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "while getting score of user %d", userID)
+	}
+	if len(scores) == 0 {
+		return nil, nil
+	}
+
+	return &scores[0], nil
+}
+
+// ListTop returns scores of the board from the highest, limited to users when users is not nil
+func (s *ScoreDB) ListTop(board Board, users []uint, offset, limit int) ([]internal.ScoreAggregate, error) {
+	s.db.RLock()
+	defer s.db.RUnlock()
+
+	scores := make([]internal.ScoreAggregate, 0)
+	err := s.boardQuery(board, users).Order("xp DESC, user_id").Offset(offset).Limit(limit).Find(&scores).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "while listing scores")
+	}
+
+	return scores, nil
+}
+
+func (s *ScoreDB) Count(board Board, users []uint) (int, error) {
+	s.db.RLock()
+	defer s.db.RUnlock()
+
+	count := 0
+	if err := s.boardQuery(board, users).Model(&internal.ScoreAggregate{}).Count(&count).Error; err != nil {
+		return 0, errors.Wrap(err, "while counting scores")
+	}
+
+	return count, nil
+}
+
+// CountAhead returns the number of scores ordered before the given one
+func (s *ScoreDB) CountAhead(board Board, users []uint, score *internal.ScoreAggregate) (int, error) {
+	s.db.RLock()
+	defer s.db.RUnlock()
+
+	count := 0
+	err := s.boardQuery(board, users).Model(&internal.ScoreAggregate{}).
+		Where("xp > ? OR (xp = ? AND user_id < ?)", score.XP, score.XP, score.UserID).
+		Count(&count).Error
+	if err != nil {
+		return 0, errors.Wrap(err, "while counting scores ahead")
+	}
+
+	return count, nil
+}
+
+func (s *ScoreDB) boardQuery(board Board, users []uint) *gorm.DB {
+	query := s.db.Where("time_window = ? AND period = ? AND scope = ? AND scope_id = ?", board.Window, board.Period, board.Scope, board.ScopeID)
+	if users != nil {
+		query = query.Where("user_id IN (?)", users)
+	}
+	return query
+}
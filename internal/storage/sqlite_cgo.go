@@ -0,0 +1,7 @@
+//go:build cgo
+// +build cgo
+
+package storage
+
+// sqliteSupported tells if the sqlite3 driver is linked, go-sqlite3 is a stub without cgo
+const sqliteSupported = true
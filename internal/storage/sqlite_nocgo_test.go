@@ -0,0 +1,11 @@
+//go:build !cgo
+// +build !cgo
+
+package storage
+
+import "testing"
+
+// TestSQLite reports the storage tests against SQLite as skipped instead of leaving them out silently
+func TestSQLite(t *testing.T) {
+	t.Skip("SQLite tests need cgo, run them with CGO_ENABLED=1")
+}
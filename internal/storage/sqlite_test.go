@@ -0,0 +1,538 @@
+//go:build cgo
+// +build cgo
+
+package storage
+
+import (
+	"github.com/best-project/api/internal"
+	"github.com/best-project/api/internal/config"
+	"github.com/jinzhu/gorm"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/go-playground/assert.v1"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newSQLiteDatabase opens a migrated database in a temporary file, it runs the storage
+// against a real engine without any database server
+func newSQLiteDatabase(t *testing.T) (*Database, *gorm.DB, func()) {
+	dir, err := ioutil.TempDir("", "storage")
+	assert.Equal(t, err, nil)
+	cfg := &config.Config{DbDialect: SQLite, DbName: filepath.Join(dir, "best.db"), MigrationLockTimeout: time.Second}
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+
+	db, err := NewDatabase(cfg, logger)
+	assert.Equal(t, err, nil)
+	applied, err := db.Schema.Migrate()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(applied), len(Migrations))
+
+	// expected constraint errors are logged by gorm otherwise
+	conn := db.Course.(*CourseDB).db.LogMode(false)
+	return db, conn, func() {
+		conn.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestSQLiteMigrationsAreRecorded(t *testing.T) {
+	db, _, cleanup := newSQLiteDatabase(t)
+	defer cleanup()
+
+	applied, err := db.Schema.Migrate()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(applied), 0)
+
+	statuses, err := db.Schema.Status()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(statuses), len(Migrations))
+	for _, status := range statuses {
+		assert.NotEqual(t, status.AppliedAt, (*time.Time)(nil))
+	}
+}
+
+func TestSQLiteBackfillDirection(t *testing.T) {
+	db, conn, cleanup := newSQLiteDatabase(t)
+	defer cleanup()
+
+	user := &internal.User{Email: "a@b.pl"}
+	assert.Equal(t, db.User.SaveUser(user), nil)
+	course := &internal.Course{UserID: user.ID, Name: "Animals"}
+	assert.Equal(t, db.Course.SaveCourse(course, 10), nil)
+	legacy := &internal.CourseResult{UserID: user.ID, CourseID: course.ID, Phase: internal.FinishedPhase, Points: 50}
+	assert.Equal(t, db.CourseResult.SaveResult(legacy), nil)
+
+	assert.Equal(t, backfillDirection(conn), nil)
+	best, points, err := db.CourseResult.ReplaceIfExist(&internal.CourseResult{UserID: user.ID, CourseID: course.ID,
+		Phase: internal.FinishedPhase, Direction: internal.ForwardDirection, Points: 10})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, best.ID, legacy.ID)
+	assert.Equal(t, points, uint(50))
+}
+
+func TestSQLiteListBestResultsPerCourse(t *testing.T) {
+	db, _, cleanup := newSQLiteDatabase(t)
+	defer cleanup()
+
+	user := &internal.User{Email: "a@b.pl"}
+	assert.Equal(t, db.User.SaveUser(user), nil)
+	course := &internal.Course{UserID: user.ID, Name: "Animals"}
+	assert.Equal(t, db.Course.SaveCourse(course, 10), nil)
+	forward := &internal.CourseResult{UserID: user.ID, CourseID: course.ID, Phase: internal.FinishedPhase,
+		Direction: internal.ForwardDirection, Points: 40}
+	assert.Equal(t, db.CourseResult.SaveResult(forward), nil)
+	reverse := &internal.CourseResult{UserID: user.ID, CourseID: course.ID, Phase: internal.FinishedPhase,
+		Direction: internal.ReverseDirection, Points: 70}
+	assert.Equal(t, db.CourseResult.SaveResult(reverse), nil)
+
+	results, err := db.CourseResult.ListBestResultsForUser(user.ID)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(results), 1)
+	assert.Equal(t, results[0].ID, reverse.ID)
+}
+
+func TestSQLiteStartedResultKeepsSessionMaxPoints(t *testing.T) {
+	db, _, cleanup := newSQLiteDatabase(t)
+	defer cleanup()
+
+	user := &internal.User{Email: "a@b.pl"}
+	assert.Equal(t, db.User.SaveUser(user), nil)
+	course := &internal.Course{UserID: user.ID, Name: "Animals"}
+	assert.Equal(t, db.Course.SaveCourse(course, 10), nil)
+
+	started, err := db.CourseResult.GetStartedForUserForCourse(user.ID, course.ID)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, started, (*internal.CourseResult)(nil))
+	session := &internal.CourseResult{UserID: user.ID, CourseID: course.ID, Phase: internal.StartedPhase, MaxPoints: 30}
+	assert.Equal(t, db.CourseResult.SaveResult(session), nil)
+
+	started, err = db.CourseResult.GetStartedForUserForCourse(user.ID, course.ID)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, started.ID, session.ID)
+	assert.Equal(t, started.MaxPoints, 30)
+}
+
+func TestSQLiteListPassedKeepsTheTimeOfPassing(t *testing.T) {
+	db, _, cleanup := newSQLiteDatabase(t)
+	defer cleanup()
+
+	user := &internal.User{Email: "a@b.pl"}
+	assert.Equal(t, db.User.SaveUser(user), nil)
+	course := &internal.Course{UserID: user.ID, Name: "Animals"}
+	assert.Equal(t, db.Course.SaveCourse(course, 10), nil)
+	failed := &internal.CourseResult{UserID: user.ID, CourseID: course.ID, Phase: internal.FinishedPhase, Points: 10}
+	assert.Equal(t, db.CourseResult.SaveResult(failed), nil)
+	passed := &internal.CourseResult{UserID: user.ID, CourseID: course.ID, Phase: internal.FinishedPhase, Points: 90, Passed: true}
+	assert.Equal(t, db.CourseResult.SaveResult(passed), nil)
+	createdAt := passed.CreatedAt
+	// a later attempt saves the best result again
+	assert.Equal(t, db.CourseResult.SaveResult(passed), nil)
+
+	results, err := db.CourseResult.ListPassedForUser(user.ID)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(results), 1)
+	assert.Equal(t, results[0].ID, passed.ID)
+	assert.Equal(t, results[0].CreatedAt.Equal(createdAt), true)
+}
+
+func TestSQLiteReplaceImageKeepsEdits(t *testing.T) {
+	db, _, cleanup := newSQLiteDatabase(t)
+	defer cleanup()
+
+	user := &internal.User{Email: "a@b.pl"}
+	assert.Equal(t, db.User.SaveUser(user), nil)
+	course := &internal.Course{UserID: user.ID, Name: "Animals", Task: []internal.Task{{Word: "cat", Translate: "kot", Image: "https://a.pl/cat.png"}}}
+	assert.Equal(t, db.Course.SaveCourse(course, 10), nil)
+	task := course.Task[0]
+
+	replaced, err := db.Task.ReplaceImage(task.ID, "https://a.pl/other.png", "/images/a.png")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, replaced, false)
+	replaced, err = db.Task.ReplaceImage(task.ID, task.Image, "/images/a.png")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, replaced, true)
+
+	saved, err := db.Task.GetByID(task.ID)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, saved.Image, "/images/a.png")
+	assert.Equal(t, saved.Translate, "kot")
+}
+
+func TestSQLiteListWithoutAudioSkipsDeferredTasks(t *testing.T) {
+	db, _, cleanup := newSQLiteDatabase(t)
+	defer cleanup()
+
+	user := &internal.User{Email: "a@b.pl"}
+	assert.Equal(t, db.User.SaveUser(user), nil)
+	course := &internal.Course{UserID: user.ID, Name: "Animals", Task: []internal.Task{{Word: "cat", Translate: "kot"}, {Word: "dog", Translate: "pies"}}}
+	assert.Equal(t, db.Course.SaveCourse(course, 10), nil)
+	now := time.Date(2019, 12, 9, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, db.Task.DeferAudio(course.Task[0].ID, 1, now.Add(time.Hour)), nil)
+
+	tasks, err := db.Task.ListWithoutAudio(1, now)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(tasks), 1)
+	assert.Equal(t, tasks[0].ID, course.Task[1].ID)
+
+	tasks, err = db.Task.ListWithoutAudio(1, now.Add(time.Hour))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, tasks[0].ID, course.Task[0].ID)
+	assert.Equal(t, tasks[0].AudioAttempts, 1)
+
+	set, err := db.Task.SetAudio(course.Task[0].ID, "/images/cat.mp3")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, set, true)
+	set, err = db.Task.SetAudio(course.Task[0].ID, "/images/other.mp3")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, set, false)
+	task, err := db.Task.GetByID(course.Task[0].ID)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, task.Audio, "/images/cat.mp3")
+	assert.Equal(t, task.AudioAttempts, 0)
+	assert.Equal(t, task.AudioRetryAt, (*time.Time)(nil))
+}
+
+func TestSQLiteChallengeIsDecidedOnce(t *testing.T) {
+	db, _, cleanup := newSQLiteDatabase(t)
+	defer cleanup()
+
+	challenger, opponent := &internal.User{Email: "a@b.pl"}, &internal.User{Email: "c@d.pl"}
+	assert.Equal(t, db.User.SaveUser(challenger), nil)
+	assert.Equal(t, db.User.SaveUser(opponent), nil)
+	course := &internal.Course{UserID: challenger.ID, Name: "Animals"}
+	assert.Equal(t, db.Course.SaveCourse(course, 10), nil)
+	challenge := &internal.Challenge{CourseID: course.ID, ChallengerID: challenger.ID, OpponentID: opponent.ID, Status: internal.PendingChallenge}
+	assert.Equal(t, db.Challenge.SaveChallenge(challenge), nil)
+	stale := *challenge
+
+	now := time.Now()
+	finished, err := db.Challenge.FinishSide(challenge.ID, true, 30, now)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, finished, true)
+	finished, err = db.Challenge.FinishSide(challenge.ID, true, 40, now)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, finished, false)
+
+	// the challenger finished after the expiry job read the challenge
+	stale.Status = internal.ExpiredChallenge
+	decided, err := db.Challenge.Decide(&stale)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, decided, false)
+
+	challenge, err = db.Challenge.GetByID(challenge.ID)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, challenge.ChallengerPoints, uint(30))
+	challenge.Status, challenge.WinnerID = internal.FinishedChallenge, challenger.ID
+	decided, err = db.Challenge.Decide(challenge)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, decided, true)
+	decided, err = db.Challenge.Decide(challenge)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, decided, false)
+}
+
+func TestSQLiteExamIsFinishedOnce(t *testing.T) {
+	db, _, cleanup := newSQLiteDatabase(t)
+	defer cleanup()
+
+	user := &internal.User{Email: "a@b.pl"}
+	assert.Equal(t, db.User.SaveUser(user), nil)
+	course := &internal.Course{UserID: user.ID, Name: "Animals"}
+	assert.Equal(t, db.Course.SaveCourse(course, 10), nil)
+	exam := &internal.Exam{UserID: user.ID, CourseID: course.ID, Deadline: time.Now()}
+	assert.Equal(t, db.Exam.SaveExam(exam), nil)
+
+	now := time.Now()
+	exam.FinishedAt, exam.Points, exam.Passed = &now, 20, true
+	finished, err := db.Exam.FinishExam(exam)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, finished, true)
+	finished, err = db.Exam.FinishExam(exam)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, finished, false)
+
+	exam, err = db.Exam.GetByID(exam.ID)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, exam.Points, uint(20))
+	assert.Equal(t, exam.Passed, true)
+}
+
+func TestSQLiteOneOpenExamPerCourse(t *testing.T) {
+	db, _, cleanup := newSQLiteDatabase(t)
+	defer cleanup()
+
+	user := &internal.User{Email: "a@b.pl"}
+	assert.Equal(t, db.User.SaveUser(user), nil)
+	course := &internal.Course{UserID: user.ID, Name: "Animals"}
+	assert.Equal(t, db.Course.SaveCourse(course, 10), nil)
+	first := &internal.Exam{UserID: user.ID, CourseID: course.ID, Deadline: time.Now()}
+	opened, err := db.Exam.OpenExam(first)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, opened, true)
+	opened, err = db.Exam.OpenExam(&internal.Exam{UserID: user.ID, CourseID: course.ID, Deadline: time.Now()})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, opened, false)
+
+	now := time.Now()
+	first.FinishedAt = &now
+	finished, err := db.Exam.FinishExam(first)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, finished, true)
+	second := &internal.Exam{UserID: user.ID, CourseID: course.ID, Deadline: time.Now()}
+	opened, err = db.Exam.OpenExam(second)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, opened, true)
+
+	// an abandoned exam is closed before the next one starts
+	assert.Equal(t, db.Exam.CloseExam(second.ID), nil)
+	opened, err = db.Exam.OpenExam(&internal.Exam{UserID: user.ID, CourseID: course.ID, Deadline: time.Now()})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, opened, true)
+}
+
+func TestSQLiteAchievementIsUnlockedOnce(t *testing.T) {
+	db, conn, cleanup := newSQLiteDatabase(t)
+	defer cleanup()
+
+	user := &internal.User{Email: "a@b.pl"}
+	assert.Equal(t, db.User.SaveUser(user), nil)
+	unlocked, err := db.Achievement.Unlock(&internal.UserAchievement{UserID: user.ID, AchievementID: "streak-7"})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, unlocked, true)
+	unlocked, err = db.Achievement.Unlock(&internal.UserAchievement{UserID: user.ID, AchievementID: "streak-7"})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, unlocked, false)
+
+	// badges unlocked twice before the index are removed by the migration
+	assert.Equal(t, conn.Exec("DROP INDEX idx_user_achievement").Error, nil)
+	assert.Equal(t, conn.Create(&internal.UserAchievement{UserID: user.ID, AchievementID: "streak-7"}).Error, nil)
+	assert.Equal(t, uniqueUserAchievements(conn), nil)
+	achievements, err := db.Achievement.ListForUser(user.ID)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(achievements), 1)
+	assert.Equal(t, conn.Dialect().HasIndex("user_achievements", "idx_user_achievement"), true)
+}
+
+func TestSQLiteListSharedFeed(t *testing.T) {
+	db, _, cleanup := newSQLiteDatabase(t)
+	defer cleanup()
+
+	first, second := &internal.User{Email: "a@b.pl"}, &internal.User{Email: "c@d.pl"}
+	assert.Equal(t, db.User.SaveUser(first), nil)
+	assert.Equal(t, db.User.SaveUser(second), nil)
+	for _, item := range []internal.FeedItem{
+		{UserID: first.ID, Type: internal.CourseFinishedFeed},
+		{UserID: first.ID, Type: internal.BadgeEarnedFeed},
+		{UserID: second.ID, Type: internal.BadgeEarnedFeed},
+	} {
+		assert.Equal(t, db.Feed.SaveItem(&item), nil)
+	}
+
+	items, err := db.Feed.ListShared(map[string][]uint{
+		internal.CourseFinishedFeed: {first.ID, second.ID},
+		internal.BadgeEarnedFeed:    {second.ID},
+		internal.LevelUpFeed:        {},
+	}, 0, 10)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(items), 2)
+	assert.Equal(t, items[0].UserID, second.ID)
+	assert.Equal(t, items[1].Type, internal.CourseFinishedFeed)
+}
+
+func TestSQLiteActivityDayAddsXP(t *testing.T) {
+	db, conn, cleanup := newSQLiteDatabase(t)
+	defer cleanup()
+
+	user := &internal.User{Email: "a@b.pl"}
+	assert.Equal(t, db.User.SaveUser(user), nil)
+	_, err := db.Activity.AddXP(user.ID, "2019-10-26", 10)
+	assert.Equal(t, err, nil)
+	day, err := db.Activity.AddXP(user.ID, "2019-10-26", 5)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, day.XP, 15)
+	assert.NotEqual(t, conn.Create(&internal.ActivityDay{UserID: user.ID, Day: "2019-10-26"}).Error, nil)
+
+	// days saved twice before the unique index are merged
+	assert.Equal(t, conn.Table("activity_days").RemoveIndex("idx_activity_day").Error, nil)
+	assert.Equal(t, conn.Create(&internal.ActivityDay{UserID: user.ID, Day: "2019-10-26", XP: 7}).Error, nil)
+	assert.Equal(t, uniqueActivityDays(conn), nil)
+	day, err = db.Activity.GetDay(user.ID, "2019-10-26")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, day.XP, 22)
+	count := 0
+	assert.Equal(t, conn.Model(&internal.ActivityDay{}).Count(&count).Error, nil)
+	assert.Equal(t, count, 1)
+}
+
+func TestSQLiteScoresAddXP(t *testing.T) {
+	db, conn, cleanup := newSQLiteDatabase(t)
+	defer cleanup()
+
+	user := &internal.User{Email: "a@b.pl"}
+	assert.Equal(t, db.User.SaveUser(user), nil)
+	board := Board{Window: internal.AllWindow, Period: internal.AllWindow, Scope: internal.GlobalScope}
+	assert.Equal(t, db.Score.AddXP(board, user.ID, 10), nil)
+	assert.Equal(t, db.Score.AddXP(board, user.ID, 5), nil)
+	score, err := db.Score.GetScore(board, user.ID)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, score.XP, 15)
+	assert.NotEqual(t, conn.Create(&internal.ScoreAggregate{Window: board.Window, Period: board.Period, Scope: board.Scope,
+		UserID: user.ID}).Error, nil)
+
+	assert.Equal(t, db.Score.SetXP(board, user.ID, 40), nil)
+	count, err := db.Score.Count(board, nil)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, 1)
+	score, err = db.Score.GetScore(board, user.ID)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, score.XP, 40)
+}
+
+func TestSQLiteCountAttempts(t *testing.T) {
+	db, _, cleanup := newSQLiteDatabase(t)
+	defer cleanup()
+
+	user := &internal.User{Email: "a@b.pl"}
+	assert.Equal(t, db.User.SaveUser(user), nil)
+	course := &internal.Course{UserID: user.ID, Name: "Animals"}
+	assert.Equal(t, db.Course.SaveCourse(course, 10), nil)
+	assert.Equal(t, db.Attempt.CountAttempt(user.ID, course.ID), nil)
+	assert.Equal(t, db.Attempt.CountAttempt(user.ID, course.ID), nil)
+
+	attempts, err := db.Attempt.ListForUsers([]uint{user.ID}, []uint{course.ID})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(attempts), 1)
+	assert.Equal(t, attempts[0].Attempts, 2)
+}
+
+// TestSQLiteMigrationsMatchModels fails when a model got a column or an index without a migration adding it
+func TestSQLiteMigrationsMatchModels(t *testing.T) {
+	_, conn, cleanup := newSQLiteDatabase(t)
+	defer cleanup()
+
+	models := []interface{}{&internal.Course{}, &internal.User{}, &internal.Task{}, &internal.CourseResult{}, &internal.Mistake{},
+		&internal.Exam{}, &internal.Certificate{}, &internal.Answer{},
+		&internal.ActivityDay{}, &internal.UserAchievement{}, &internal.League{}, &internal.LeagueMember{}, &internal.ScoreAggregate{},
+		&internal.Follow{}, &internal.FeedItem{}, &internal.Challenge{},
+		&internal.Classroom{}, &internal.ClassroomMember{}, &internal.Assignment{}, &internal.CourseAttempt{},
+		&internal.Notification{}, &internal.NotificationPreference{}, &internal.Image{}}
+	for _, model := range models {
+		scope := conn.NewScope(model)
+		table := scope.TableName()
+		assert.Equal(t, scope.Dialect().HasTable(table), true)
+		for _, field := range scope.GetModelStruct().StructFields {
+			if !field.IsNormal || field.IsIgnored {
+				continue
+			}
+			if !scope.Dialect().HasColumn(table, field.DBName) {
+				t.Errorf("column %s.%s has no migration", table, field.DBName)
+			}
+			for _, setting := range []string{"INDEX", "UNIQUE_INDEX"} {
+				name, ok := field.TagSettingsGet(setting)
+				if !ok {
+					continue
+				}
+				if name == setting {
+					name = strings.ToLower(map[string]string{"INDEX": "idx", "UNIQUE_INDEX": "uix"}[setting]) + "_" + table + "_" + field.DBName
+				}
+				if !scope.Dialect().HasIndex(table, name) {
+					t.Errorf("index %s of %s has no migration", name, table)
+				}
+			}
+		}
+	}
+}
+
+func TestSQLiteForeignKeys(t *testing.T) {
+	db, conn, cleanup := newSQLiteDatabase(t)
+	defer cleanup()
+
+	user := &internal.User{Email: "a@b.pl"}
+	assert.Equal(t, db.User.SaveUser(user), nil)
+	course := &internal.Course{UserID: user.ID, Name: "Animals", Task: []internal.Task{{Word: "cat", Translate: "kot"}, {Word: "dog", Translate: "pies"}}}
+	assert.Equal(t, db.Course.SaveCourse(course, 10), nil)
+	assert.Equal(t, course.MaxPoints, 20)
+
+	orphan := &internal.Task{CourseID: course.ID + 100, Word: "bird", Translate: "ptak"}
+	assert.NotEqual(t, db.Task.SaveTask(orphan), nil)
+
+	// removing the author removes the course and its tasks
+	assert.Equal(t, conn.Delete(user).Error, nil)
+	tasks := 0
+	assert.Equal(t, conn.Model(&internal.Task{}).Count(&tasks).Error, nil)
+	assert.Equal(t, tasks, 0)
+	assert.Equal(t, db.Course.Exist(strconv.Itoa(int(course.ID))), false)
+}
+
+func TestSQLiteImageReferences(t *testing.T) {
+	db, _, cleanup := newSQLiteDatabase(t)
+	defer cleanup()
+
+	user := &internal.User{Email: "a@b.pl", Avatar: "/images/shared.png"}
+	assert.Equal(t, db.User.SaveUser(user), nil)
+	assert.Equal(t, db.Course.SaveCourse(&internal.Course{UserID: user.ID, Name: "Public", Image: "/images/shared.png"}, 10), nil)
+	assert.Equal(t, db.Course.SaveCourse(&internal.Course{UserID: user.ID, Name: "Private", Private: true, Image: "/images/secret.png",
+		Task: []internal.Task{{Word: "cat", Translate: "kot", Audio: "/images/secret.png"}}}, 10), nil)
+
+	counts, err := db.Image.CountReferences()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, counts["/images/shared.png"], 2)
+	assert.Equal(t, counts["/images/secret.png"], 2)
+
+	private, public, err := db.Image.CountVisibility("/images/secret.png")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, private, 2)
+	assert.Equal(t, public, 0)
+
+	private, public, err = db.Image.CountVisibility("/images/shared.png")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, private, 0)
+	assert.Equal(t, public, 2)
+}
+
+func TestSQLiteAggregates(t *testing.T) {
+	db, conn, cleanup := newSQLiteDatabase(t)
+	defer cleanup()
+
+	user := &internal.User{Email: "a@b.pl"}
+	assert.Equal(t, db.User.SaveUser(user), nil)
+	course := &internal.Course{UserID: user.ID, Name: "Animals", Task: []internal.Task{{Word: "cat", Translate: "kot"}}}
+	assert.Equal(t, db.Course.SaveCourse(course, 10), nil)
+	taskID := course.Task[0].ID
+	for _, answer := range []internal.Answer{{Correct: true, Duration: 1000}, {Correct: false, Duration: 3000}} {
+		answer.UserID, answer.CourseID, answer.TaskID = user.ID, course.ID, taskID
+		assert.Equal(t, db.Answer.SaveAnswer(&answer), nil)
+	}
+
+	stats, err := db.Answer.StatsForCourse(course.ID)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, stats, []internal.TaskStat{{TaskID: taskID, Attempts: 2, Correct: 1, AvgDuration: 2000}})
+
+	week := time.Date(2019, 12, 9, 0, 0, 0, 0, time.UTC)
+	league := &internal.League{Tier: 1, Week: week}
+	assert.Equal(t, db.League.CreateLeague(league), nil)
+	member := &internal.LeagueMember{LeagueID: league.ID, UserID: user.ID}
+	assert.Equal(t, db.League.SaveMember(member), nil)
+	assert.NotEqual(t, conn.Create(&internal.LeagueMember{LeagueID: league.ID, UserID: user.ID}).Error, nil)
+	assert.Equal(t, db.League.AddXP(member.ID, 10), nil)
+	assert.Equal(t, db.League.AddXP(member.ID, 5), nil)
+	members, err := db.League.ListMembers(league.ID)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, members[0].XP, 15)
+
+	open, err := db.League.FindOpen(1, week, 2)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, open.ID, league.ID)
+	open, err = db.League.FindOpen(1, week, 1)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, open, (*internal.League)(nil))
+	next := &internal.League{Tier: 1, Week: week}
+	assert.Equal(t, db.League.CreateLeague(next), nil)
+	assert.Equal(t, next.Cohort, league.Cohort+1)
+}
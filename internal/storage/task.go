@@ -5,6 +5,7 @@ import (
 	"github.com/best-project/api/internal"
 	"github.com/jinzhu/gorm"
 	"github.com/pkg/errors"
+	"time"
 )
 
 type TaskDB struct {
@@ -74,3 +75,61 @@ func (c *TaskDB) RemoveByID(task *internal.Task) error {
 
 	return c.db.Delete(task).Error
 }
+
+// ListWithRemoteImages returns tasks whose image is an absolute http or https url
+func (c *TaskDB) ListWithRemoteImages() ([]internal.Task, error) {
+	c.db.RLock()
+	defer c.db.RUnlock()
+
+	tasks := make([]internal.Task, 0)
+	if err := c.db.Where("image LIKE ? OR image LIKE ?", "http://%", "https://%").Order("id").Find(&tasks).Error; err != nil {
+		return nil, errors.Wrap(err, "while listing tasks with remote images")
+	}
+
+	return tasks, nil
+}
+
+// ReplaceImage sets the image of the task unless it changed from old since the task was read,
+// it returns false then. Only the image is written so a parallel edit of the task is kept
+func (c *TaskDB) ReplaceImage(taskID uint, old, image string) (bool, error) {
+	result := c.db.Model(&internal.Task{}).Where("id = ? AND image = ?", taskID, old).Update("image", image)
+	if result.Error != nil {
+		return false, errors.Wrapf(result.Error, "while replacing image of task %d", taskID)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ListWithoutAudio returns up to limit tasks without a pronunciation, the oldest first.
+// Tasks which failed before are skipped until their retry time
+func (c *TaskDB) ListWithoutAudio(limit int, now time.Time) ([]internal.Task, error) {
+	c.db.RLock()
+	defer c.db.RUnlock()
+
+	tasks := make([]internal.Task, 0)
+	err := c.db.Where("audio = ? AND word <> ? AND (audio_retry_at IS NULL OR audio_retry_at <= ?)", "", "", now).
+		Order("id").Limit(limit).Find(&tasks).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "while listing tasks without audio")
+	}
+
+	return tasks, nil
+}
+
+// SetAudio stores the pronunciation of a task which has none yet, it returns false when a parallel
+// edit or another instance set one first. The other columns of the task are left untouched
+func (c *TaskDB) SetAudio(taskID uint, audio string) (bool, error) {
+	result := c.db.Model(&internal.Task{}).Where("id = ? AND audio = ?", taskID, "").
+		Updates(map[string]interface{}{"audio": audio, "audio_attempts": 0, "audio_retry_at": gorm.Expr("NULL")})
+	if result.Error != nil {
+		return false, errors.Wrapf(result.Error, "while setting audio of task %d", taskID)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// DeferAudio records a failed synthesis of the task audio
+func (c *TaskDB) DeferAudio(taskID uint, attempts int, retryAt time.Time) error {
+	return c.db.Model(&internal.Task{ID: taskID}).UpdateColumns(map[string]interface{}{
+		"audio_attempts": attempts,
+		"audio_retry_at": retryAt,
+	}).Error
+}
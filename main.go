@@ -1,16 +1,31 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"flag"
 	"fmt"
+	"github.com/best-project/api/internal"
 	"github.com/best-project/api/internal/config"
+	"github.com/best-project/api/internal/event"
+	"github.com/best-project/api/internal/jobs"
+	"github.com/best-project/api/internal/live"
+	"github.com/best-project/api/internal/media"
 	"github.com/best-project/api/internal/server"
 	"github.com/best-project/api/internal/service"
 	"github.com/best-project/api/internal/storage"
 	"github.com/madebyais/facebook-go-sdk"
 	"github.com/sirupsen/logrus"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 )
 
+const shutdownTimeout = 10 * time.Second
+
 func main() {
 	cfg, err := config.NewConfig()
 	fatalOnError(err)
@@ -24,10 +39,248 @@ func main() {
 
 	db, err := storage.NewDatabase(cfg, logger)
 	fatalOnError(err)
+	// handled before anything else touches the schema, the status has to show it as it is
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		migrate(db.Schema, os.Args[2:], logger)
+		return
+	}
+	if cfg.MigrateOnStart {
+		applyMigrations(db.Schema, logger)
+	}
+	blobs, err := storage.NewBlobStore(cfg)
+	fatalOnError(err)
+
+	rules, err := service.LoadAchievementRules(cfg.AchievementsPath)
+	fatalOnError(err)
+
+	xp := service.XPRules{
+		PerTask: map[string]int{
+			internal.NormalType: cfg.XpPerTask,
+			internal.PuzzleType: cfg.XpPerPuzzleTask,
+		},
+		PerfectBonus:         cfg.XpPerfectBonus,
+		FirstCompletionBonus: cfg.XpFirstCompletionBonus,
+		RepeatPercent:        cfg.XpRepeatPercent,
+	}
+	curve, err := service.NewLevelCurve(service.LevelCurveConfig{
+		Name:     cfg.LevelCurve,
+		Base:     cfg.LevelCurveBase,
+		Step:     cfg.LevelCurveStep,
+		Factor:   cfg.LevelCurveFactor,
+		Exponent: cfg.LevelCurveExponent,
+		Table:    cfg.LevelTable,
+	})
+	fatalOnError(err)
+
+	bus := event.NewBus(logger)
+	streakLogic := service.NewStreakLogic(db, cfg.DailyGoal, cfg.StreakFreezeEvery, cfg.StreakFreezeMax)
+	courseLogic := service.NewCourseLogic(db, cfg.PassPercent, xp, curve, streakLogic, bus)
+
+	socialLogic := service.NewSocialLogic(db)
+	socialLogic.Subscribe(bus)
+	leaderboardLogic := service.NewLeaderboardLogic(db)
+	leaderboardLogic.Subscribe(bus)
+	leaderboardLogic.RegisterGroup(internal.FriendsScope, socialLogic.Friends)
+	classroomLogic := service.NewClassroomLogic(db, bus)
+	classroomLogic.Subscribe(bus)
+	leaderboardLogic.RegisterGroup(internal.ClassroomScope, classroomLogic.Members)
+	notificationLogic := service.NewNotificationLogic(db)
+	notificationLogic.Subscribe(bus)
+	imageLogic := service.NewImageLogic(db, blobs, cfg.ImageGCGrace)
+	imageLimits := media.ImageLimits{
+		MaxBytes:  cfg.ImageMaxBytes,
+		MaxWidth:  cfg.ImageMaxWidth,
+		MaxHeight: cfg.ImageMaxHeight,
+	}
+	importLogic := service.NewImportLogic(db, imageLogic, media.NewFetcher(imageLimits, cfg.ImageImportTimeout))
+	speech, err := service.NewSpeechProvider(service.SpeechSettings{
+		Provider: cfg.SpeechProvider,
+		URL:      cfg.SpeechURL,
+		Timeout:  cfg.SpeechTimeout,
+		MaxBytes: cfg.AudioMaxBytes,
+	})
+	fatalOnError(err)
+	audioLogic := service.NewAudioLogic(db, imageLogic, speech, cfg.AudioMaxBytes)
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "recompute-levels":
+			updated, err := courseLogic.RecomputeLevels()
+			fatalOnError(err)
+			logger.Infof("Recomputed levels, %d users updated", updated)
+		case "backfill-leaderboard":
+			fatalOnError(leaderboardLogic.BackfillAllTime())
+			logger.Info("Filled the all-time leaderboard with user points")
+		case "collect-images":
+			flags := flag.NewFlagSet("collect-images", flag.ExitOnError)
+			dryRun := flags.Bool("dry-run", false, "only report the images which would be removed")
+			fatalOnError(flags.Parse(os.Args[2:]))
+			report, err := imageLogic.Collect(time.Now(), *dryRun)
+			fatalOnError(err)
+			logImageReport(logger, report)
+		case "import-images":
+			report, err := importLogic.ImportTaskImages(context.Background())
+			logImportReport(logger, report)
+			fatalOnError(err)
+		case "seed":
+			paths := os.Args[2:]
+			if len(paths) == 0 {
+				paths = strings.Split(cfg.SeedFixtures, ",")
+			}
+			fixtures, err := service.LoadFixtures(paths...)
+			fatalOnError(err)
+			report, err := service.NewSeedLogic(db, xp, cfg.SeedPassword).Seed(fixtures)
+			logSeedReport(logger, report)
+			fatalOnError(err)
+		case "synthesize-audio":
+			report, err := audioLogic.FillMissing(context.Background(), cfg.SpeechBatch, time.Now())
+			logSpeechReport(logger, report)
+			fatalOnError(err)
+		default:
+			logrus.Fatalf("unknown command %s", os.Args[1])
+		}
+		return
+	}
+
+	achievementLogic := service.NewAchievementLogic(db, rules, courseLogic, bus)
+	achievementLogic.Subscribe(bus)
+	examLogic := service.NewExamLogic(db, courseLogic, xp)
+
+	cutoffDay, err := service.ParseWeekday(cfg.LeagueCutoffDay)
+	fatalOnError(err)
+	leagueLogic := service.NewLeagueLogic(db, service.LeagueSettings{
+		CohortSize: cfg.LeagueCohortSize,
+		Tiers:      cfg.LeagueTiers,
+		Promote:    cfg.LeaguePromote,
+		Relegate:   cfg.LeagueRelegate,
+		CutoffDay:  cutoffDay,
+		CutoffHour: cfg.LeagueCutoffHour,
+	})
+	leagueLogic.Subscribe(bus)
+	challengeLogic := service.NewChallengeLogic(db, courseLogic, xp, service.ChallengeSettings{
+		TaskCount:     cfg.ChallengeTaskCount,
+		DefaultExpiry: cfg.ChallengeDefaultExpiry,
+		MaxExpiry:     cfg.ChallengeMaxExpiry,
+	}, bus)
+
+	runner := jobs.NewRunner(logger)
+	runner.Add("close-league-weeks", cfg.LeagueJobPeriod, leagueLogic.CloseWeeks)
+	runner.Add("settle-expired-challenges", cfg.ChallengeJobPeriod, challengeLogic.SettleExpired)
+	runner.Add("collect-images", cfg.ImageGCPeriod, func(now time.Time) error {
+		report, err := imageLogic.Collect(now, false)
+		if err == nil {
+			logImageReport(logger, report)
+		}
+		return err
+	})
+	runner.Add("import-images", cfg.ImageImportPeriod, func(now time.Time) error {
+		report, err := importLogic.ImportTaskImages(context.Background())
+		logImportReport(logger, report)
+		return err
+	})
+	if speech != nil {
+		runner.Add("synthesize-audio", cfg.SpeechPeriod, func(now time.Time) error {
+			report, err := audioLogic.FillMissing(context.Background(), cfg.SpeechBatch, now)
+			logSpeechReport(logger, report)
+			return err
+		})
+	}
+	runner.Start()
+	defer runner.Stop()
+
+	hub := live.NewHub(live.Settings{
+		QuestionTime: cfg.LiveQuestionTime,
+		RevealTime:   cfg.LiveRevealTime,
+		LobbyTimeout: cfg.LiveLobbyTimeout,
+		MaxPoints:    cfg.LiveMaxPoints,
+		MaxPlayers:   cfg.LiveMaxPlayers,
+	})
+
+	signer := media.NewURLSigner(mediaSigningKey(cfg, logger), cfg.MediaURLTTL)
+	srv := server.NewServer(server.Deps{
+		DB:            db,
+		Facebook:      fb,
+		Courses:       courseLogic,
+		Answers:       service.NewAnswerLogic(db, cfg.MistakeStreak),
+		Exams:         examLogic,
+		Difficulty:    service.NewDifficultyLogic(db),
+		Streaks:       streakLogic,
+		Achievements:  achievementLogic,
+		Leagues:       leagueLogic,
+		Leaderboard:   leaderboardLogic,
+		Social:        socialLogic,
+		Challenges:    challengeLogic,
+		Classrooms:    classroomLogic,
+		Live:          hub,
+		Blobs:         blobs,
+		ImageLimits:   imageLimits,
+		Images:        imageLogic,
+		Imports:       importLogic,
+		Audio:         audioLogic,
+		AudioMaxBytes: cfg.AudioMaxBytes,
+		Signer:        signer,
+		Notifications: notificationLogic,
+		XP:            xp,
+		Events:        bus,
+		Logger:        logger,
+	})
+	httpServer := &http.Server{Addr: fmt.Sprintf(":%s", cfg.Port), Handler: srv.Handle()}
+	// stopped is closed once the requests in flight are done
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+
+		logger.Info("===Stopping Server===")
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := httpServer.Shutdown(ctx); err != nil {
+			logger.Errorf("while stopping server: %s", err)
+		}
+	}()
 
-	srv := server.NewServer(db, fb, service.NewCourseLogic(db, cfg.PassPercent), logger)
 	logger.Info("===Starting Server===")
-	fatalOnError(http.ListenAndServe(fmt.Sprintf(":%s", cfg.Port), srv.Handle()))
+	if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+		fatalOnError(err)
+	}
+	// ListenAndServe returns as soon as the shutdown starts
+	<-stopped
+	// live rooms run on hijacked connections which the server does not track
+	hub.Shutdown()
+}
+
+func logImageReport(logger logrus.FieldLogger, report *service.ImageReport) {
+	verb := "Removed"
+	if report.DryRun {
+		verb = "Would remove"
+	}
+	for _, key := range report.Removed {
+		logger.Infof("%s image %s", verb, key)
+	}
+	logger.Infof("%s %d of %d images (%d bytes), %d referenced, %d waiting for the grace period",
+		verb, len(report.Removed), report.Images, report.FreedBytes, report.Referenced, report.Waiting)
+}
+
+func logImportReport(logger logrus.FieldLogger, report *service.ImportReport) {
+	if report == nil {
+		return
+	}
+	for _, failure := range report.Failed {
+		logger.Warnf("Cannot import image of task %d from %s: %s", failure.TaskID, failure.URL, failure.Reason)
+	}
+	logger.Infof("Imported %d task images, %d failed", report.Imported, len(report.Failed))
+}
+
+func logSpeechReport(logger logrus.FieldLogger, report *service.SpeechReport) {
+	if report == nil {
+		return
+	}
+	for _, failure := range report.Failed {
+		logger.Warnf("Cannot synthesize audio of task %d, retrying at %s: %s", failure.TaskID, failure.RetryAt.Format(time.RFC3339), failure.Reason)
+	}
+	logger.Infof("Synthesized audio of %d tasks, %d failed", report.Synthesized, len(report.Failed))
 }
 
 func fatalOnError(err error) {
@@ -35,3 +288,62 @@ func fatalOnError(err error) {
 		logrus.Fatal(err.Error())
 	}
 }
+
+func logSeedReport(logger *logrus.Logger, report *service.SeedReport) {
+	logger.Infof("Seeded %d users and %d courses, %d users and %d courses already existed",
+		report.Users, report.Courses, report.SkippedUsers, report.SkippedCourses)
+	if report.GeneratedPassword != "" {
+		// printed rather than logged, it is not stored anywhere and will not be shown again
+		fmt.Printf("Generated password of %s: %s\n", strings.Join(report.GeneratedFor, ", "), report.GeneratedPassword)
+	}
+}
+
+func migrate(schema *storage.Migrator, args []string, logger *logrus.Logger) {
+	command := ""
+	if len(args) > 0 {
+		command = args[0]
+	}
+	switch command {
+	case "", "up":
+		applyMigrations(schema, logger)
+	case "status":
+		statuses, err := schema.Status()
+		fatalOnError(err)
+		for _, status := range statuses {
+			state := "pending"
+			if status.AppliedAt != nil {
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if !status.Known {
+				state += ", unknown to this build"
+			}
+			fmt.Printf("%4d  %-30s %s\n", status.Version, status.Name, state)
+		}
+	default:
+		logrus.Fatalf("unknown migrate command %s", command)
+	}
+}
+
+func applyMigrations(schema *storage.Migrator, logger *logrus.Logger) {
+	applied, err := schema.Migrate()
+	for _, migration := range applied {
+		logger.Infof("Applied migration %d %s", migration.Version, migration.Name)
+	}
+	fatalOnError(err)
+}
+
+// mediaSigningKey fails unless the key is configured, instances sharing a database must verify each others urls.
+// A single SQLite instance may run with a random key, its signed urls stop working after a restart
+func mediaSigningKey(cfg *config.Config, logger *logrus.Logger) []byte {
+	if cfg.MediaSigningKey != "" {
+		return []byte(cfg.MediaSigningKey)
+	}
+	if cfg.DbDialect != storage.SQLite {
+		logger.Fatalf("MEDIA_SIGNING_KEY is required with the %s database", cfg.DbDialect)
+	}
+	logger.Warnln("MEDIA_SIGNING_KEY is not set, signed media urls will not survive a restart")
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	fatalOnError(err)
+	return key
+}